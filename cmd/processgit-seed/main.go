@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -15,6 +17,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"code.gitea.io/gitea/models"
@@ -24,10 +27,14 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/gitrepo"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/mcp"
 	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/uapf"
 	"code.gitea.io/gitea/modules/util"
 	repo_service "code.gitea.io/gitea/services/repository"
+
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -36,13 +43,49 @@ const (
 	templateConfigPath         = "/opt/processgit/bootstrap/template-repos.json"
 	templateCommitName         = "ProcessGit Templates"
 	templateCommitEmail        = "templates@processgit.org"
-	templateClassificationType = "template"
+	templateClassificationType = repo_model.RepoClassificationTypeTemplate
+	templateTempDirPrefix      = "template-seed-"
 )
 
 type templateRepoConfig struct {
 	Name        string `json:"name"`
 	Path        string `json:"path"`
 	Description string `json:"description"`
+	// Private hides the template from users without access to the templates owner. Like the
+	// description, it is applied to an existing repo whenever its entry is imported.
+	Private bool `json:"private"`
+
+	// Classification of the seeded repo, "template" and "draft" when omitted.
+	// ReferenceKind is only allowed for the "reference" type.
+	ClassificationType string `json:"classification_type"`
+	Status             string `json:"status"`
+	ReferenceKind      string `json:"reference_kind"`
+	// UAPFLevel is required for process repos that aren't drafts and not allowed for
+	// reference and connector repos, see repo_model.ValidateUAPFLevelForType.
+	UAPFLevel *int `json:"uapf_level"`
+}
+
+// classification returns the classification type and status of the template repo with the defaults applied.
+func (cfg templateRepoConfig) classification() (repoType, status string) {
+	return util.IfZero(cfg.ClassificationType, templateClassificationType), util.IfZero(cfg.Status, repo_model.RepoClassificationStatusDraft)
+}
+
+// validateClassification checks the classification fields with the same rules as classifications edited in the UI.
+func (cfg templateRepoConfig) validateClassification() error {
+	repoType, status := cfg.classification()
+	if err := repo_model.ValidateRepoType(repoType); err != nil {
+		return err
+	}
+	if err := repo_model.ValidateStatus(status); err != nil {
+		return err
+	}
+	// like the model, drafts may still lack a required level
+	if cfg.UAPFLevel != nil || status != repo_model.RepoClassificationStatusDraft {
+		if err := repo_model.ValidateUAPFLevelForType(cfg.UAPFLevel, repoType); err != nil {
+			return err
+		}
+	}
+	return repo_model.ValidateReferenceKind(cfg.ReferenceKind, repoType)
 }
 
 func main() {
@@ -77,12 +120,17 @@ func run() error {
 		return fmt.Errorf("init git: %w", err)
 	}
 
-	if _, err := os.Stat(templateMarkerPath); err == nil {
-		seedLogf("Templates already bootstrapped; skipping")
-		return nil
-	} else if !errors.Is(err, os.ErrNotExist) {
+	forceContent, err := parseSeedForceContent()
+	if err != nil {
+		return err
+	}
+
+	// the marker holds the hash of the template config it was written for
+	marker, err := os.ReadFile(templateMarkerPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("check marker: %w", err)
 	}
+	bootstrapped := err == nil
 
 	if err := ensureDirExists(templateRootPath, "template root"); err != nil {
 		return err
@@ -91,11 +139,24 @@ func run() error {
 		return err
 	}
 
-	repos, err := loadTemplateRepoConfig(templateConfigPath)
+	repos, configHash, err := loadTemplateRepoConfig(templateConfigPath)
 	if err != nil {
 		return err
 	}
 
+	configChanged := bootstrapped && strings.TrimSpace(string(marker)) != configHash
+	if bootstrapped {
+		switch {
+		case forceContent:
+			seedLogf("Templates already bootstrapped; updating template content (PROCESSGIT_SEED_FORCE_CONTENT)")
+		case configChanged:
+			seedLogf("Template repo config changed since the last bootstrap; updating template metadata and importing new templates")
+		default:
+			seedLogf("Templates already bootstrapped; skipping")
+			return nil
+		}
+	}
+
 	ownerName := envOrDefault("PROCESSGIT_TEMPLATES_OWNER", "processgit-templates")
 	ownerEmail := envOrDefault("PROCESSGIT_TEMPLATES_EMAIL", "processgit-templates@example.invalid")
 	ownerPassword := envOrDefault("PROCESSGIT_TEMPLATES_PASSWORD", "processgit-templates")
@@ -110,48 +171,51 @@ func run() error {
 		return err
 	}
 
-	seedLogf("Bootstrapping %d template repos", len(repos))
-	hadFailure := false
-	for _, repoCfg := range repos {
-		repoName := repoCfg.Name
-		if repoName == "" {
-			repoName = "<unknown>"
+	workers, err := parseSeedWorkers()
+	if err != nil {
+		return err
+	}
+
+	// a changed config updates the metadata of every template, but only imports the content
+	// of the ones added since the last bootstrap
+	var missing map[string]bool
+	if configChanged && !forceContent {
+		if missing, err = missingTemplateRepos(ctx, owner, repos); err != nil {
+			return err
 		}
-		err := func() error {
-			if repoCfg.Name == "" {
-				return fmt.Errorf("template repo entry missing name")
-			}
-			if repoCfg.Path == "" {
-				return fmt.Errorf("template repo entry %q missing path", repoCfg.Name)
-			}
-			sourceDir := filepath.Join(templateRootPath, repoCfg.Path)
-			if err := ensureDirExists(sourceDir, fmt.Sprintf("template content for %s", repoCfg.Name)); err != nil {
-				return err
-			}
+	}
 
-			repo, err := ensureTemplateRepo(ctx, owner, repoCfg)
-			if err != nil {
-				return err
-			}
+	pruneTemplateTempDirs()
 
-			if err := ensureTemplateClassification(ctx, repo, owner); err != nil {
-				return err
+	seedLogf("Bootstrapping %d template repos with %d workers", len(repos), workers)
+	var hadFailure atomic.Bool
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(workers)
+	for _, repoCfg := range repos {
+		eg.Go(func() error {
+			// in strict mode a failed import stops the remaining ones
+			if egCtx.Err() != nil {
+				return nil
 			}
-
-			if err := ensureRepoContent(ctx, owner, repo, sourceDir); err != nil {
-				return err
+			importContent := missing == nil || missing[repoCfg.Name]
+			err := importTemplateRepo(egCtx, owner, repoCfg, importContent, forceContent)
+			if err == nil {
+				return nil
 			}
-			seedLogf("Template imported OK: %s/%s", owner.Name, repo.Name)
-			return nil
-		}()
-		if err != nil {
 			if seedStrict {
 				return err
 			}
-			hadFailure = true
+			hadFailure.Store(true)
+			repoName := repoCfg.Name
+			if repoName == "" {
+				repoName = "<unknown>"
+			}
 			log.Error("[seed] Template import failed for %s: %v", repoName, err)
-			continue
-		}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
 	}
 
 	for _, repoCfg := range repos {
@@ -160,30 +224,30 @@ func run() error {
 		}
 		repo, err := repo_model.GetRepositoryByName(ctx, owner.ID, repoCfg.Name)
 		if err != nil {
-			hadFailure = true
+			hadFailure.Store(true)
 			log.Error("[seed] Failed to reload repo %s/%s: %v", owner.Name, repoCfg.Name, err)
 			continue
 		}
 		gitRepo, err := gitrepo.OpenRepository(ctx, repo)
 		if err != nil {
-			hadFailure = true
+			hadFailure.Store(true)
 			log.Error("[seed] Failed to open repo %s/%s: %v", owner.Name, repo.Name, err)
 			continue
 		}
 		isEmpty, err := gitRepo.IsEmpty()
 		gitRepo.Close()
 		if err != nil {
-			hadFailure = true
+			hadFailure.Store(true)
 			log.Error("[seed] Failed to check repo %s/%s empty state: %v", owner.Name, repo.Name, err)
 			continue
 		}
 		if isEmpty {
-			hadFailure = true
+			hadFailure.Store(true)
 			log.Error("[seed] Template repo still empty after seeding: %s/%s", owner.Name, repo.Name)
 		}
 	}
 
-	if hadFailure {
+	if hadFailure.Load() {
 		seedLogf("Template bootstrap completed with failures; marker not written")
 		return nil
 	}
@@ -191,7 +255,7 @@ func run() error {
 	if err := os.MkdirAll(filepath.Dir(templateMarkerPath), 0o755); err != nil {
 		return fmt.Errorf("create marker dir: %w", err)
 	}
-	if err := os.WriteFile(templateMarkerPath, []byte("ok"), 0o644); err != nil {
+	if err := os.WriteFile(templateMarkerPath, []byte(configHash), 0o644); err != nil {
 		return fmt.Errorf("write marker: %w", err)
 	}
 
@@ -199,6 +263,40 @@ func run() error {
 	return nil
 }
 
+// importTemplateRepo creates and classifies one template repo, and fills it if importContent is set.
+func importTemplateRepo(ctx context.Context, owner *user_model.User, repoCfg templateRepoConfig, importContent, forceContent bool) error {
+	if repoCfg.Name == "" {
+		return fmt.Errorf("template repo entry missing name")
+	}
+	if repoCfg.Path == "" {
+		return fmt.Errorf("template repo entry %q missing path", repoCfg.Name)
+	}
+	seedLogf("Importing template %s", repoCfg.Name)
+	sourceDir := filepath.Join(templateRootPath, repoCfg.Path)
+	if err := ensureDirExists(sourceDir, fmt.Sprintf("template content for %s", repoCfg.Name)); err != nil {
+		return err
+	}
+
+	repo, err := ensureTemplateRepo(ctx, owner, repoCfg)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureTemplateClassification(ctx, repo, owner, repoCfg); err != nil {
+		return err
+	}
+
+	if !importContent {
+		seedLogf("Template metadata updated: %s/%s", owner.Name, repo.Name)
+		return nil
+	}
+	if err := ensureRepoContent(ctx, owner, repo, sourceDir, forceContent); err != nil {
+		return err
+	}
+	seedLogf("Template imported OK: %s/%s", owner.Name, repo.Name)
+	return nil
+}
+
 func ensureDirExists(path, label string) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -227,16 +325,41 @@ func ensureFileExists(path, label string) error {
 	return nil
 }
 
-func loadTemplateRepoConfig(path string) ([]templateRepoConfig, error) {
+// loadTemplateRepoConfig returns the template repo entries and the SHA-256 hash of the config file.
+func loadTemplateRepoConfig(path string) ([]templateRepoConfig, string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("read template repo config: %w", err)
+		return nil, "", fmt.Errorf("read template repo config: %w", err)
 	}
 	var repos []templateRepoConfig
 	if err := json.Unmarshal(data, &repos); err != nil {
-		return nil, fmt.Errorf("parse template repo config: %w", err)
+		return nil, "", fmt.Errorf("parse template repo config: %w", err)
+	}
+	for _, repoCfg := range repos {
+		if err := repoCfg.validateClassification(); err != nil {
+			return nil, "", fmt.Errorf("template repo entry %q: %w", repoCfg.Name, err)
+		}
 	}
-	return repos, nil
+	hash := sha256.Sum256(data)
+	return repos, hex.EncodeToString(hash[:]), nil
+}
+
+// missingTemplateRepos returns the names of the entries whose repo doesn't exist yet, so a
+// changed config only imports the content of the templates added since the last bootstrap.
+func missingTemplateRepos(ctx context.Context, owner *user_model.User, repos []templateRepoConfig) (map[string]bool, error) {
+	missing := make(map[string]bool)
+	for _, repoCfg := range repos {
+		if repoCfg.Name != "" {
+			_, err := repo_model.GetRepositoryByName(ctx, owner.ID, repoCfg.Name)
+			if err == nil {
+				continue
+			} else if !repo_model.IsErrRepoNotExist(err) {
+				return nil, fmt.Errorf("lookup repo %s: %w", repoCfg.Name, err)
+			}
+		}
+		missing[repoCfg.Name] = true
+	}
+	return missing, nil
 }
 
 func ensureTemplatesOwner(ctx context.Context, name, email, password string) (*user_model.User, error) {
@@ -273,14 +396,15 @@ func ensureTemplateRepo(ctx context.Context, owner *user_model.User, cfg templat
 			return nil, fmt.Errorf("lookup repo %s: %w", cfg.Name, err)
 		}
 		seedLogf("Creating template repo %s/%s", owner.Name, cfg.Name)
+		classificationType, _ := cfg.classification()
 		return repo_service.CreateRepositoryDirectly(ctx, owner, owner, repo_service.CreateRepoOptions{
 			Name:               cfg.Name,
 			Description:        cfg.Description,
-			IsPrivate:          false,
+			IsPrivate:          cfg.Private,
 			IsTemplate:         true,
 			AutoInit:           false,
 			DefaultBranch:      setting.Repository.DefaultBranch,
-			ClassificationType: templateClassificationType,
+			ClassificationType: classificationType,
 		}, true)
 	}
 
@@ -300,16 +424,27 @@ func ensureTemplateRepo(ctx context.Context, owner *user_model.User, cfg templat
 		}
 	}
 
+	if repo.IsPrivate != cfg.Private {
+		// the repo service also updates accesses, stars and actions of the repo
+		seedLogf("Updating template repo visibility for %s/%s (private: %t)", owner.Name, cfg.Name, cfg.Private)
+		makeVisible := repo_service.MakeRepoPublic
+		if cfg.Private {
+			makeVisible = repo_service.MakeRepoPrivate
+		}
+		if err := makeVisible(ctx, repo); err != nil {
+			return nil, fmt.Errorf("update visibility of repo %s: %w", cfg.Name, err)
+		}
+	}
+
 	return repo, nil
 }
 
-func ensureTemplateClassification(ctx context.Context, repo *repo_model.Repository, doer *user_model.User) error {
+func ensureTemplateClassification(ctx context.Context, repo *repo_model.Repository, doer *user_model.User, cfg templateRepoConfig) error {
 	if repo.ID == 0 {
 		return fmt.Errorf("template repo %s/%s has no id", repo.OwnerName, repo.Name)
 	}
 	seedLogf("Ensuring classification for repo_id=%d %s/%s", repo.ID, repo.OwnerName, repo.Name)
-	desiredType := templateClassificationType
-	desiredStatus := repo_model.RepoClassificationStatusDraft
+	desiredType, desiredStatus := cfg.classification()
 
 	rc, err := repo_model.GetRepoClassification(ctx, repo.ID)
 	if err != nil {
@@ -325,6 +460,8 @@ func ensureTemplateClassification(ctx context.Context, repo *repo_model.Reposito
 			RepoID:                      repo.ID,
 			RepoType:                    desiredType,
 			Status:                      desiredStatus,
+			ReferenceKind:               cfg.ReferenceKind,
+			UAPFLevel:                   cfg.UAPFLevel,
 			IdxRepoClassificationType:   desiredType,
 			IdxRepoClassificationStatus: desiredStatus,
 			UpdatedBy:                   doer.ID,
@@ -336,9 +473,20 @@ func ensureTemplateClassification(ctx context.Context, repo *repo_model.Reposito
 	}
 
 	rc.RepoType = desiredType
-	rc.Status = desiredStatus
 	rc.IdxRepoClassificationType = desiredType
-	rc.IdxRepoClassificationStatus = desiredStatus
+	rc.ReferenceKind = cfg.ReferenceKind
+	if cfg.UAPFLevel != nil {
+		rc.UAPFLevel = cfg.UAPFLevel
+	} else if rc.UAPFLevel != nil && repo_model.UAPFLevelForbidden(desiredType) {
+		seedLogf("Clearing UAPF level of %s/%s, which %s repos can't have", repo.OwnerName, repo.Name, desiredType)
+		rc.UAPFLevel = nil
+	}
+	if err := repo_model.ValidateStatusTransition(rc.Status, desiredStatus); err == nil {
+		rc.Status = desiredStatus
+		rc.IdxRepoClassificationStatus = desiredStatus
+	} else {
+		seedLogf("Keeping status %q of %s/%s: %v", rc.Status, repo.OwnerName, repo.Name, err)
+	}
 	rc.UpdatedBy = doer.ID
 	if err := repo_model.UpsertRepoClassification(ctx, rc); err != nil {
 		return fmt.Errorf("upsert repo classification for %s/%s: %w", repo.OwnerName, repo.Name, err)
@@ -346,7 +494,10 @@ func ensureTemplateClassification(ctx context.Context, repo *repo_model.Reposito
 	return nil
 }
 
-func ensureRepoContent(ctx context.Context, owner *user_model.User, repo *repo_model.Repository, sourceDir string) error {
+// ensureRepoContent imports the template content into an empty repo. A repo that already has
+// content is left alone, unless forceContent is set: then changed and added template files are
+// committed on top of the default branch, and files missing from the template are kept.
+func ensureRepoContent(ctx context.Context, owner *user_model.User, repo *repo_model.Repository, sourceDir string, forceContent bool) error {
 	repoExists, err := gitrepo.IsRepositoryExist(ctx, repo)
 	if err != nil {
 		return fmt.Errorf("check repo path for %s/%s: %w", repo.OwnerName, repo.Name, err)
@@ -368,12 +519,15 @@ func ensureRepoContent(ctx context.Context, owner *user_model.User, repo *repo_m
 	if err != nil {
 		return fmt.Errorf("check empty repo %s/%s: %w", repo.OwnerName, repo.Name, err)
 	}
-	if !isEmpty {
+	if !isEmpty && !forceContent {
 		seedLogf("Repo %s/%s already has content; skipping import", repo.OwnerName, repo.Name)
 		return nil
 	}
 
-	seedLogf("Importing template content into %s/%s", repo.OwnerName, repo.Name)
+	if err := validateTemplateContent(sourceDir); err != nil {
+		return fmt.Errorf("template content for %s/%s is invalid: %w", repo.OwnerName, repo.Name, err)
+	}
+
 	defaultBranch := repo.DefaultBranch
 	if defaultBranch == "" {
 		defaultBranch = setting.Repository.DefaultBranch
@@ -382,19 +536,31 @@ func ensureRepoContent(ctx context.Context, owner *user_model.User, repo *repo_m
 		defaultBranch = "main"
 	}
 
-	tmpDir, cleanup, err := setting.AppDataTempDir("git-repo-content").MkdirTempRandom("template-seed-" + repo.Name)
+	tmpDir, cleanup, err := setting.AppDataTempDir("git-repo-content").MkdirTempRandom(templateTempDirPrefix + repo.Name)
 	if err != nil {
 		return fmt.Errorf("create temp dir for %s/%s: %w", repo.OwnerName, repo.Name, err)
 	}
 	defer cleanup()
 
 	workDir := filepath.Join(tmpDir, "repo")
+	if !isEmpty {
+		seedLogf("Updating template content in %s/%s", repo.OwnerName, repo.Name)
+		if err := updateTemplateContent(ctx, workDir, sourceDir, repo, defaultBranch); err != nil {
+			return err
+		}
+		return verifyTemplateBranch(ctx, repo, defaultBranch)
+	}
+
+	seedLogf("Importing template content into %s/%s", repo.OwnerName, repo.Name)
 	if err := os.MkdirAll(workDir, 0o755); err != nil {
 		return fmt.Errorf("create workdir for %s/%s: %w", repo.OwnerName, repo.Name, err)
 	}
 	if err := commitAndPushTemplate(ctx, workDir, sourceDir, repo, owner, defaultBranch); err != nil {
 		return err
 	}
+	if err := verifyTemplateBranch(ctx, repo, defaultBranch); err != nil {
+		return err
+	}
 
 	repo.IsEmpty = false
 	repo.DefaultBranch = defaultBranch
@@ -404,6 +570,72 @@ func ensureRepoContent(ctx context.Context, owner *user_model.User, repo *repo_m
 	return nil
 }
 
+// verifyTemplateBranch re-opens the repo after a push and checks that the default branch exists
+// and its commit has a non-empty tree, so a push that failed silently isn't recorded as content.
+func verifyTemplateBranch(ctx context.Context, repo *repo_model.Repository, defaultBranch string) error {
+	gitRepo, err := gitrepo.OpenRepository(ctx, repo)
+	if err != nil {
+		return fmt.Errorf("reopen git repo %s/%s: %w", repo.OwnerName, repo.Name, err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(defaultBranch)
+	if err != nil {
+		return fmt.Errorf("branch %s of %s/%s not found after push: %w", defaultBranch, repo.OwnerName, repo.Name, err)
+	}
+	entries, err := commit.Tree.ListEntries()
+	if err != nil {
+		return fmt.Errorf("list tree of %s/%s@%s: %w", repo.OwnerName, repo.Name, defaultBranch, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("branch %s of %s/%s is empty after push", defaultBranch, repo.OwnerName, repo.Name)
+	}
+	return nil
+}
+
+// pruneTemplateTempDirs removes checkouts left behind by an earlier seed run that was killed
+// before its deferred cleanup ran.
+func pruneTemplateTempDirs() {
+	pattern := setting.AppDataTempDir("git-repo-content").JoinPath(templateTempDirPrefix + "*")
+	dirs, err := filepath.Glob(pattern)
+	if err != nil {
+		log.Error("[seed] Failed to list stale temp dirs %s: %v", pattern, err)
+		return
+	}
+	for _, dir := range dirs {
+		if err := util.RemoveAll(dir); err != nil {
+			log.Error("[seed] Failed to remove stale temp dir %s: %v", dir, err)
+			continue
+		}
+		seedLogf("Removed stale temp dir %s", dir)
+	}
+}
+
+// validateTemplateContent checks the well-known config files of a template before it is
+// committed: processgit.mcp.yaml must be a valid MCP config and manifest.json a valid UAPF manifest.
+func validateTemplateContent(sourceDir string) error {
+	mcpConfigPath := filepath.Join(sourceDir, mcp.ConfigFileName)
+	if f, err := os.Open(mcpConfigPath); err == nil {
+		_, err = mcp.ParseConfig(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("open %s: %w", mcp.ConfigFileName, err)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(sourceDir, "manifest.json"))
+	if err == nil {
+		if err := uapf.ValidateManifest(manifest); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read manifest.json: %w", err)
+	}
+	return nil
+}
+
 func copyTemplateDir(sourceDir, destDir string) error {
 	return filepath.WalkDir(sourceDir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
@@ -433,6 +665,10 @@ func copyTemplateDir(sourceDir, destDir string) error {
 			if err != nil {
 				return err
 			}
+			// replace whatever is there when copying over an existing checkout
+			if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
 			return os.Symlink(link, target)
 		}
 		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
@@ -442,7 +678,9 @@ func copyTemplateDir(sourceDir, destDir string) error {
 	})
 }
 
-func commitAndPushTemplate(ctx context.Context, workDir, sourceDir string, repo *repo_model.Repository, owner *user_model.User, defaultBranch string) error {
+// templateGitRunner returns a helper that runs git commands in workDir as the template committer.
+// It runs git directly, bypassing gitcmd security, since the seed works on its own temp checkouts.
+func templateGitRunner(ctx context.Context, workDir string) func(args ...string) error {
 	commitTime := time.Now().Format(time.RFC3339)
 
 	env := append(os.Environ(),
@@ -455,8 +693,7 @@ func commitAndPushTemplate(ctx context.Context, workDir, sourceDir string, repo
 		"GIT_TERMINAL_PROMPT=0",
 	)
 
-	// Helper to run git commands directly (bypassing gitcmd security)
-	runGit := func(args ...string) error {
+	return func(args ...string) error {
 		cmd := exec.CommandContext(ctx, "git", args...)
 		cmd.Dir = workDir
 		cmd.Env = env
@@ -467,6 +704,10 @@ func commitAndPushTemplate(ctx context.Context, workDir, sourceDir string, repo
 		}
 		return nil
 	}
+}
+
+func commitAndPushTemplate(ctx context.Context, workDir, sourceDir string, repo *repo_model.Repository, owner *user_model.User, defaultBranch string) error {
+	runGit := templateGitRunner(ctx, workDir)
 
 	// Initialize git repository
 	if err := runGit("init"); err != nil {
@@ -515,6 +756,60 @@ func commitAndPushTemplate(ctx context.Context, workDir, sourceDir string, repo
 	return nil
 }
 
+// updateTemplateContent clones the default branch of repo, copies the template over it and
+// commits and pushes the result if anything changed. Files are only added or modified, never
+// deleted, so content added to the repo after bootstrap is preserved.
+func updateTemplateContent(ctx context.Context, workDir, sourceDir string, repo *repo_model.Repository, defaultBranch string) error {
+	repoBarePath := repo_model.RepoPath(repo.OwnerName, repo.Name)
+	fileURL := "file://" + repoBarePath
+
+	if err := templateGitRunner(ctx, filepath.Dir(workDir))("clone", "--branch", defaultBranch, "--single-branch", fileURL, workDir); err != nil {
+		return err
+	}
+	runGit := templateGitRunner(ctx, workDir)
+
+	if err := copyTemplateDir(sourceDir, workDir); err != nil {
+		return fmt.Errorf("copy template content for %s/%s: %w", repo.OwnerName, repo.Name, err)
+	}
+
+	if err := runGit("config", "user.name", templateCommitName); err != nil {
+		return err
+	}
+	if err := runGit("config", "user.email", templateCommitEmail); err != nil {
+		return err
+	}
+
+	// Nothing is removed from the checkout, so staging everything only picks up changes and additions
+	if err := runGit("add", "--all"); err != nil {
+		return err
+	}
+	// "git diff --quiet" exits with 1 when there are differences, so it can't go through runGit
+	diffCmd := exec.CommandContext(ctx, "git", "diff", "--cached", "--quiet")
+	diffCmd.Dir = workDir
+	err := diffCmd.Run()
+	if err == nil {
+		seedLogf("Repo %s/%s is up to date with its template", repo.OwnerName, repo.Name)
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return fmt.Errorf("git diff --cached --quiet: %w", err)
+	}
+
+	if err := runGit("commit", "-m", "Update template content", "--no-gpg-sign"); err != nil {
+		return err
+	}
+
+	refspec := fmt.Sprintf("HEAD:refs/heads/%s", defaultBranch)
+	seedLogf("Pushing template update to bare repo via file:// protocol: %s", fileURL)
+	if err := runGit("push", fileURL, refspec); err != nil {
+		return err
+	}
+
+	seedLogf("Successfully pushed template update to %s/%s", repo.OwnerName, repo.Name)
+	return nil
+}
+
 func envOrDefault(key, fallback string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -535,6 +830,36 @@ func parseSeedStrict() (bool, error) {
 	return parsed, nil
 }
 
+// defaultSeedWorkers is the number of template repos imported concurrently by default.
+const defaultSeedWorkers = 4
+
+func parseSeedWorkers() (int, error) {
+	value := os.Getenv("PROCESSGIT_SEED_WORKERS")
+	if value == "" {
+		return defaultSeedWorkers, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse PROCESSGIT_SEED_WORKERS: %w", err)
+	}
+	if parsed < 1 {
+		return 0, fmt.Errorf("parse PROCESSGIT_SEED_WORKERS: must be at least 1, got %d", parsed)
+	}
+	return parsed, nil
+}
+
+func parseSeedForceContent() (bool, error) {
+	value := os.Getenv("PROCESSGIT_SEED_FORCE_CONTENT")
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse PROCESSGIT_SEED_FORCE_CONTENT: %w", err)
+	}
+	return parsed, nil
+}
+
 func seedLogf(format string, args ...any) {
 	log.Info("[seed] "+format, args...)
 }
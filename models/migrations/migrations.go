@@ -401,6 +401,7 @@ func prepareMigrationTasks() []*migration {
 		newMigration(324, "Fix closed milestone completeness for milestones with no issues", v1_26.FixClosedMilestoneCompleteness),
 		newMigration(325, "Add repo classification metadata table", v1_26.AddRepoClassificationTable),
 		newMigration(326, "Set default repo classification type and backfill", v1_26.SetRepoClassificationDefault),
+		newMigration(327, "Add repo classification history table", v1_26.AddRepoClassificationHistoryTable),
 	}
 	return preparedMigrations
 }
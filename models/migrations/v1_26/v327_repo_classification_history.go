@@ -0,0 +1,35 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_26
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// RepoClassificationHistory records a single change of a repository classification.
+type RepoClassificationHistory struct {
+	ID               int64              `xorm:"pk autoincr"`
+	RepoID           int64              `xorm:"INDEX NOT NULL"`
+	OldRepoType      string             `xorm:"VARCHAR(30)"`
+	NewRepoType      string             `xorm:"VARCHAR(30) NOT NULL"`
+	OldStatus        string             `xorm:"VARCHAR(30)"`
+	NewStatus        string             `xorm:"VARCHAR(30) NOT NULL"`
+	OldUAPFLevel     *int               `xorm:"null"`
+	NewUAPFLevel     *int               `xorm:"null"`
+	OldReferenceKind string             `xorm:"VARCHAR(50)"`
+	NewReferenceKind string             `xorm:"VARCHAR(50)"`
+	UpdatedBy        int64              `xorm:"INDEX"`
+	CreatedUnix      timeutil.TimeStamp `xorm:"created INDEX"`
+}
+
+func (RepoClassificationHistory) TableName() string {
+	return "repo_classification_history"
+}
+
+// AddRepoClassificationHistoryTable creates the repo_classification_history table.
+func AddRepoClassificationHistoryTable(x *xorm.Engine) error {
+	return x.Sync(new(RepoClassificationHistory))
+}
@@ -12,6 +12,8 @@ import (
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
 )
 
 const (
@@ -20,6 +22,7 @@ const (
 	RepoClassificationTypeReference = "reference"
 	RepoClassificationTypeConnector = "connector"
 	RepoClassificationTypeTemplate  = "template"
+	RepoClassificationTypePolicy    = "policy"
 	RepoClassificationDefaultType   = RepoClassificationTypeProcess
 
 	RepoClassificationStatusDraft      = "draft"
@@ -35,6 +38,7 @@ var (
 		RepoClassificationTypeReference,
 		RepoClassificationTypeConnector,
 		RepoClassificationTypeTemplate,
+		RepoClassificationTypePolicy,
 	}
 	allowedRepoClassificationStatuses = []string{
 		RepoClassificationStatusDraft,
@@ -42,6 +46,24 @@ var (
 		RepoClassificationStatusDeprecated,
 		RepoClassificationStatusArchived,
 	}
+	// allowedRepoClassificationStatusTransitions lists the statuses reachable from each status.
+	// Archived is terminal.
+	allowedRepoClassificationStatusTransitions = map[string][]string{
+		RepoClassificationStatusDraft:      {RepoClassificationStatusStable},
+		RepoClassificationStatusStable:     {RepoClassificationStatusDeprecated, RepoClassificationStatusArchived},
+		RepoClassificationStatusDeprecated: {RepoClassificationStatusArchived, RepoClassificationStatusStable},
+		RepoClassificationStatusArchived:   {},
+	}
+	// repoTypeUAPFLevelRules says whether repositories of each type must, may or must not have a
+	// UAPF level: processes sit at a level of the hierarchy, reference data and connectors don't.
+	repoTypeUAPFLevelRules = map[string]uapfLevelRule{
+		RepoClassificationTypeProcess:   uapfLevelRequired,
+		RepoClassificationTypeDecision:  uapfLevelOptional,
+		RepoClassificationTypeReference: uapfLevelForbidden,
+		RepoClassificationTypeConnector: uapfLevelForbidden,
+		RepoClassificationTypeTemplate:  uapfLevelOptional,
+		RepoClassificationTypePolicy:    uapfLevelOptional,
+	}
 	allowedRepoReferenceKinds = []string{
 		"schema",
 		"classifier",
@@ -115,6 +137,28 @@ func ValidateStatus(status string) error {
 	return nil
 }
 
+// ValidateStatusTransition ensures a classification may move from oldStatus to newStatus.
+// Keeping the current status is always allowed.
+func ValidateStatusTransition(oldStatus, newStatus string) error {
+	if err := ValidateStatus(newStatus); err != nil {
+		return err
+	}
+	if oldStatus == newStatus {
+		return nil
+	}
+	next, ok := allowedRepoClassificationStatusTransitions[oldStatus]
+	if !ok {
+		return fmt.Errorf("invalid status: %s", oldStatus)
+	}
+	if !slices.Contains(next, newStatus) {
+		if len(next) == 0 {
+			return fmt.Errorf("invalid status transition from %q to %q: %q is a terminal status", oldStatus, newStatus, oldStatus)
+		}
+		return fmt.Errorf("invalid status transition from %q to %q: allowed targets are %s", oldStatus, newStatus, strings.Join(next, ", "))
+	}
+	return nil
+}
+
 // ValidateUAPFLevel validates the optional UAPF Level (0..4).
 func ValidateUAPFLevel(level *int) error {
 	if level == nil {
@@ -126,6 +170,59 @@ func ValidateUAPFLevel(level *int) error {
 	return nil
 }
 
+type uapfLevelRule int
+
+const (
+	uapfLevelOptional uapfLevelRule = iota
+	uapfLevelRequired
+	uapfLevelForbidden
+)
+
+// uapfLevelMatrix describes repoTypeUAPFLevelRules for error messages, e.g.
+// "process: 0..4; decision: 0..4 or null; reference: null; ...".
+func uapfLevelMatrix() string {
+	parts := make([]string, 0, len(allowedRepoClassificationTypes))
+	for _, repoType := range allowedRepoClassificationTypes {
+		switch repoTypeUAPFLevelRules[repoType] {
+		case uapfLevelRequired:
+			parts = append(parts, repoType+": 0..4")
+		case uapfLevelForbidden:
+			parts = append(parts, repoType+": null")
+		default:
+			parts = append(parts, repoType+": 0..4 or null")
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ValidateUAPFLevelForType validates the UAPF level against the rules of the repo_type:
+// process repositories need a level, reference and connector repositories may not have one,
+// and the other types may have one.
+func ValidateUAPFLevelForType(level *int, repoType string) error {
+	if err := ValidateRepoType(repoType); err != nil {
+		return err
+	}
+	if err := ValidateUAPFLevel(level); err != nil {
+		return err
+	}
+	switch repoTypeUAPFLevelRules[repoType] {
+	case uapfLevelRequired:
+		if level == nil {
+			return fmt.Errorf("uapf_level is required for %s repositories (uapf_level by repo_type: %s)", repoType, uapfLevelMatrix())
+		}
+	case uapfLevelForbidden:
+		if level != nil {
+			return fmt.Errorf("uapf_level must be null for %s repositories (uapf_level by repo_type: %s)", repoType, uapfLevelMatrix())
+		}
+	}
+	return nil
+}
+
+// UAPFLevelForbidden reports whether repositories of repoType may not have a UAPF level.
+func UAPFLevelForbidden(repoType string) bool {
+	return repoTypeUAPFLevelRules[repoType] == uapfLevelForbidden
+}
+
 // ValidateReferenceKind validates reference_kind relative to repo_type.
 func ValidateReferenceKind(kind, repoType string) error {
 	if repoType != RepoClassificationTypeReference {
@@ -158,6 +255,54 @@ func GetRepoClassification(ctx context.Context, repoID int64) (*RepoClassificati
 	return rc, nil
 }
 
+// ListRepoClassificationsOptions filters classification rows. Empty fields are not filtered on.
+type ListRepoClassificationsOptions struct {
+	RepoType      string
+	Status        string
+	ReferenceKind string
+	UAPFLevel     *int
+	Limit         int // 0 means no limit
+	Offset        int
+}
+
+// ToConds builds the query conditions, using the dedicated index columns where available.
+func (opts ListRepoClassificationsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if repoType := strings.TrimSpace(opts.RepoType); repoType != "" {
+		cond = cond.And(builder.Eq{"idx_repo_classification_type": repoType})
+	}
+	if status := strings.TrimSpace(opts.Status); status != "" {
+		cond = cond.And(builder.Eq{"idx_repo_classification_status": status})
+	}
+	if kind := strings.TrimSpace(opts.ReferenceKind); kind != "" {
+		cond = cond.And(builder.Eq{"reference_kind": kind})
+	}
+	if opts.UAPFLevel != nil {
+		cond = cond.And(builder.Eq{"idx_repo_classification_level": *opts.UAPFLevel})
+	}
+	return cond
+}
+
+// ListRepoClassifications returns the classification rows matching opts ordered by repo id,
+// together with the total number of matching rows ignoring pagination.
+func ListRepoClassifications(ctx context.Context, opts ListRepoClassificationsOptions) ([]*RepoClassification, int64, error) {
+	cond := opts.ToConds()
+	total, err := db.GetEngine(ctx).Where(cond).Count(new(RepoClassification))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := db.GetEngine(ctx).Where(cond).OrderBy("repo_id ASC")
+	if opts.Limit > 0 {
+		sess.Limit(opts.Limit, max(opts.Offset, 0))
+	}
+	rcs := make([]*RepoClassification, 0, max(opts.Limit, 0))
+	if err := sess.Find(&rcs); err != nil {
+		return nil, 0, err
+	}
+	return rcs, total, nil
+}
+
 func validateRepoClassification(rc *RepoClassification) error {
 	if err := ValidateRepoType(rc.RepoType); err != nil {
 		return err
@@ -165,11 +310,11 @@ func validateRepoClassification(rc *RepoClassification) error {
 	if err := ValidateStatus(rc.Status); err != nil {
 		return err
 	}
-	if err := ValidateUAPFLevel(rc.UAPFLevel); err != nil {
-		return err
-	}
-	if rc.RepoType == RepoClassificationTypeReference && rc.UAPFLevel != nil {
-		return errors.New("uapf_level must be null for reference repositories")
+	// new repositories start as process drafts without a level, which they need to leave draft
+	if rc.UAPFLevel != nil || rc.Status != RepoClassificationStatusDraft {
+		if err := ValidateUAPFLevelForType(rc.UAPFLevel, rc.RepoType); err != nil {
+			return err
+		}
 	}
 	if err := ValidateReferenceKind(rc.ReferenceKind, rc.RepoType); err != nil {
 		return err
@@ -208,31 +353,43 @@ func UpsertRepoClassification(ctx context.Context, rc *RepoClassification) error
 		return err
 	}
 
-	now := timeutil.TimeStampNow()
-	existing, err := GetRepoClassification(ctx, rc.RepoID)
-	if err != nil {
-		if !IsErrRepoClassificationNotExist(err) {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		now := timeutil.TimeStampNow()
+		existing, err := GetRepoClassification(ctx, rc.RepoID)
+		if err != nil {
+			if !IsErrRepoClassificationNotExist(err) {
+				return err
+			}
+			existing = nil
+		}
+		if existing == nil {
+			rc.CreatedUnix = now
+			rc.UpdatedUnix = now
+			if err := db.Insert(ctx, rc); err != nil {
+				return err
+			}
+			return insertRepoClassificationHistory(ctx, nil, rc, now)
+		}
+
+		if err := ValidateStatusTransition(existing.Status, rc.Status); err != nil {
 			return err
 		}
-		existing = nil
-	}
-	if existing == nil {
-		rc.CreatedUnix = now
-		rc.UpdatedUnix = now
-		return db.Insert(ctx, rc)
-	}
-
-	existing.RepoType = rc.RepoType
-	existing.UAPFLevel = rc.UAPFLevel
-	existing.ReferenceKind = strings.TrimSpace(rc.ReferenceKind)
-	existing.Status = rc.Status
-	existing.IdxRepoClassificationType = rc.IdxRepoClassificationType
-	existing.IdxRepoClassificationStatus = rc.IdxRepoClassificationStatus
-	existing.IdxRepoClassificationLevel = rc.IdxRepoClassificationLevel
-	existing.UpdatedUnix = now
-	existing.UpdatedBy = rc.UpdatedBy
-	_, err = db.GetEngine(ctx).ID(existing.RepoID).AllCols().Update(existing)
-	return err
+
+		old := *existing
+		existing.RepoType = rc.RepoType
+		existing.UAPFLevel = rc.UAPFLevel
+		existing.ReferenceKind = strings.TrimSpace(rc.ReferenceKind)
+		existing.Status = rc.Status
+		existing.IdxRepoClassificationType = rc.IdxRepoClassificationType
+		existing.IdxRepoClassificationStatus = rc.IdxRepoClassificationStatus
+		existing.IdxRepoClassificationLevel = rc.IdxRepoClassificationLevel
+		existing.UpdatedUnix = now
+		existing.UpdatedBy = rc.UpdatedBy
+		if _, err := db.GetEngine(ctx).ID(existing.RepoID).AllCols().Update(existing); err != nil {
+			return err
+		}
+		return insertRepoClassificationHistory(ctx, &old, existing, now)
+	})
 }
 
 // EnsureRepoClassificationDefault creates a default classification if missing.
@@ -0,0 +1,67 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(RepoClassificationHistory))
+}
+
+// RepoClassificationHistory records a single change of a repository classification.
+// The Old* fields are empty for the row recorded when the classification is first created.
+type RepoClassificationHistory struct {
+	ID               int64              `xorm:"pk autoincr"`
+	RepoID           int64              `xorm:"INDEX NOT NULL"`
+	OldRepoType      string             `xorm:"VARCHAR(30)"`
+	NewRepoType      string             `xorm:"VARCHAR(30) NOT NULL"`
+	OldStatus        string             `xorm:"VARCHAR(30)"`
+	NewStatus        string             `xorm:"VARCHAR(30) NOT NULL"`
+	OldUAPFLevel     *int               `xorm:"null"`
+	NewUAPFLevel     *int               `xorm:"null"`
+	OldReferenceKind string             `xorm:"VARCHAR(50)"`
+	NewReferenceKind string             `xorm:"VARCHAR(50)"`
+	UpdatedBy        int64              `xorm:"INDEX"`
+	CreatedUnix      timeutil.TimeStamp `xorm:"created INDEX"`
+}
+
+func (RepoClassificationHistory) TableName() string {
+	return "repo_classification_history"
+}
+
+func insertRepoClassificationHistory(ctx context.Context, old, cur *RepoClassification, now timeutil.TimeStamp) error {
+	h := &RepoClassificationHistory{
+		RepoID:           cur.RepoID,
+		NewRepoType:      cur.RepoType,
+		NewStatus:        cur.Status,
+		NewUAPFLevel:     cur.UAPFLevel,
+		NewReferenceKind: cur.ReferenceKind,
+		UpdatedBy:        cur.UpdatedBy,
+		CreatedUnix:      now,
+	}
+	if old != nil {
+		h.OldRepoType = old.RepoType
+		h.OldStatus = old.Status
+		h.OldUAPFLevel = old.UAPFLevel
+		h.OldReferenceKind = old.ReferenceKind
+	}
+	return db.Insert(ctx, h)
+}
+
+// ListRepoClassificationHistory returns the classification changes of a repository, oldest first.
+func ListRepoClassificationHistory(ctx context.Context, repoID int64) ([]*RepoClassificationHistory, error) {
+	hs := make([]*RepoClassificationHistory, 0, 10)
+	return hs, db.GetEngine(ctx).Where("repo_id = ?", repoID).OrderBy("id ASC").Find(&hs)
+}
+
+// DeleteRepoClassificationHistory removes the classification history of a repository.
+func DeleteRepoClassificationHistory(ctx context.Context, repoID int64) error {
+	_, err := db.GetEngine(ctx).Where("repo_id = ?", repoID).Delete(&RepoClassificationHistory{})
+	return err
+}
@@ -96,6 +96,86 @@ func TestRepoClassificationValidation(t *testing.T) {
 		UAPFLevel: &bad,
 	})
 	assert.Error(t, err)
+
+	assert.NoError(t, repo_model.ValidateRepoType(repo_model.RepoClassificationTypePolicy))
+	assert.Error(t, repo_model.ValidateRepoType("unknown"))
+
+	err = repo_model.UpsertRepoClassification(t.Context(), &repo_model.RepoClassification{
+		RepoID:        3,
+		RepoType:      repo_model.RepoClassificationTypePolicy,
+		Status:        repo_model.RepoClassificationStatusDraft,
+		ReferenceKind: "schema",
+	})
+	assert.Error(t, err)
+
+	err = repo_model.UpsertRepoClassification(t.Context(), &repo_model.RepoClassification{
+		RepoID:   3,
+		RepoType: repo_model.RepoClassificationTypePolicy,
+		Status:   repo_model.RepoClassificationStatusDraft,
+	})
+	assert.NoError(t, err)
+
+	err = repo_model.UpsertRepoClassification(t.Context(), &repo_model.RepoClassification{
+		RepoID:    3,
+		RepoType:  repo_model.RepoClassificationTypeConnector,
+		Status:    repo_model.RepoClassificationStatusDraft,
+		UAPFLevel: &level,
+	})
+	assert.ErrorContains(t, err, "uapf_level must be null for connector repositories")
+
+	// a process draft may leave the level unset, but needs one to become stable
+	rc := &repo_model.RepoClassification{
+		RepoID:   7,
+		RepoType: repo_model.RepoClassificationTypeProcess,
+		Status:   repo_model.RepoClassificationStatusDraft,
+	}
+	assert.NoError(t, repo_model.UpsertRepoClassification(t.Context(), rc))
+	rc.Status = repo_model.RepoClassificationStatusStable
+	assert.ErrorContains(t, repo_model.UpsertRepoClassification(t.Context(), rc), "uapf_level is required for process repositories")
+	rc.UAPFLevel = &level
+	assert.NoError(t, repo_model.UpsertRepoClassification(t.Context(), rc))
+}
+
+func TestValidateUAPFLevelForType(t *testing.T) {
+	level := func(l int) *int { return &l }
+	tests := []struct {
+		repoType string
+		level    *int
+		valid    bool
+	}{
+		{repo_model.RepoClassificationTypeProcess, level(2), true},
+		{repo_model.RepoClassificationTypeProcess, nil, false},
+		{repo_model.RepoClassificationTypeProcess, level(5), false},
+		{repo_model.RepoClassificationTypeDecision, level(0), true},
+		{repo_model.RepoClassificationTypeDecision, nil, true},
+		{repo_model.RepoClassificationTypeTemplate, level(4), true},
+		{repo_model.RepoClassificationTypeTemplate, nil, true},
+		{repo_model.RepoClassificationTypePolicy, level(1), true},
+		{repo_model.RepoClassificationTypePolicy, nil, true},
+		{repo_model.RepoClassificationTypeReference, nil, true},
+		{repo_model.RepoClassificationTypeReference, level(0), false},
+		{repo_model.RepoClassificationTypeConnector, nil, true},
+		{repo_model.RepoClassificationTypeConnector, level(3), false},
+		{"unknown", nil, false},
+	}
+	for _, tt := range tests {
+		err := repo_model.ValidateUAPFLevelForType(tt.level, tt.repoType)
+		if tt.valid {
+			assert.NoError(t, err, "%s %v", tt.repoType, tt.level)
+		} else {
+			assert.Error(t, err, "%s %v", tt.repoType, tt.level)
+		}
+	}
+
+	assert.True(t, repo_model.UAPFLevelForbidden(repo_model.RepoClassificationTypeReference))
+	assert.True(t, repo_model.UAPFLevelForbidden(repo_model.RepoClassificationTypeConnector))
+	assert.False(t, repo_model.UAPFLevelForbidden(repo_model.RepoClassificationTypeProcess))
+	assert.False(t, repo_model.UAPFLevelForbidden(repo_model.RepoClassificationTypeTemplate))
+
+	// the error names the rules of every type
+	err := repo_model.ValidateUAPFLevelForType(nil, repo_model.RepoClassificationTypeProcess)
+	assert.EqualError(t, err, "uapf_level is required for process repositories (uapf_level by repo_type: "+
+		"process: 0..4; decision: 0..4 or null; reference: null; connector: null; template: 0..4 or null; policy: 0..4 or null)")
 }
 
 func TestDeleteRepoClassification(t *testing.T) {
@@ -114,3 +194,157 @@ func TestDeleteRepoClassification(t *testing.T) {
 	assert.True(t, repo_model.IsErrRepoClassificationNotExist(err))
 	assert.Nil(t, rc)
 }
+
+func TestListRepoClassifications(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	rcs, total, err := repo_model.ListRepoClassifications(t.Context(), repo_model.ListRepoClassificationsOptions{
+		RepoType: repo_model.RepoClassificationTypeReference,
+		Status:   repo_model.RepoClassificationStatusStable,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	if assert.Len(t, rcs, 2) {
+		assert.EqualValues(t, 10, rcs[0].RepoID)
+		assert.EqualValues(t, 11, rcs[1].RepoID)
+	}
+
+	rcs, total, err = repo_model.ListRepoClassifications(t.Context(), repo_model.ListRepoClassificationsOptions{
+		RepoType:      repo_model.RepoClassificationTypeReference,
+		ReferenceKind: "schema",
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	if assert.Len(t, rcs, 2) {
+		assert.EqualValues(t, 10, rcs[0].RepoID)
+		assert.EqualValues(t, 12, rcs[1].RepoID)
+	}
+
+	level := 3
+	rcs, total, err = repo_model.ListRepoClassifications(t.Context(), repo_model.ListRepoClassificationsOptions{
+		UAPFLevel: &level,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, rcs, 1) {
+		assert.EqualValues(t, 14, rcs[0].RepoID)
+		assert.Equal(t, repo_model.RepoClassificationTypeDecision, rcs[0].RepoType)
+	}
+
+	rcs, total, err = repo_model.ListRepoClassifications(t.Context(), repo_model.ListRepoClassificationsOptions{
+		RepoType: repo_model.RepoClassificationTypeReference,
+		Limit:    1,
+		Offset:   1,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	if assert.Len(t, rcs, 1) {
+		assert.EqualValues(t, 11, rcs[0].RepoID)
+	}
+}
+
+func TestRepoClassificationHistory(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	repoID := int64(5)
+	assert.NoError(t, repo_model.DeleteRepoClassification(t.Context(), repoID))
+	assert.NoError(t, repo_model.DeleteRepoClassificationHistory(t.Context(), repoID))
+
+	assert.NoError(t, repo_model.EnsureRepoClassificationDefault(t.Context(), repoID, 2))
+
+	level := 3
+	assert.NoError(t, repo_model.UpsertRepoClassification(t.Context(), &repo_model.RepoClassification{
+		RepoID:    repoID,
+		RepoType:  repo_model.RepoClassificationTypeDecision,
+		Status:    repo_model.RepoClassificationStatusStable,
+		UAPFLevel: &level,
+		UpdatedBy: 3,
+	}))
+
+	hs, err := repo_model.ListRepoClassificationHistory(t.Context(), repoID)
+	assert.NoError(t, err)
+	if assert.Len(t, hs, 2) {
+		assert.Empty(t, hs[0].OldRepoType)
+		assert.Empty(t, hs[0].OldStatus)
+		assert.Equal(t, repo_model.RepoClassificationTypeProcess, hs[0].NewRepoType)
+		assert.Equal(t, repo_model.RepoClassificationStatusDraft, hs[0].NewStatus)
+		assert.EqualValues(t, 2, hs[0].UpdatedBy)
+		assert.NotZero(t, hs[0].CreatedUnix)
+
+		assert.Equal(t, repo_model.RepoClassificationTypeProcess, hs[1].OldRepoType)
+		assert.Equal(t, repo_model.RepoClassificationTypeDecision, hs[1].NewRepoType)
+		assert.Equal(t, repo_model.RepoClassificationStatusDraft, hs[1].OldStatus)
+		assert.Equal(t, repo_model.RepoClassificationStatusStable, hs[1].NewStatus)
+		assert.Nil(t, hs[1].OldUAPFLevel)
+		if assert.NotNil(t, hs[1].NewUAPFLevel) {
+			assert.Equal(t, level, *hs[1].NewUAPFLevel)
+		}
+		assert.EqualValues(t, 3, hs[1].UpdatedBy)
+	}
+}
+
+func TestValidateStatusTransition(t *testing.T) {
+	statuses := []string{
+		repo_model.RepoClassificationStatusDraft,
+		repo_model.RepoClassificationStatusStable,
+		repo_model.RepoClassificationStatusDeprecated,
+		repo_model.RepoClassificationStatusArchived,
+	}
+	// allowed[from][to]
+	allowed := map[string]map[string]bool{
+		repo_model.RepoClassificationStatusDraft: {
+			repo_model.RepoClassificationStatusDraft:  true,
+			repo_model.RepoClassificationStatusStable: true,
+		},
+		repo_model.RepoClassificationStatusStable: {
+			repo_model.RepoClassificationStatusStable:     true,
+			repo_model.RepoClassificationStatusDeprecated: true,
+			repo_model.RepoClassificationStatusArchived:   true,
+		},
+		repo_model.RepoClassificationStatusDeprecated: {
+			repo_model.RepoClassificationStatusStable:     true,
+			repo_model.RepoClassificationStatusDeprecated: true,
+			repo_model.RepoClassificationStatusArchived:   true,
+		},
+		repo_model.RepoClassificationStatusArchived: {
+			repo_model.RepoClassificationStatusArchived: true,
+		},
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			err := repo_model.ValidateStatusTransition(from, to)
+			if allowed[from][to] {
+				assert.NoError(t, err, "%s -> %s", from, to)
+			} else {
+				assert.Error(t, err, "%s -> %s", from, to)
+			}
+		}
+	}
+
+	assert.Error(t, repo_model.ValidateStatusTransition(repo_model.RepoClassificationStatusDraft, "unknown"))
+}
+
+func TestUpsertRepoClassificationTransition(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	repoID := int64(6)
+	assert.NoError(t, repo_model.DeleteRepoClassification(t.Context(), repoID))
+
+	// initial creation is unrestricted
+	level := 1
+	rc := &repo_model.RepoClassification{
+		RepoID:    repoID,
+		RepoType:  repo_model.RepoClassificationTypeProcess,
+		Status:    repo_model.RepoClassificationStatusArchived,
+		UAPFLevel: &level,
+	}
+	assert.NoError(t, repo_model.UpsertRepoClassification(t.Context(), rc))
+
+	rc.Status = repo_model.RepoClassificationStatusStable
+	err := repo_model.UpsertRepoClassification(t.Context(), rc)
+	assert.ErrorContains(t, err, "terminal")
+
+	rcFetched, err := repo_model.GetRepoClassification(t.Context(), repoID)
+	assert.NoError(t, err)
+	assert.Equal(t, repo_model.RepoClassificationStatusArchived, rcFetched.Status)
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/typesniffer"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	// MaxAttachments is the maximum number of files attached to a single message.
+	MaxAttachments = 5
+	// MaxAttachmentSize is the maximum size of a single attached file.
+	MaxAttachmentSize = 5 << 20
+)
+
+// attachmentImageTypes are the image media types accepted by the Claude API.
+var attachmentImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ResolveAttachments reads the given repository files at commit and converts them to content
+// blocks: supported images become image blocks, PDFs and text files become document blocks.
+func ResolveAttachments(commit *git.Commit, paths []string) ([]ClaudeContentBlock, error) {
+	if len(paths) > MaxAttachments {
+		return nil, fmt.Errorf("too many attachments (max %d)", MaxAttachments)
+	}
+
+	blocks := make([]ClaudeContentBlock, 0, len(paths))
+	for _, p := range paths {
+		treePath := util.PathJoinRelX(p)
+		data, err := readAttachment(commit, treePath)
+		if err != nil {
+			return nil, err
+		}
+		block, err := attachmentBlock(treePath, data)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func readAttachment(commit *git.Commit, treePath string) ([]byte, error) {
+	entry, err := commit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, fmt.Errorf("attachment %s not found", treePath)
+		}
+		return nil, fmt.Errorf("error reading attachment %s: %w", treePath, err)
+	}
+	if entry.IsDir() {
+		return nil, fmt.Errorf("attachment %s is a directory", treePath)
+	}
+	if entry.Blob().Size() > MaxAttachmentSize {
+		return nil, fmt.Errorf("attachment %s exceeds max size (%d bytes)", treePath, MaxAttachmentSize)
+	}
+
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment %s blob: %w", treePath, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// attachmentBlock builds the content block for a file based on its sniffed content type.
+func attachmentBlock(treePath string, data []byte) (ClaudeContentBlock, error) {
+	sniffed := typesniffer.DetectContentType(data)
+	mimeType := sniffed.GetMimeType()
+
+	switch {
+	case attachmentImageTypes[mimeType]:
+		return ClaudeContentBlock{
+			Type:   "image",
+			Source: &ClaudeBlockSource{Type: "base64", MediaType: mimeType, Data: base64.StdEncoding.EncodeToString(data)},
+		}, nil
+	case sniffed.IsPDF():
+		return ClaudeContentBlock{
+			Type:   "document",
+			Title:  treePath,
+			Source: &ClaudeBlockSource{Type: "base64", MediaType: "application/pdf", Data: base64.StdEncoding.EncodeToString(data)},
+		}, nil
+	case sniffed.IsRepresentableAsText():
+		return ClaudeContentBlock{
+			Type:   "document",
+			Title:  treePath,
+			Source: &ClaudeBlockSource{Type: "text", MediaType: "text/plain", Data: string(data)},
+		}, nil
+	default:
+		return ClaudeContentBlock{}, fmt.Errorf("attachment %s has unsupported type %s", treePath, mimeType)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentBlock(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	block, err := attachmentBlock("images/logo.png", png)
+	require.NoError(t, err)
+	assert.Equal(t, "image", block.Type)
+	assert.Empty(t, block.Title)
+	assert.Equal(t, &ClaudeBlockSource{Type: "base64", MediaType: "image/png", Data: base64.StdEncoding.EncodeToString(png)}, block.Source)
+
+	pdf := []byte("%PDF-1.7\n")
+	block, err = attachmentBlock("docs/spec.pdf", pdf)
+	require.NoError(t, err)
+	assert.Equal(t, "document", block.Type)
+	assert.Equal(t, "docs/spec.pdf", block.Title)
+	assert.Equal(t, "application/pdf", block.Source.MediaType)
+	assert.Equal(t, "base64", block.Source.Type)
+
+	block, err = attachmentBlock("README.md", []byte("# repo\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "document", block.Type)
+	assert.Equal(t, "README.md", block.Title)
+	assert.Equal(t, &ClaudeBlockSource{Type: "text", MediaType: "text/plain", Data: "# repo\n"}, block.Source)
+
+	_, err = attachmentBlock("bin/tool", []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x00, 0x00, 0xff, 0xfe})
+	assert.ErrorContains(t, err, "unsupported type")
+}
+
+func TestResolveAttachments_TooMany(t *testing.T) {
+	_, err := ResolveAttachments(nil, make([]string, MaxAttachments+1))
+	assert.ErrorContains(t, err, "too many attachments")
+}
@@ -5,11 +5,15 @@ package chat
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/hostmatcher"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 
 	"gopkg.in/yaml.v3"
@@ -118,12 +122,44 @@ type ChatAgentInfo struct {
 	Config   *ChatConfig `json:"config"`
 }
 
-// ResolveAPIKey resolves an API key reference to the actual key value.
-// It checks environment variables first.
+// SecretRef is an api_key_ref naming an Actions secret: "repo:<name>" refers to a secret of
+// the repository and "org:<owner>:<name>" to one of the repository's owner.
+type SecretRef struct {
+	Owner string // empty for repository secrets
+	Name  string
+}
+
+// ParseSecretRef parses a secret reference. ok is false for plain references, which name an
+// environment variable or app.ini key.
+func ParseSecretRef(ref string) (secretRef SecretRef, ok bool, err error) {
+	if name, found := strings.CutPrefix(ref, "repo:"); found {
+		if name == "" {
+			return SecretRef{}, false, fmt.Errorf("api_key_ref %q: secret name is empty", ref)
+		}
+		return SecretRef{Name: name}, true, nil
+	}
+	if rest, found := strings.CutPrefix(ref, "org:"); found {
+		owner, name, _ := strings.Cut(rest, ":")
+		if owner == "" || name == "" {
+			return SecretRef{}, false, fmt.Errorf("api_key_ref %q: must be of the form org:<owner>:<secret>", ref)
+		}
+		return SecretRef{Owner: owner, Name: name}, true, nil
+	}
+	return SecretRef{}, false, nil
+}
+
+// ResolveAPIKey resolves a plain API key reference to the actual key value.
+// It checks environment variables first. Secret references need the repository
+// and are resolved by services/chat.
 func ResolveAPIKey(ref string) (string, error) {
 	if ref == "" {
 		return "", fmt.Errorf("api_key_ref is empty")
 	}
+	if _, ok, err := ParseSecretRef(ref); err != nil {
+		return "", err
+	} else if ok {
+		return "", fmt.Errorf("api_key_ref %q refers to a secret and can only be resolved for a repository", ref)
+	}
 
 	// Priority 1: Environment variable
 	if val := os.Getenv(ref); val != "" {
@@ -140,11 +176,6 @@ func ResolveAPIKey(ref string) (string, error) {
 		}
 	}
 
-	// Priority 3: Org-prefixed references (future)
-	if strings.HasPrefix(ref, "org:") {
-		return "", fmt.Errorf("org-level API key resolution not yet implemented for ref %q", ref)
-	}
-
 	return "", fmt.Errorf("API key not found for ref %q: set as environment variable or add to [chat] section in app.ini", ref)
 }
 
@@ -199,6 +230,10 @@ func validateChatConfig(cfg *ChatConfig) error {
 		return fmt.Errorf("agent.chat.yaml: llm.api_key_ref is required")
 	}
 
+	if _, _, err := ParseSecretRef(cfg.LLM.APIKeyRef); err != nil {
+		return fmt.Errorf("agent.chat.yaml: llm.%w", err)
+	}
+
 	// Validate provider
 	switch cfg.LLM.Provider {
 	case "anthropic", "openai", "ollama":
@@ -207,6 +242,68 @@ func validateChatConfig(cfg *ChatConfig) error {
 		return fmt.Errorf("agent.chat.yaml: llm.provider %q is not supported (must be anthropic, openai, or ollama)", cfg.LLM.Provider)
 	}
 
+	for i, stop := range cfg.LLM.StopSequences {
+		if stop == "" {
+			return fmt.Errorf("agent.chat.yaml: llm.stop_sequences[%d] must not be empty", i)
+		}
+	}
+	// the Chat Completions API rejects more
+	if cfg.LLM.Provider == "openai" && len(cfg.LLM.StopSequences) > 4 {
+		return fmt.Errorf("agent.chat.yaml: llm.stop_sequences has %d entries, openai accepts at most 4", len(cfg.LLM.StopSequences))
+	}
+	if cfg.MCP.MaxToolCalls < 0 {
+		return fmt.Errorf("agent.chat.yaml: mcp.max_tool_calls must not be negative")
+	}
+
+	for i, server := range cfg.MCP.AdditionalServers {
+		if err := validateMCPServerURL(server.URL); err != nil {
+			return fmt.Errorf("agent.chat.yaml: mcp.additional_servers[%d].url %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupIP resolves the hosts of additional MCP servers, replaced in tests.
+var lookupIP = net.LookupIP
+
+// validateMCPServerURL checks that an additional MCP server URL uses https, is on a host allowed by
+// [chat] MCP_ALLOWED_HOSTS and, unless [chat] MCP_ALLOW_LOCAL_NETWORKS is set, does not point at a
+// loopback or private address, so that a repository cannot make the agent reach internal services.
+func validateMCPServerURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%q is not a valid URL", rawURL)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%q must use https", rawURL)
+	}
+	hostName := strings.ToLower(u.Hostname())
+
+	allowList := hostmatcher.ParseSimpleMatchList("chat.MCP_ALLOWED_HOSTS", setting.Chat.MCPAllowedHosts)
+	if !allowList.IsEmpty() && !allowList.MatchHostName(hostName) {
+		return fmt.Errorf("%q is not on a host allowed by MCP_ALLOWED_HOSTS", rawURL)
+	}
+
+	if setting.Chat.MCPAllowLocalNetworks {
+		return nil
+	}
+	if hostName == "localhost" || strings.HasSuffix(hostName, ".localhost") {
+		return fmt.Errorf("%q points at a loopback address", rawURL)
+	}
+	var addrList []net.IP
+	if ip := net.ParseIP(hostName); ip != nil {
+		addrList = []net.IP{ip}
+	} else {
+		// like migrations, a failed lookup is not an error: the host may only be reachable through a proxy
+		addrList, _ = lookupIP(hostName)
+	}
+	external := hostmatcher.ParseHostMatchList("", hostmatcher.MatchBuiltinExternal)
+	for _, addr := range addrList {
+		if !external.MatchIPAddr(addr) {
+			return fmt.Errorf("%q resolves to the non-public address %s", rawURL, addr)
+		}
+	}
 	return nil
 }
 
@@ -217,6 +314,10 @@ func applyDefaults(cfg *ChatConfig) {
 	if cfg.LLM.MaxTokens == 0 {
 		cfg.LLM.MaxTokens = 1024
 	}
+	if limit := setting.Chat.MaxTokensLimit; limit > 0 && cfg.LLM.MaxTokens > limit {
+		log.Warn("agent.chat.yaml: llm.max_tokens %d exceeds the instance limit, clamped to %d", cfg.LLM.MaxTokens, limit)
+		cfg.LLM.MaxTokens = limit
+	}
 	if cfg.LLM.Temperature == 0 {
 		cfg.LLM.Temperature = 0.3
 	}
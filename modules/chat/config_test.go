@@ -4,8 +4,13 @@
 package chat
 
 import (
+	"errors"
+	"net"
 	"testing"
 
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,6 +67,25 @@ func TestValidateChatConfig(t *testing.T) {
 		assert.Contains(t, err.Error(), "llm.api_key_ref is required")
 	})
 
+	t.Run("StopSequencesAndToolCalls", func(t *testing.T) {
+		cfg := &ChatConfig{
+			UI:  UIConfig{Name: "Test"},
+			LLM: LLMConfig{Provider: "openai", Model: "gpt-test", APIKeyRef: "KEY", StopSequences: []string{"END"}},
+			MCP: MCPChatConfig{MaxToolCalls: 3},
+		}
+		assert.NoError(t, validateChatConfig(cfg))
+
+		cfg.LLM.StopSequences = []string{"END", ""}
+		assert.ErrorContains(t, validateChatConfig(cfg), "llm.stop_sequences[1] must not be empty")
+		cfg.LLM.StopSequences = []string{"a", "b", "c", "d", "e"}
+		assert.ErrorContains(t, validateChatConfig(cfg), "openai accepts at most 4")
+		cfg.LLM.Provider = "anthropic"
+		assert.NoError(t, validateChatConfig(cfg))
+
+		cfg.MCP.MaxToolCalls = -1
+		assert.ErrorContains(t, validateChatConfig(cfg), "mcp.max_tool_calls must not be negative")
+	})
+
 	t.Run("InvalidProvider", func(t *testing.T) {
 		cfg := &ChatConfig{
 			UI:  UIConfig{Name: "Test"},
@@ -73,6 +97,78 @@ func TestValidateChatConfig(t *testing.T) {
 	})
 }
 
+func TestValidateMCPServerURL(t *testing.T) {
+	defer test.MockVariableValue(&lookupIP, func(host string) ([]net.IP, error) {
+		switch host {
+		case "mcp.example.com", "registry.example.org":
+			return []net.IP{net.ParseIP("93.184.215.14")}, nil
+		case "internal.example.com":
+			return []net.IP{net.ParseIP("93.184.215.14"), net.ParseIP("10.1.2.3")}, nil
+		}
+		return nil, errors.New("no such host")
+	})()
+
+	t.Run("Allowed", func(t *testing.T) {
+		for _, u := range []string{
+			"https://mcp.example.com/owner/repo/mcp",
+			"https://registry.example.org:8443/mcp",
+			"https://93.184.215.14/mcp",
+			"https://unresolvable.example.net/mcp",
+		} {
+			assert.NoError(t, validateMCPServerURL(u), u)
+		}
+	})
+
+	t.Run("Blocked", func(t *testing.T) {
+		for u, msg := range map[string]string{
+			"http://mcp.example.com/mcp":       "must use https",
+			"ftp://mcp.example.com/mcp":        "must use https",
+			"mcp.example.com/mcp":              "not a valid URL",
+			"https://localhost/mcp":            "loopback",
+			"https://LOCALHOST:3000/mcp":       "loopback",
+			"https://127.0.0.1/mcp":            "non-public address 127.0.0.1",
+			"https://[::1]/mcp":                "non-public address ::1",
+			"https://192.168.1.10/mcp":         "non-public address",
+			"https://169.254.169.254/latest":   "non-public address",
+			"https://0.0.0.0/mcp":              "non-public address",
+			"https://internal.example.com/mcp": "non-public address 10.1.2.3",
+		} {
+			err := validateMCPServerURL(u)
+			if assert.Error(t, err, u) {
+				assert.Contains(t, err.Error(), msg, u)
+			}
+		}
+	})
+
+	t.Run("AllowedHosts", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Chat.MCPAllowedHosts, "*.example.org, mcp.example.com")()
+		assert.NoError(t, validateMCPServerURL("https://mcp.example.com/mcp"))
+		assert.NoError(t, validateMCPServerURL("https://registry.example.org/mcp"))
+		err := validateMCPServerURL("https://93.184.215.14/mcp")
+		assert.ErrorContains(t, err, "MCP_ALLOWED_HOSTS")
+	})
+
+	t.Run("AllowLocalNetworks", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Chat.MCPAllowLocalNetworks, true)()
+		assert.NoError(t, validateMCPServerURL("https://localhost/mcp"))
+		assert.NoError(t, validateMCPServerURL("https://10.1.2.3/mcp"))
+		assert.Error(t, validateMCPServerURL("http://10.1.2.3/mcp"))
+	})
+
+	t.Run("ValidateChatConfig", func(t *testing.T) {
+		cfg := &ChatConfig{
+			UI:  UIConfig{Name: "Test"},
+			LLM: LLMConfig{Provider: "anthropic", Model: "claude-sonnet-4-5", APIKeyRef: "KEY"},
+			MCP: MCPChatConfig{AdditionalServers: []MCPServerEntry{
+				{Name: "ok", URL: "https://mcp.example.com/mcp"},
+				{Name: "internal", URL: "https://127.0.0.1:3000/mcp"},
+			}},
+		}
+		err := validateChatConfig(cfg)
+		assert.ErrorContains(t, err, "mcp.additional_servers[1].url")
+	})
+}
+
 func TestApplyDefaults(t *testing.T) {
 	cfg := &ChatConfig{
 		UI:  UIConfig{Name: "Test"},
@@ -93,6 +189,24 @@ func TestApplyDefaults(t *testing.T) {
 	assert.Equal(t, 100, cfg.Access.RateLimits.RequestsPerDay)
 }
 
+func TestApplyDefaults_MaxTokensLimit(t *testing.T) {
+	defer test.MockVariableValue(&setting.Chat.MaxTokensLimit, 4096)()
+
+	cfg := &ChatConfig{LLM: LLMConfig{MaxTokens: 100000}}
+	applyDefaults(cfg)
+	assert.Equal(t, 4096, cfg.LLM.MaxTokens)
+
+	cfg = &ChatConfig{LLM: LLMConfig{MaxTokens: 2000}}
+	applyDefaults(cfg)
+	assert.Equal(t, 2000, cfg.LLM.MaxTokens)
+
+	// no ceiling configured
+	setting.Chat.MaxTokensLimit = 0
+	cfg = &ChatConfig{LLM: LLMConfig{MaxTokens: 100000}}
+	applyDefaults(cfg)
+	assert.Equal(t, 100000, cfg.LLM.MaxTokens)
+}
+
 func TestResolveAPIKey(t *testing.T) {
 	t.Run("EmptyRef", func(t *testing.T) {
 		_, err := ResolveAPIKey("")
@@ -112,13 +226,41 @@ func TestResolveAPIKey(t *testing.T) {
 		assert.Contains(t, err.Error(), "not found")
 	})
 
-	t.Run("OrgPrefix", func(t *testing.T) {
+	t.Run("SecretRef", func(t *testing.T) {
 		_, err := ResolveAPIKey("org:varam:anthropic_key")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "not yet implemented")
+		assert.Contains(t, err.Error(), "can only be resolved for a repository")
 	})
 }
 
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    SecretRef
+		ok      bool
+		wantErr bool
+	}{
+		{ref: "ANTHROPIC_API_KEY"},
+		{ref: "repo:ANTHROPIC_KEY", want: SecretRef{Name: "ANTHROPIC_KEY"}, ok: true},
+		{ref: "org:varam:anthropic_key", want: SecretRef{Owner: "varam", Name: "anthropic_key"}, ok: true},
+		{ref: "repo:", wantErr: true},
+		{ref: "org:varam", wantErr: true},
+		{ref: "org::key", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, ok, err := ParseSecretRef(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestIsChatConfigFile(t *testing.T) {
 	assert.True(t, isChatConfigFile("agent.chat.yaml"))
 	assert.True(t, isChatConfigFile("classification.agent.chat.yaml"))
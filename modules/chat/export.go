@@ -0,0 +1,62 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportContentTypes maps the supported conversation export formats to their MIME types.
+var ExportContentTypes = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"json":     "application/json; charset=utf-8",
+}
+
+// ConversationMarkdown renders the conversation as a Markdown transcript for archiving:
+// a metadata header followed by the user and assistant turns with their attachments and
+// the MCP tools called for each reply.
+func ConversationMarkdown(conv *Conversation) string {
+	title := conv.Title
+	if title == "" {
+		title = GenerateTitle(conv)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	fmt.Fprintf(&sb, "- Conversation: `%s`\n", conv.ID)
+	fmt.Fprintf(&sb, "- User: %s\n", conv.User.DisplayName)
+	fmt.Fprintf(&sb, "- Agent: `%s`\n", conv.AgentConfig)
+	fmt.Fprintf(&sb, "- Model: `%s`\n", conv.Model)
+	fmt.Fprintf(&sb, "- Created: %s\n", conv.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "- Updated: %s\n", conv.UpdatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "- Turns: %d\n", conv.Stats.Turns)
+	fmt.Fprintf(&sb, "- Tokens: %d input, %d output\n", conv.Stats.TotalInputTokens, conv.Stats.TotalOutputTokens)
+	fmt.Fprintf(&sb, "- Estimated cost: $%.4f\n", conv.Stats.TotalCostUSD)
+
+	for _, msg := range conv.Messages {
+		role := "User"
+		if msg.Role == "assistant" {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&sb, "\n## %s — %s\n\n", role, msg.Timestamp.UTC().Format(time.RFC3339))
+		if len(msg.ToolCalls) > 0 {
+			tools := make([]string, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				tools = append(tools, fmt.Sprintf("`%s` (%s)", call.Tool, call.Server))
+			}
+			fmt.Fprintf(&sb, "_Tools called: %s_\n\n", strings.Join(tools, ", "))
+		}
+		sb.WriteString(strings.TrimSpace(msg.Content))
+		sb.WriteString("\n")
+		if len(msg.Attachments) > 0 {
+			sb.WriteString("\nAttachments:\n")
+			for _, path := range msg.Attachments {
+				fmt.Fprintf(&sb, "- `%s`\n", path)
+			}
+		}
+	}
+	return sb.String()
+}
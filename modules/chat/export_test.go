@@ -0,0 +1,62 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationMarkdown(t *testing.T) {
+	created := time.Date(2026, 2, 11, 14, 30, 0, 0, time.UTC)
+	conv := &Conversation{
+		ID:          "conv_abcd1234",
+		CreatedAt:   created,
+		UpdatedAt:   created.Add(time.Minute),
+		User:        ConversationUser{ID: "2", DisplayName: "user2"},
+		AgentConfig: "agent.chat.yaml",
+		Model:       "claude-sonnet-4-5",
+		Stats:       ConversationStats{Turns: 2, TotalInputTokens: 120, TotalOutputTokens: 40, TotalCostUSD: 0.00096},
+		Messages: []Message{
+			{Role: "user", Content: "Which ministry is 01?", Timestamp: created, Attachments: []string{"docs/spec.pdf"}},
+			{
+				Role:      "assistant",
+				Content:   "Ministry 01 is the State Chancellery.\n",
+				Timestamp: created.Add(time.Minute),
+				ToolCalls: []ToolCall{{Tool: "get_entity", Server: "repo1-mcp"}, {Tool: "search", Server: "repo1-mcp"}},
+			},
+		},
+	}
+
+	assert.Equal(t, `# Which ministry is 01?
+
+- Conversation: `+"`conv_abcd1234`"+`
+- User: user2
+- Agent: `+"`agent.chat.yaml`"+`
+- Model: `+"`claude-sonnet-4-5`"+`
+- Created: 2026-02-11T14:30:00Z
+- Updated: 2026-02-11T14:31:00Z
+- Turns: 2
+- Tokens: 120 input, 40 output
+- Estimated cost: $0.0010
+
+## User — 2026-02-11T14:30:00Z
+
+Which ministry is 01?
+
+Attachments:
+- `+"`docs/spec.pdf`"+`
+
+## Assistant — 2026-02-11T14:31:00Z
+
+_Tools called: `+"`get_entity` (repo1-mcp), `search` (repo1-mcp)"+`_
+
+Ministry 01 is the State Chancellery.
+`, ConversationMarkdown(conv))
+
+	conv.Title = "State Chancellery"
+	assert.Contains(t, ConversationMarkdown(conv), "# State Chancellery\n")
+}
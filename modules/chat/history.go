@@ -8,11 +8,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 )
 
 const (
@@ -21,16 +25,21 @@ const (
 	maxTitleLength        = 60
 	batchFlushInterval    = 5 * time.Minute
 	batchFlushThreshold   = 10
+	shardPrefixLength     = 2
 )
 
 // ConversationBuffer holds conversations pending commit to git.
 type ConversationBuffer struct {
-	mu            sync.Mutex
-	conversations map[string]*Conversation // keyed by conversation ID
-	lastFlush     time.Time
-	repoID        int64
+	mu              sync.Mutex
+	conversations   map[string]*Conversation  // keyed by conversation ID
+	titleGenerators map[string]TitleGenerator // keyed by conversation ID
+	lastFlush       time.Time
+	repoID          int64
 }
 
+// TitleGenerator produces a concise title for a conversation, e.g. with a single model call.
+type TitleGenerator func(conv *Conversation) (string, error)
+
 var (
 	buffersMu sync.RWMutex
 	buffers   = make(map[int64]*ConversationBuffer) // keyed by repo ID
@@ -67,6 +76,21 @@ func (b *ConversationBuffer) BufferConversation(conv *Conversation) {
 	b.conversations[conv.ID] = conv
 }
 
+// BufferConversationWithTitle buffers conv like BufferConversation. If the conversation has
+// no title yet, it is generated with generate when the buffer is drained, so the model call
+// happens during the asynchronous flush instead of in the request path.
+func (b *ConversationBuffer) BufferConversationWithTitle(conv *Conversation, generate TitleGenerator) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.conversations[conv.ID] = conv
+	if conv.Title == "" {
+		if b.titleGenerators == nil {
+			b.titleGenerators = make(map[string]TitleGenerator)
+		}
+		b.titleGenerators[conv.ID] = generate
+	}
+}
+
 // ShouldFlush returns true if the buffer should be flushed to git.
 func (b *ConversationBuffer) ShouldFlush() bool {
 	b.mu.Lock()
@@ -79,21 +103,50 @@ func (b *ConversationBuffer) ShouldFlush() bool {
 }
 
 // DrainConversations returns all buffered conversations and clears the buffer.
+// Pending titles are generated after the buffer is unlocked.
 func (b *ConversationBuffer) DrainConversations() []*Conversation {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	if len(b.conversations) == 0 {
+		b.mu.Unlock()
 		return nil
 	}
 	result := make([]*Conversation, 0, len(b.conversations))
 	for _, conv := range b.conversations {
 		result = append(result, conv)
 	}
+	titleGenerators := b.titleGenerators
 	b.conversations = make(map[string]*Conversation)
+	b.titleGenerators = nil
 	b.lastFlush = time.Now()
+	b.mu.Unlock()
+
+	for _, conv := range result {
+		if generate, ok := titleGenerators[conv.ID]; ok && conv.Title == "" {
+			conv.Title = generateTitleWith(conv, generate)
+		}
+	}
 	return result
 }
 
+// generateTitleWith generates a title once the conversation has an assistant response,
+// falling back to GenerateTitle when generate fails. It returns "" while there is no response yet.
+func generateTitleWith(conv *Conversation, generate TitleGenerator) string {
+	if !slices.ContainsFunc(conv.Messages, func(msg Message) bool { return msg.Role == "assistant" }) {
+		return ""
+	}
+	title, err := generate(conv)
+	if err != nil {
+		log.Warn("Chat: unable to generate title for conversation %s: %v", conv.ID, err)
+		return GenerateTitle(conv)
+	}
+	title = strings.Join(strings.Fields(title), " ")
+	title = strings.Trim(title, `"'`)
+	if title == "" {
+		return GenerateTitle(conv)
+	}
+	return util.EllipsisDisplayString(title, maxTitleLength)
+}
+
 // GenerateConversationID creates a new unique conversation identifier.
 func GenerateConversationID() string {
 	b := make([]byte, 4)
@@ -101,10 +154,33 @@ func GenerateConversationID() string {
 	return "conv_" + hex.EncodeToString(b)
 }
 
-// ConversationFilePath returns the git path for a conversation file.
-func ConversationFilePath(conv *Conversation) string {
-	t := conv.CreatedAt
-	return fmt.Sprintf("%d/%02d/%02d/%s.json", t.Year(), t.Month(), t.Day(), conv.ID)
+// ConversationFilePath returns the git path for a conversation file. The path follows the
+// shard of the conversation's entry in index, as assigned by BuildUpdatedIndex; index may be
+// nil for a conversation in no index yet.
+func ConversationFilePath(index *ConversationIndex, conv *Conversation) string {
+	if index != nil {
+		for _, summary := range index.Conversations {
+			if summary.ID == conv.ID {
+				return summaryFilePath(summary)
+			}
+		}
+	}
+	return summaryFilePath(ConversationSummary{ID: conv.ID, CreatedAt: conv.CreatedAt})
+}
+
+// conversationDay returns the day directory of a conversation created at t.
+func conversationDay(t time.Time) string {
+	return fmt.Sprintf("%d/%02d/%02d", t.Year(), t.Month(), t.Day())
+}
+
+// conversationShard returns the sub-directory of a day directory for a conversation ID:
+// the first hex characters of its random part.
+func conversationShard(convID string) string {
+	id := strings.TrimPrefix(convID, "conv_")
+	if len(id) < shardPrefixLength {
+		return ""
+	}
+	return strings.ToLower(id[:shardPrefixLength])
 }
 
 // GenerateTitle creates a conversation title from the first user message.
@@ -139,15 +215,21 @@ func LoadConversation(commit *git.Commit, convID string) (*Conversation, error)
 	// Find the conversation in the index to get its creation date for the path
 	for _, summary := range index.Conversations {
 		if summary.ID == convID {
-			t := summary.CreatedAt
-			path := fmt.Sprintf("%d/%02d/%02d/%s.json", t.Year(), t.Month(), t.Day(), convID)
-			return loadConversationByPath(commit, path)
+			return loadConversationByPath(commit, summaryFilePath(summary))
 		}
 	}
 
 	return nil, nil
 }
 
+// summaryFilePath returns the git path of the conversation file of an index entry.
+func summaryFilePath(summary ConversationSummary) string {
+	if summary.Shard != "" {
+		return fmt.Sprintf("%s/%s/%s.json", conversationDay(summary.CreatedAt), summary.Shard, summary.ID)
+	}
+	return fmt.Sprintf("%s/%s.json", conversationDay(summary.CreatedAt), summary.ID)
+}
+
 func loadConversationByPath(commit *git.Commit, filePath string) (*Conversation, error) {
 	entry, err := commit.GetTreeEntryByPath(filePath)
 	if err != nil {
@@ -213,6 +295,7 @@ func ListConversations(commit *git.Commit, userID string, limit, offset int) ([]
 	}
 
 	// Apply pagination
+	offset = max(offset, 0)
 	if offset >= len(filtered) {
 		return nil, nil
 	}
@@ -224,6 +307,64 @@ func ListConversations(commit *git.Commit, userID string, limit, offset int) ([]
 	return filtered, nil
 }
 
+// SearchConversations returns the summaries of the user's conversations whose title contains
+// query, ignoring case, with the same filtering and pagination as ListConversations. If
+// searchContent is set, the messages of the most recent conversations whose title doesn't
+// match are searched too, reading at most setting.Chat.HistorySearchLimit files.
+func SearchConversations(commit *git.Commit, userID, query string, searchContent bool, limit, offset int) ([]ConversationSummary, error) {
+	all, err := ListConversations(commit, userID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	scanned := 0
+	matched := make([]bool, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(all[i].Title), query) {
+			matched[i] = true
+			continue
+		}
+		if !searchContent || scanned >= setting.Chat.HistorySearchLimit {
+			continue
+		}
+		scanned++
+		conv, err := loadConversationByPath(commit, summaryFilePath(all[i]))
+		if err != nil {
+			log.Warn("Skipping conversation %s in search: %v", all[i].ID, err)
+			continue
+		}
+		matched[i] = conv != nil && conversationContains(conv, query)
+	}
+
+	var results []ConversationSummary
+	for i, summary := range all {
+		if matched[i] {
+			results = append(results, summary)
+		}
+	}
+
+	offset = max(offset, 0)
+	if offset >= len(results) {
+		return nil, nil
+	}
+	results = results[offset:]
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// conversationContains reports whether a user or assistant message contains the lower-cased query.
+func conversationContains(conv *Conversation, query string) bool {
+	for _, msg := range conv.Messages {
+		if (msg.Role == "user" || msg.Role == "assistant") && strings.Contains(strings.ToLower(msg.Content), query) {
+			return true
+		}
+	}
+	return false
+}
+
 // BuildUpdatedIndex creates an updated index incorporating new/modified conversations.
 func BuildUpdatedIndex(existing *ConversationIndex, conversations []*Conversation) *ConversationIndex {
 	if existing == nil {
@@ -233,25 +374,42 @@ func BuildUpdatedIndex(existing *ConversationIndex, conversations []*Conversatio
 		}
 	}
 
-	// Build a map of existing conversations for quick lookup
+	// Build a map of existing conversations for quick lookup, and count the files stored
+	// directly in each day directory to know when new conversations need a shard
 	existingMap := make(map[string]int)
+	dayFiles := make(map[string]int)
 	for i, conv := range existing.Conversations {
 		existingMap[conv.ID] = i
+		if conv.Shard == "" {
+			dayFiles[conversationDay(conv.CreatedAt)]++
+		}
 	}
 
 	for _, conv := range conversations {
 		summary := ConversationSummary{
 			ID:        conv.ID,
-			Title:     GenerateTitle(conv),
+			Title:     util.IfZero(conv.Title, GenerateTitle(conv)),
 			UserHash:  conv.User.ID,
 			CreatedAt: conv.CreatedAt,
 			Turns:     conv.Stats.Turns,
 			CostUSD:   conv.Stats.TotalCostUSD,
+
+			DurationSeconds: conv.Stats.DurationSeconds,
 		}
 
 		if idx, ok := existingMap[conv.ID]; ok {
+			// a conversation file never moves once written
+			summary.Shard = existing.Conversations[idx].Shard
 			existing.Conversations[idx] = summary
 		} else {
+			day := conversationDay(conv.CreatedAt)
+			if threshold := setting.Chat.HistoryShardThreshold; threshold > 0 && dayFiles[day] >= threshold {
+				summary.Shard = conversationShard(conv.ID)
+			}
+			if summary.Shard == "" {
+				dayFiles[day]++
+			}
+			existingMap[conv.ID] = len(existing.Conversations)
 			existing.Conversations = append(existing.Conversations, summary)
 		}
 	}
@@ -312,4 +470,26 @@ func (c *Conversation) AddMessage(msg Message) {
 	for _, tc := range msg.ToolCalls {
 		c.Stats.ToolsCalled = append(c.Stats.ToolsCalled, tc.Tool)
 	}
+
+	if msg.Role == "assistant" {
+		c.Stats.DurationSeconds = int(c.turnsDuration().Seconds())
+	}
+}
+
+// turnsDuration sums the time from each user message to the assistant reply that follows it.
+func (c *Conversation) turnsDuration() time.Duration {
+	var total time.Duration
+	var asked time.Time
+	for _, msg := range c.Messages {
+		switch msg.Role {
+		case "user":
+			asked = msg.Timestamp
+		case "assistant":
+			if !asked.IsZero() && msg.Timestamp.After(asked) {
+				total += msg.Timestamp.Sub(asked)
+			}
+			asked = time.Time{}
+		}
+	}
+	return total
 }
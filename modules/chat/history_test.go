@@ -4,10 +4,16 @@
 package chat
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateConversationID(t *testing.T) {
@@ -23,10 +29,47 @@ func TestConversationFilePath(t *testing.T) {
 		ID:        "conv_abcd1234",
 		CreatedAt: time.Date(2026, 2, 11, 14, 30, 0, 0, time.UTC),
 	}
-	path := ConversationFilePath(conv)
+	path := ConversationFilePath(nil, conv)
 	assert.Equal(t, "2026/02/11/conv_abcd1234.json", path)
 }
 
+func TestConversationFilePath_Sharded(t *testing.T) {
+	defer test.MockVariableValue(&setting.Chat.HistoryShardThreshold, 2)()
+
+	day := time.Date(2026, 2, 11, 14, 30, 0, 0, time.UTC)
+	newConv := func(id string, createdAt time.Time) *Conversation {
+		return &Conversation{ID: id, CreatedAt: createdAt, Messages: []Message{{Role: "user", Content: id}}}
+	}
+
+	first := []*Conversation{newConv("conv_0a000001", day), newConv("conv_0b000002", day)}
+	index := BuildUpdatedIndex(nil, first)
+	assert.Equal(t, "2026/02/11/conv_0a000001.json", ConversationFilePath(index, first[0]))
+	assert.Equal(t, "2026/02/11/conv_0b000002.json", ConversationFilePath(index, first[1]))
+
+	// the day directory is full: new conversations of that day go into a shard by ID,
+	// other days are unaffected
+	third := newConv("conv_Ff000003", day.Add(time.Hour))
+	otherDay := newConv("conv_cd000004", day.AddDate(0, 0, 1))
+	index = BuildUpdatedIndex(index, []*Conversation{third, otherDay})
+	assert.Equal(t, "2026/02/11/ff/conv_Ff000003.json", ConversationFilePath(index, third))
+	assert.Equal(t, "2026/02/12/conv_cd000004.json", ConversationFilePath(index, otherDay))
+
+	// updates keep the path the conversation was first written to, whatever the threshold
+	defer test.MockVariableValue(&setting.Chat.HistoryShardThreshold, 0)()
+	index = BuildUpdatedIndex(index, []*Conversation{third, first[0]})
+	assert.Equal(t, "2026/02/11/ff/conv_Ff000003.json", ConversationFilePath(index, third))
+	assert.Equal(t, "2026/02/11/conv_0a000001.json", ConversationFilePath(index, first[0]))
+	assert.Equal(t, 4, index.TotalConversations)
+
+	// the index round-trips the shard, so loading reconstructs the same path
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	var loaded ConversationIndex
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, "2026/02/11/ff/conv_Ff000003.json", summaryFilePath(loaded.Conversations[2]))
+	assert.Equal(t, "2026/02/12/conv_cd000004.json", summaryFilePath(loaded.Conversations[3]))
+}
+
 func TestGenerateTitle(t *testing.T) {
 	t.Run("NormalMessage", func(t *testing.T) {
 		conv := &Conversation{
@@ -185,6 +228,25 @@ func TestAddMessage(t *testing.T) {
 	assert.Equal(t, []string{"search"}, conv.Stats.ToolsCalled)
 }
 
+func TestAddMessageDuration(t *testing.T) {
+	conv := NewConversation("agent.chat.yaml", "claude-sonnet-4-5", "u1", "User")
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	conv.AddMessage(Message{Role: "user", Content: "Hello", Timestamp: start})
+	assert.Equal(t, 0, conv.Stats.DurationSeconds)
+	conv.AddMessage(Message{Role: "assistant", Content: "Hi", Timestamp: start.Add(2500 * time.Millisecond)})
+	assert.Equal(t, 2, conv.Stats.DurationSeconds)
+
+	// the time between turns is not counted, while fractions of seconds add up across turns
+	conv.AddMessage(Message{Role: "user", Content: "More", Timestamp: start.Add(time.Minute)})
+	conv.AddMessage(Message{Role: "assistant", Content: "Sure", Timestamp: start.Add(time.Minute + 4500*time.Millisecond)})
+	assert.Equal(t, 7, conv.Stats.DurationSeconds)
+
+	index := BuildUpdatedIndex(nil, []*Conversation{conv})
+	require.Len(t, index.Conversations, 1)
+	assert.Equal(t, 7, index.Conversations[0].DurationSeconds)
+}
+
 func TestConversationBuffer(t *testing.T) {
 	buf := &ConversationBuffer{
 		conversations: make(map[string]*Conversation),
@@ -211,3 +273,59 @@ func TestConversationBuffer(t *testing.T) {
 	assert.False(t, buf.ShouldFlush())
 	assert.Empty(t, buf.DrainConversations())
 }
+
+func TestConversationBuffer_LLMTitles(t *testing.T) {
+	newConv := func(messages ...Message) *Conversation {
+		conv := NewConversation("agent.chat.yaml", "model", "user", "User")
+		conv.Messages = messages
+		return conv
+	}
+	question := Message{Role: "user", Content: "What about the retention period of personnel files?"}
+	answer := Message{Role: "assistant", Content: "Personnel files are kept for 75 years."}
+
+	buf := &ConversationBuffer{
+		conversations: make(map[string]*Conversation),
+		lastFlush:     time.Now(),
+	}
+
+	calls := 0
+	generated := newConv(question, answer)
+	buf.BufferConversationWithTitle(generated, func(conv *Conversation) (string, error) {
+		calls++
+		return "  \"Personnel file retention\"\n", nil
+	})
+	assert.Zero(t, calls, "titles are generated when the buffer is drained, not when buffering")
+
+	failed := newConv(question, answer)
+	buf.BufferConversationWithTitle(failed, func(conv *Conversation) (string, error) {
+		return "", errors.New("unavailable")
+	})
+
+	pending := newConv(question)
+	buf.BufferConversationWithTitle(pending, func(conv *Conversation) (string, error) {
+		t.Fatal("no title is generated before the first assistant response")
+		return "", nil
+	})
+
+	titled := newConv(question, answer)
+	titled.Title = "Existing title"
+	buf.BufferConversationWithTitle(titled, func(conv *Conversation) (string, error) {
+		t.Fatal("an existing title is kept")
+		return "", nil
+	})
+
+	plain := newConv(question, answer)
+	buf.BufferConversation(plain)
+
+	require.Len(t, buf.DrainConversations(), 5)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "Personnel file retention", generated.Title)
+	assert.Equal(t, GenerateTitle(failed), failed.Title)
+	assert.Empty(t, pending.Title)
+	assert.Equal(t, "Existing title", titled.Title)
+	assert.Empty(t, plain.Title)
+
+	index := BuildUpdatedIndex(nil, []*Conversation{generated, plain})
+	assert.Equal(t, "Personnel file retention", index.Conversations[0].Title)
+	assert.Equal(t, GenerateTitle(plain), index.Conversations[1].Title)
+}
@@ -285,35 +285,43 @@ func TestEstimateCost(t *testing.T) {
 	}
 }
 
-// EstimateCost is exported for testing; mirrors the handler's estimateCost function.
-func EstimateCost(inputTokens, outputTokens int, model string) float64 {
-	var inputRate, outputRate float64
-	switch {
-	case contains(model, "opus"):
-		inputRate = 5.0
-		outputRate = 25.0
-	case contains(model, "sonnet"):
-		inputRate = 3.0
-		outputRate = 15.0
-	case contains(model, "haiku"):
-		inputRate = 0.25
-		outputRate = 1.25
-	default:
-		inputRate = 3.0
-		outputRate = 15.0
-	}
-	return (float64(inputTokens)*inputRate + float64(outputTokens)*outputRate) / 1_000_000
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
+func TestEstimateOpenAICost(t *testing.T) {
+	assert.InDelta(t, 0.0075, estimateOpenAICost(1000, 500, "gpt-4o"), 1e-9)
+	assert.InDelta(t, 0.00045, estimateOpenAICost(1000, 500, "gpt-4o-mini-2024-07-18"), 1e-9)
+	assert.InDelta(t, 0.00625, estimateOpenAICost(1000, 500, "gpt-5"), 1e-9)
+	assert.InDelta(t, 0.0033, estimateOpenAICost(1000, 500, "o3-mini"), 1e-9)
+
+	// unknown models aren't priced with another provider's rates
+	assert.Zero(t, estimateOpenAICost(1000, 500, "claude-sonnet-4-5"))
+	assert.Zero(t, estimateOpenAICost(1000, 500, "my-finetune"))
 }
 
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+func TestClaudeMessageContentBlocks(t *testing.T) {
+	// Without blocks the content stays a plain string
+	data, err := json.Marshal(ClaudeMessage{Role: "user", Content: "Hello"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":"Hello"}`, string(data))
+
+	msg := ClaudeMessage{
+		Role:    "user",
+		Content: "What is in this image?",
+		Blocks: []ClaudeContentBlock{
+			{Type: "image", Source: &ClaudeBlockSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+			{Type: "document", Title: "README.md", Source: &ClaudeBlockSource{Type: "text", MediaType: "text/plain", Data: "# repo"}},
+		},
 	}
-	return false
+	data, err = json.Marshal(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":[
+		{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}},
+		{"type":"document","title":"README.md","source":{"type":"text","media_type":"text/plain","data":"# repo"}},
+		{"type":"text","text":"What is in this image?"}
+	]}`, string(data))
+
+	var restored ClaudeMessage
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, msg, restored)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"role":"assistant","content":"Hi"}`), &restored))
+	assert.Equal(t, ClaudeMessage{Role: "assistant", Content: "Hi"}, restored)
 }
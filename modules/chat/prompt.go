@@ -0,0 +1,51 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"strings"
+	"text/template"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// PromptData is the context llm.system_prompt is rendered with as a text/template, e.g.
+// "You answer questions about {{.Repo.Owner}}/{{.Repo.Name}}".
+type PromptData struct {
+	Repo  PromptRepo
+	Stats PromptStats
+}
+
+// PromptRepo describes the repository the agent belongs to.
+type PromptRepo struct {
+	Name          string
+	Owner         string
+	DefaultBranch string
+}
+
+// PromptStats holds the entity counts of the repository's MCP index; they are zero if the
+// repository has no MCP config or its index could not be built.
+type PromptStats struct {
+	TotalEntities int
+	TypeCounts    map[string]int
+}
+
+// RenderSystemPrompt renders prompt as a template with data. A prompt that fails to parse or
+// execute, e.g. because it refers to an unknown field, is returned unchanged.
+func RenderSystemPrompt(prompt string, data PromptData) string {
+	if !strings.Contains(prompt, "{{") {
+		return prompt
+	}
+	tmpl, err := template.New("system_prompt").Option("missingkey=error").Parse(prompt)
+	if err != nil {
+		log.Debug("Chat system prompt is not a valid template, sending it verbatim: %v", err)
+		return prompt
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		log.Debug("Chat system prompt template failed, sending it verbatim: %v", err)
+		return prompt
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSystemPrompt(t *testing.T) {
+	data := PromptData{
+		Repo:  PromptRepo{Name: "register", Owner: "gov", DefaultBranch: "main"},
+		Stats: PromptStats{TotalEntities: 5, TypeCounts: map[string]int{"ministry": 2, "organization": 3}},
+	}
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{"no template", "Be brief.", "Be brief."},
+		{"repo", "You answer about {{.Repo.Owner}}/{{.Repo.Name}} ({{.Repo.DefaultBranch}}).", "You answer about gov/register (main)."},
+		{"stats", "It has {{.Stats.TotalEntities}} entities, {{index .Stats.TypeCounts \"ministry\"}} ministries.", "It has 5 entities, 2 ministries."},
+		{"parse error", "Hello {{.Repo.Name", "Hello {{.Repo.Name"},
+		{"unknown field", "Hello {{.Repo.Title}}", "Hello {{.Repo.Title}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenderSystemPrompt(tt.prompt, data))
+		})
+	}
+
+	// without an MCP index the stats are zero
+	assert.Equal(t, "0 entities", RenderSystemPrompt("{{.Stats.TotalEntities}} entities", PromptData{}))
+}
+
+func TestBuildClaudeRequest_SystemPromptTemplate(t *testing.T) {
+	cfg, conv := newProviderTestConversation()
+	cfg.LLM.SystemPrompt = "Answer about {{.Repo.Name}} with {{.Stats.TotalEntities}} entities."
+
+	req, err := BuildClaudeRequest(cfg, conv, ProviderOptions{Prompt: PromptData{
+		Repo:  PromptRepo{Name: "register"},
+		Stats: PromptStats{TotalEntities: 42},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Answer about register with 42 entities.", req.System)
+}
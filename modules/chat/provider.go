@@ -0,0 +1,272 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// streamTimeout bounds a complete streamed response of a provider.
+const streamTimeout = 5 * time.Minute
+
+// Provider streams the assistant's reply to a conversation from an LLM backend.
+//
+// Stream returns an error if the request cannot be built or is rejected by the backend; errors
+// wrapping util.ErrInvalidArgument are caused by the conversation, e.g. an unreadable attachment,
+// and a rejection by the backend is an *UpstreamError.
+// Otherwise the reply is delivered as "text" and "tool_call" events followed by a single "done"
+// event carrying the usage, or an "error" event if the stream breaks. Canceling ctx aborts the
+// upstream request and ends the stream with a "canceled" event carrying the usage consumed so
+// far, so callers must keep receiving until the channel is closed.
+type Provider interface {
+	Stream(ctx context.Context, cfg *ChatConfig, conv *Conversation) (<-chan SSEEvent, error)
+}
+
+// Titler is implemented by providers that can title a conversation with a model call.
+type Titler interface {
+	GenerateTitle(conv *Conversation) (string, error)
+}
+
+// ProviderOptions holds the per-request state a provider needs besides the agent config.
+type ProviderOptions struct {
+	APIKey string
+	// Commit is the repository commit that message attachments are read from.
+	Commit *git.Commit
+	// RepoMCP is the repository's own MCP server, offered to the model when mcp.use_repo_mcp is set.
+	RepoMCP MCPServerEntry
+	// Prompt fills the template variables of llm.system_prompt.
+	Prompt PromptData
+}
+
+// NewProvider returns the provider for the given llm.provider name.
+func NewProvider(name string, opts ProviderOptions) (Provider, error) {
+	switch name {
+	case "anthropic":
+		return &anthropicProvider{opts: opts}, nil
+	case "openai":
+		return &openAIProvider{opts: opts}, nil
+	case "ollama":
+		return &ollamaProvider{opts: opts}, nil
+	default:
+		return nil, fmt.Errorf("llm.provider %q is not supported", name)
+	}
+}
+
+// conversationMessages returns the user and assistant messages of the conversation with
+// their attachments read from opts.Commit. An attachment of the latest message that cannot be
+// read is an invalid argument error, while one of an earlier message (e.g. since deleted from
+// the repository) is replaced by a note.
+func conversationMessages(conv *Conversation, opts ProviderOptions) ([]ClaudeMessage, error) {
+	messages := make([]ClaudeMessage, 0, len(conv.Messages))
+	for i, msg := range conv.Messages {
+		if msg.Role != "user" && msg.Role != "assistant" {
+			continue
+		}
+		claudeMsg := ClaudeMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+		if len(msg.Attachments) > 0 {
+			blocks, err := ResolveAttachments(opts.Commit, msg.Attachments)
+			if err != nil {
+				if i == len(conv.Messages)-1 {
+					return nil, util.NewInvalidArgumentErrorf("%v", err)
+				}
+				claudeMsg.Content += fmt.Sprintf("\n\n[Attachments unavailable: %v]", err)
+			}
+			claudeMsg.Blocks = blocks
+		}
+		messages = append(messages, claudeMsg)
+	}
+	return messages, nil
+}
+
+// splitBlocks prepares a message for backends without document blocks: text documents are
+// inlined into the returned text and base64 images are returned separately. Other documents
+// are rejected for the latest message and replaced by a note in earlier ones.
+func splitBlocks(msg ClaudeMessage, latest bool) (string, []*ClaudeBlockSource, error) {
+	var texts []string
+	var images []*ClaudeBlockSource
+	for _, block := range msg.Blocks {
+		switch {
+		case block.Type == "image":
+			images = append(images, block.Source)
+		case block.Type == "document" && block.Source.Type == "text":
+			texts = append(texts, fmt.Sprintf("<document title=%q>\n%s\n</document>", block.Title, block.Source.Data))
+		case latest:
+			return "", nil, util.NewInvalidArgumentErrorf("attachment %s is not supported by this provider", block.Title)
+		default:
+			texts = append(texts, fmt.Sprintf("[Attachment %s unavailable: not supported by this provider]", block.Title))
+		}
+	}
+	return strings.Join(append(texts, msg.Content), "\n\n"), images, nil
+}
+
+// mcpServers returns the MCP servers the agent may use: the repository's own server if
+// enabled, followed by the additional servers of the config.
+func mcpServers(cfg *ChatConfig, opts ProviderOptions) []MCPServerEntry {
+	var servers []MCPServerEntry
+	if cfg.MCP.UseRepoMCP && opts.RepoMCP.URL != "" {
+		servers = append(servers, opts.RepoMCP)
+	}
+	return append(servers, cfg.MCP.AdditionalServers...)
+}
+
+// titlePrompt is the system prompt of Titler implementations.
+const titlePrompt = "Write a concise title of at most six words for this conversation. Use the language of the question. Reply with the title only."
+
+// firstExchange returns the first question of the user and the first answer to it.
+func firstExchange(conv *Conversation) (question, answer string) {
+	for _, msg := range conv.Messages {
+		if msg.Role == "user" && question == "" {
+			question = msg.Content
+		} else if msg.Role == "assistant" && answer == "" {
+			answer = msg.Content
+		}
+	}
+	return question, answer
+}
+
+// postJSON sends body as JSON to url and returns the response if the backend accepted the request.
+func postJSON(ctx context.Context, url string, headers map[string]string, body any, timeout time.Duration) (*http.Response, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &UpstreamError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
+
+// UpstreamError is returned when the LLM backend rejects a request. Its Error holds the backend's
+// response, which may reveal configuration details, so it is meant for the server log while
+// UserMessage is shown to the user.
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// UserMessage describes the error without the backend's response. A rejected API key or
+// missing permission is the operator's problem, so the user is only told to contact them.
+func (e *UpstreamError) UserMessage() string {
+	return upstreamUserMessage(e.StatusCode)
+}
+
+func upstreamUserMessage(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "chat is misconfigured, contact the operator"
+	case http.StatusTooManyRequests:
+		return "the chat provider is rate limiting requests, try again later"
+	case http.StatusServiceUnavailable, statusOverloaded:
+		return "the chat provider is overloaded, try again later"
+	default:
+		return fmt.Sprintf("the chat provider failed to answer (status %d)", statusCode)
+	}
+}
+
+// statusOverloaded is the status Anthropic answers with when its API is overloaded.
+const statusOverloaded = 529
+
+// streamEvents runs produce in a goroutine and returns the channel it sends events to.
+// produce keeps usage up to date as the backend reports it; estimateCost prices the usage with
+// the backend's rates, or is nil for backends that do not charge. If ctx is canceled before produce
+// sent a "done" or "error" event, the stream ends with a "canceled" event carrying the partial
+// usage, with the output tokens estimated from the text streamed so far if the backend did not
+// report them yet. The response body is closed and the channel is closed once produce returns.
+func streamEvents(ctx context.Context, resp *http.Response, estimateCost func(inputTokens, outputTokens int) float64, usage *Usage, produce func(send func(SSEEvent) bool)) <-chan SSEEvent {
+	events := make(chan SSEEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		var streamed int
+		var finished bool
+		produce(func(event SSEEvent) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			select {
+			case events <- event:
+				streamed += len(event.Text)
+				finished = event.Type == "done" || event.Type == "error"
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if finished || ctx.Err() == nil {
+			return
+		}
+
+		partial := *usage
+		if partial.OutputTokens == 0 {
+			partial.OutputTokens = estimateTokens(streamed)
+		}
+		if estimateCost != nil {
+			partial.CostUSD = estimateCost(partial.InputTokens, partial.OutputTokens)
+		}
+		events <- SSEEvent{Type: "canceled", Usage: &partial}
+	}()
+	return events
+}
+
+// estimateTokens approximates the number of tokens of a text of the given byte length.
+func estimateTokens(length int) int {
+	return (length + 3) / 4
+}
+
+// EstimateCost approximates the cost of an Anthropic request in USD from per-million-token rates
+// of the model family.
+func EstimateCost(inputTokens, outputTokens int, model string) float64 {
+	var inputRate, outputRate float64
+	switch {
+	case strings.Contains(model, "opus"):
+		inputRate = 5.0
+		outputRate = 25.0
+	case strings.Contains(model, "sonnet"):
+		inputRate = 3.0
+		outputRate = 15.0
+	case strings.Contains(model, "haiku"):
+		inputRate = 0.25
+		outputRate = 1.25
+	default:
+		inputRate = 3.0
+		outputRate = 15.0
+	}
+
+	return (float64(inputTokens)*inputRate + float64(outputTokens)*outputRate) / 1_000_000
+}
@@ -0,0 +1,255 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	anthropicAPIVersion = "2023-06-01"
+	anthropicMCPBeta    = "mcp-client-2025-11-20"
+
+	// anthropicTitleModel is the inexpensive model that titles conversations when history.llm_titles is set.
+	anthropicTitleModel = "claude-haiku-4-5"
+)
+
+// anthropicProvider streams replies from the Claude Messages API, which connects to the
+// configured MCP servers itself.
+type anthropicProvider struct {
+	opts ProviderOptions
+}
+
+func (p *anthropicProvider) messagesURL() string {
+	return setting.Chat.AnthropicBaseURL + "/v1/messages"
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.opts.APIKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+}
+
+// Stream implements Provider.
+func (p *anthropicProvider) Stream(ctx context.Context, cfg *ChatConfig, conv *Conversation) (<-chan SSEEvent, error) {
+	req, err := BuildClaudeRequest(cfg, conv, p.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := p.headers()
+	headers["anthropic-beta"] = anthropicMCPBeta
+	resp, err := postJSON(ctx, p.messagesURL(), headers, req, streamTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{}
+	estimateCost := func(inputTokens, outputTokens int) float64 {
+		return EstimateCost(inputTokens, outputTokens, req.Model)
+	}
+	return streamEvents(ctx, resp, estimateCost, usage, func(send func(SSEEvent) bool) {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			if data == "[DONE]" {
+				break
+			}
+
+			var event map[string]any
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+
+			eventType, _ := event["type"].(string)
+			switch eventType {
+			case "content_block_delta":
+				delta, ok := event["delta"].(map[string]any)
+				if !ok {
+					continue
+				}
+				deltaType, _ := delta["type"].(string)
+				if deltaType == "text_delta" {
+					text, _ := delta["text"].(string)
+					if !send(SSEEvent{Type: "text", Text: text}) {
+						return
+					}
+				}
+
+			case "content_block_start":
+				block, ok := event["content_block"].(map[string]any)
+				if !ok {
+					continue
+				}
+				blockType, _ := block["type"].(string)
+				if blockType == "mcp_tool_use" {
+					toolName, _ := block["name"].(string)
+					serverName, _ := block["server_name"].(string)
+					if !send(SSEEvent{Type: "tool_call", Tool: toolName, Server: serverName}) {
+						return
+					}
+				}
+
+			case "message_delta":
+				if u, ok := event["usage"].(map[string]any); ok {
+					if v, ok := u["output_tokens"].(float64); ok {
+						usage.OutputTokens = int(v)
+					}
+				}
+
+			case "error":
+				// the backend failed after the stream started, e.g. because it is overloaded
+				errType, errMessage := "", ""
+				if e, ok := event["error"].(map[string]any); ok {
+					errType, _ = e["type"].(string)
+					errMessage, _ = e["message"].(string)
+				}
+				log.Error("Anthropic stream error %s: %s", errType, errMessage)
+				send(SSEEvent{Type: "error", Text: upstreamUserMessage(anthropicErrorStatus(errType))})
+				return
+
+			case "message_start":
+				if msg, ok := event["message"].(map[string]any); ok {
+					if u, ok := msg["usage"].(map[string]any); ok {
+						if v, ok := u["input_tokens"].(float64); ok {
+							usage.InputTokens = int(v)
+						}
+					}
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(SSEEvent{Type: "error", Text: fmt.Sprintf("stream interrupted: %v", err)})
+			return
+		}
+
+		usage.CostUSD = estimateCost(usage.InputTokens, usage.OutputTokens)
+		send(SSEEvent{Type: "done", Usage: usage})
+	}), nil
+}
+
+// anthropicErrorStatus returns the HTTP status Anthropic uses for errors of the given type.
+func anthropicErrorStatus(errType string) int {
+	switch errType {
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return statusOverloaded
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GenerateTitle implements Titler by asking anthropicTitleModel for a concise title of the
+// conversation's first exchange in a single, non-streaming call.
+func (p *anthropicProvider) GenerateTitle(conv *Conversation) (string, error) {
+	question, answer := firstExchange(conv)
+	req := &ClaudeRequest{
+		Model:     anthropicTitleModel,
+		MaxTokens: 32,
+		System:    titlePrompt,
+		Messages: []ClaudeMessage{{
+			Role:    "user",
+			Content: fmt.Sprintf("Question: %s\n\nAnswer: %s", util.TruncateRunes(question, 1000), util.TruncateRunes(answer, 1000)),
+		}},
+	}
+
+	resp, err := postJSON(context.Background(), p.messagesURL(), p.headers(), req, 30*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			return block.Text, nil
+		}
+	}
+	return "", errors.New("response has no text content")
+}
+
+// BuildClaudeRequest converts the conversation into a Claude API request.
+func BuildClaudeRequest(cfg *ChatConfig, conv *Conversation, opts ProviderOptions) (*ClaudeRequest, error) {
+	messages, err := conversationMessages(conv, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ClaudeRequest{
+		Model:       cfg.LLM.Model,
+		MaxTokens:   cfg.LLM.MaxTokens,
+		System:      RenderSystemPrompt(cfg.LLM.SystemPrompt, opts.Prompt),
+		Messages:    messages,
+		Stream:      true,
+		Temperature: cfg.LLM.Temperature,
+
+		StopSequences: cfg.LLM.StopSequences,
+	}
+
+	// Build MCP server configurations
+	for _, server := range mcpServers(cfg, opts) {
+		req.MCPServers = append(req.MCPServers, ClaudeMCPServer{
+			Type: "url",
+			URL:  server.URL,
+			Name: server.Name,
+		})
+	}
+
+	// Build tool configurations
+	for _, mcpServer := range req.MCPServers {
+		tool := ClaudeTool{
+			Type:          "mcp_toolset",
+			MCPServerName: mcpServer.Name,
+		}
+
+		// Apply tool allow/deny lists
+		if len(cfg.MCP.AllowedTools) > 0 {
+			// Default all tools to disabled, enable only allowed ones
+			tool.DefaultConfig = &ClaudeToolDefaultConfig{Enabled: false}
+			tool.Configs = make(map[string]ClaudeToolOverride)
+			for _, toolName := range cfg.MCP.AllowedTools {
+				tool.Configs[toolName] = ClaudeToolOverride{Enabled: true}
+			}
+		} else if len(cfg.MCP.DeniedTools) > 0 {
+			// Default all tools to enabled, disable denied ones
+			tool.DefaultConfig = &ClaudeToolDefaultConfig{Enabled: true}
+			tool.Configs = make(map[string]ClaudeToolOverride)
+			for _, toolName := range cfg.MCP.DeniedTools {
+				tool.Configs[toolName] = ClaudeToolOverride{Enabled: false}
+			}
+		}
+
+		req.Tools = append(req.Tools, tool)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ollamaProvider streams replies from a self-hosted Ollama server. MCP servers are not passed
+// on and usage is reported without cost.
+type ollamaProvider struct {
+	opts ProviderOptions
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // base64 encoded
+}
+
+type ollamaOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type ollamaChunk struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// Stream implements Provider.
+func (p *ollamaProvider) Stream(ctx context.Context, cfg *ChatConfig, conv *Conversation) (<-chan SSEEvent, error) {
+	req, err := p.buildRequest(cfg, conv)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	if p.opts.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.opts.APIKey
+	}
+	resp, err := postJSON(ctx, setting.Chat.OllamaBaseURL+"/api/chat", headers, req, streamTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{}
+	return streamEvents(ctx, resp, nil, usage, func(send func(SSEEvent) bool) {
+		// The response is a stream of JSON objects, one per line
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var chunk ollamaChunk
+			if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != "" {
+				send(SSEEvent{Type: "error", Text: chunk.Error})
+				return
+			}
+			if chunk.Message.Content != "" && !send(SSEEvent{Type: "text", Text: chunk.Message.Content}) {
+				return
+			}
+			if chunk.Done {
+				usage.InputTokens, usage.OutputTokens = chunk.PromptEvalCount, chunk.EvalCount
+				send(SSEEvent{Type: "done", Usage: usage})
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(SSEEvent{Type: "error", Text: fmt.Sprintf("stream interrupted: %v", err)})
+			return
+		}
+		send(SSEEvent{Type: "error", Text: "stream ended unexpectedly"})
+	}), nil
+}
+
+func (p *ollamaProvider) buildRequest(cfg *ChatConfig, conv *Conversation) (*ollamaRequest, error) {
+	messages, err := conversationMessages(conv, p.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ollamaRequest{
+		Model:   cfg.LLM.Model,
+		Stream:  true,
+		Options: ollamaOptions{NumPredict: cfg.LLM.MaxTokens, Temperature: cfg.LLM.Temperature, Stop: cfg.LLM.StopSequences},
+	}
+	if cfg.LLM.SystemPrompt != "" {
+		req.Messages = append(req.Messages, ollamaMessage{Role: "system", Content: RenderSystemPrompt(cfg.LLM.SystemPrompt, p.opts.Prompt)})
+	}
+
+	for i, msg := range messages {
+		text, images, err := splitBlocks(msg, i == len(messages)-1)
+		if err != nil {
+			return nil, err
+		}
+		ollamaMsg := ollamaMessage{Role: msg.Role, Content: text}
+		for _, image := range images {
+			ollamaMsg.Images = append(ollamaMsg.Images, image.Data)
+		}
+		req.Messages = append(req.Messages, ollamaMsg)
+	}
+	return req, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// openAIProvider streams replies from the OpenAI Chat Completions API. MCP servers are not
+// passed on since the API cannot call them.
+type openAIProvider struct {
+	opts ProviderOptions
+}
+
+type openAIRequest struct {
+	Model               string              `json:"model"`
+	Messages            []openAIMessage     `json:"messages"`
+	MaxCompletionTokens int                 `json:"max_completion_tokens,omitempty"`
+	Temperature         float64             `json:"temperature,omitempty"`
+	Stream              bool                `json:"stream"`
+	StreamOptions       *openAIStreamOption `json:"stream_options,omitempty"`
+	Stop                []string            `json:"stop,omitempty"`
+}
+
+type openAIStreamOption struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIMessage has either a string content or, for messages with images, an array of parts.
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Stream implements Provider.
+func (p *openAIProvider) Stream(ctx context.Context, cfg *ChatConfig, conv *Conversation) (<-chan SSEEvent, error) {
+	req, err := p.buildRequest(cfg, conv)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + p.opts.APIKey}
+	resp, err := postJSON(ctx, setting.Chat.OpenAIBaseURL+"/v1/chat/completions", headers, req, streamTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{}
+	estimateCost := func(inputTokens, outputTokens int) float64 {
+		return estimateOpenAICost(inputTokens, outputTokens, req.Model)
+	}
+	return streamEvents(ctx, resp, estimateCost, usage, func(send func(SSEEvent) bool) {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			if data == "[DONE]" {
+				break
+			}
+
+			var chunk openAIChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			for _, choice := range chunk.Choices {
+				if choice.Delta.Content == "" {
+					continue
+				}
+				if !send(SSEEvent{Type: "text", Text: choice.Delta.Content}) {
+					return
+				}
+			}
+			if chunk.Usage != nil {
+				usage.InputTokens = chunk.Usage.PromptTokens
+				usage.OutputTokens = chunk.Usage.CompletionTokens
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(SSEEvent{Type: "error", Text: fmt.Sprintf("stream interrupted: %v", err)})
+			return
+		}
+
+		usage.CostUSD = estimateCost(usage.InputTokens, usage.OutputTokens)
+		send(SSEEvent{Type: "done", Usage: usage})
+	}), nil
+}
+
+// openAIRates are the USD per-million-token input and output rates of OpenAI models, matched
+// by model name prefix in order, so more specific prefixes come first.
+var openAIRates = []struct {
+	prefix                string
+	inputRate, outputRate float64
+}{
+	{"gpt-5-nano", 0.05, 0.40},
+	{"gpt-5-mini", 0.25, 2.00},
+	{"gpt-5", 1.25, 10.00},
+	{"gpt-4.1-nano", 0.10, 0.40},
+	{"gpt-4.1-mini", 0.40, 1.60},
+	{"gpt-4.1", 2.00, 8.00},
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10.00},
+	{"gpt-4-turbo", 10.00, 30.00},
+	{"gpt-3.5-turbo", 0.50, 1.50},
+	{"o4-mini", 1.10, 4.40},
+	{"o3-mini", 1.10, 4.40},
+	{"o3", 2.00, 8.00},
+	{"o1-mini", 1.10, 4.40},
+	{"o1", 15.00, 60.00},
+}
+
+// unpricedOpenAIModels remembers the models without rates that were already logged.
+var unpricedOpenAIModels sync.Map
+
+// estimateOpenAICost approximates the cost of an OpenAI request in USD. Models without rates
+// are reported as free, which is logged once per model, rather than guessed.
+func estimateOpenAICost(inputTokens, outputTokens int, model string) float64 {
+	for _, rates := range openAIRates {
+		if strings.HasPrefix(model, rates.prefix) {
+			return (float64(inputTokens)*rates.inputRate + float64(outputTokens)*rates.outputRate) / 1_000_000
+		}
+	}
+	if _, logged := unpricedOpenAIModels.LoadOrStore(model, true); !logged {
+		log.Warn("Chat: no rates for OpenAI model %q, its cost is reported as 0 and not counted toward budgets", model)
+	}
+	return 0
+}
+
+func (p *openAIProvider) buildRequest(cfg *ChatConfig, conv *Conversation) (*openAIRequest, error) {
+	messages, err := conversationMessages(conv, p.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &openAIRequest{
+		Model:               cfg.LLM.Model,
+		MaxCompletionTokens: cfg.LLM.MaxTokens,
+		Temperature:         cfg.LLM.Temperature,
+		Stream:              true,
+		StreamOptions:       &openAIStreamOption{IncludeUsage: true},
+		Stop:                cfg.LLM.StopSequences,
+	}
+	if cfg.LLM.SystemPrompt != "" {
+		req.Messages = append(req.Messages, openAIMessage{Role: "system", Content: RenderSystemPrompt(cfg.LLM.SystemPrompt, p.opts.Prompt)})
+	}
+
+	for i, msg := range messages {
+		text, images, err := splitBlocks(msg, i == len(messages)-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(images) == 0 {
+			req.Messages = append(req.Messages, openAIMessage{Role: msg.Role, Content: text})
+			continue
+		}
+
+		parts := make([]openAIContentPart, 0, len(images)+1)
+		for _, image := range images {
+			parts = append(parts, openAIContentPart{
+				Type:     "image_url",
+				ImageURL: &openAIImageURL{URL: "data:" + image.MediaType + ";base64," + image.Data},
+			})
+		}
+		parts = append(parts, openAIContentPart{Type: "text", Text: text})
+		req.Messages = append(req.Messages, openAIMessage{Role: msg.Role, Content: parts})
+	}
+	return req, nil
+}
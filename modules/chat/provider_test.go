@@ -0,0 +1,257 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProviderTestServer serves body at path and records the decoded request and headers.
+func newProviderTestServer(t *testing.T, path, body string) (*httptest.Server, *map[string]any, *http.Header) {
+	var request map[string]any
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, path, r.URL.Path)
+		header = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(data, &request))
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &request, &header
+}
+
+func collectEvents(events <-chan SSEEvent) []SSEEvent {
+	var collected []SSEEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	return collected
+}
+
+func newProviderTestConversation() (*ChatConfig, *Conversation) {
+	cfg := &ChatConfig{LLM: LLMConfig{Model: "test-model", MaxTokens: 500, Temperature: 0.2, SystemPrompt: "Be brief."}}
+	conv := NewConversation("agent.chat.yaml", cfg.LLM.Model, "1", "user1")
+	conv.AddMessage(Message{Role: "user", Content: "Hello"})
+	return cfg, conv
+}
+
+func TestAnthropicProviderStream(t *testing.T) {
+	server, request, header := newProviderTestServer(t, "/v1/messages", `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":12}}}
+
+data: {"type":"content_block_start","content_block":{"type":"mcp_tool_use","name":"search","server_name":"repo-mcp"}}
+
+data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hi "}}
+
+data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"there"}}
+
+data: {"type":"message_delta","usage":{"output_tokens":3}}
+`)
+	defer test.MockVariableValue(&setting.Chat.AnthropicBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	cfg.MCP.UseRepoMCP = true
+	cfg.LLM.StopSequences = []string{"END"}
+	provider, err := NewProvider("anthropic", ProviderOptions{APIKey: "secret", RepoMCP: MCPServerEntry{Name: "repo-mcp", URL: "https://example.com/owner/repo/mcp"}})
+	require.NoError(t, err)
+
+	events, err := provider.Stream(t.Context(), cfg, conv)
+	require.NoError(t, err)
+	assert.Equal(t, []SSEEvent{
+		{Type: "tool_call", Tool: "search", Server: "repo-mcp"},
+		{Type: "text", Text: "Hi "},
+		{Type: "text", Text: "there"},
+		{Type: "done", Usage: &Usage{InputTokens: 12, OutputTokens: 3, CostUSD: EstimateCost(12, 3, "test-model")}},
+	}, collectEvents(events))
+
+	assert.Equal(t, "secret", header.Get("x-api-key"))
+	assert.Equal(t, anthropicMCPBeta, header.Get("anthropic-beta"))
+	assert.Equal(t, "Be brief.", (*request)["system"])
+	assert.Equal(t, []any{"END"}, (*request)["stop_sequences"])
+	assert.Equal(t, []any{map[string]any{"type": "url", "url": "https://example.com/owner/repo/mcp", "name": "repo-mcp"}}, (*request)["mcp_servers"])
+}
+
+func TestOpenAIProviderStream(t *testing.T) {
+	server, request, header := newProviderTestServer(t, "/v1/chat/completions", `data: {"choices":[{"delta":{"role":"assistant","content":""}}]}
+
+data: {"choices":[{"delta":{"content":"Hi "}}]}
+
+data: {"choices":[{"delta":{"content":"there"}}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":9,"completion_tokens":2}}
+
+data: [DONE]
+`)
+	defer test.MockVariableValue(&setting.Chat.OpenAIBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("openai", ProviderOptions{APIKey: "secret"})
+	require.NoError(t, err)
+
+	events, err := provider.Stream(t.Context(), cfg, conv)
+	require.NoError(t, err)
+	assert.Equal(t, []SSEEvent{
+		{Type: "text", Text: "Hi "},
+		{Type: "text", Text: "there"},
+		// test-model has no OpenAI rates
+		{Type: "done", Usage: &Usage{InputTokens: 9, OutputTokens: 2}},
+	}, collectEvents(events))
+
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+	assert.Equal(t, []any{
+		map[string]any{"role": "system", "content": "Be brief."},
+		map[string]any{"role": "user", "content": "Hello"},
+	}, (*request)["messages"])
+	assert.EqualValues(t, 500, (*request)["max_completion_tokens"])
+}
+
+func TestOllamaProviderStream(t *testing.T) {
+	server, request, _ := newProviderTestServer(t, "/api/chat", `{"message":{"role":"assistant","content":"Hi "},"done":false}
+{"message":{"role":"assistant","content":"there"},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":7,"eval_count":2}
+`)
+	defer test.MockVariableValue(&setting.Chat.OllamaBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("ollama", ProviderOptions{})
+	require.NoError(t, err)
+
+	events, err := provider.Stream(t.Context(), cfg, conv)
+	require.NoError(t, err)
+	assert.Equal(t, []SSEEvent{
+		{Type: "text", Text: "Hi "},
+		{Type: "text", Text: "there"},
+		{Type: "done", Usage: &Usage{InputTokens: 7, OutputTokens: 2}},
+	}, collectEvents(events))
+
+	assert.Equal(t, map[string]any{"num_predict": float64(500), "temperature": 0.2}, (*request)["options"])
+}
+
+func TestProviderStreamErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer test.MockVariableValue(&setting.Chat.AnthropicBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("anthropic", ProviderOptions{})
+	require.NoError(t, err)
+	_, err = provider.Stream(t.Context(), cfg, conv)
+	assert.ErrorContains(t, err, "API returned status 503")
+	assert.False(t, errors.Is(err, util.ErrInvalidArgument))
+
+	_, err = NewProvider("mistral", ProviderOptions{})
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestProviderStreamUpstreamAuthError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key sk-ant-123"}}`))
+	}))
+	defer server.Close()
+	defer test.MockVariableValue(&setting.Chat.AnthropicBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("anthropic", ProviderOptions{APIKey: "sk-ant-123"})
+	require.NoError(t, err)
+	_, err = provider.Stream(t.Context(), cfg, conv)
+
+	var upstreamErr *UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusUnauthorized, upstreamErr.StatusCode)
+	// the upstream response is kept for the log but not shown to the user
+	assert.Contains(t, err.Error(), "invalid x-api-key")
+	assert.Equal(t, "chat is misconfigured, contact the operator", upstreamErr.UserMessage())
+
+	assert.Equal(t, "chat is misconfigured, contact the operator", (&UpstreamError{StatusCode: http.StatusForbidden}).UserMessage())
+	assert.Contains(t, (&UpstreamError{StatusCode: http.StatusTooManyRequests}).UserMessage(), "rate limiting")
+	assert.Contains(t, (&UpstreamError{StatusCode: 529}).UserMessage(), "overloaded")
+}
+
+func TestAnthropicProviderStreamError(t *testing.T) {
+	server, _, _ := newProviderTestServer(t, "/v1/messages", `data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hi"}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+`)
+	defer test.MockVariableValue(&setting.Chat.AnthropicBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("anthropic", ProviderOptions{})
+	require.NoError(t, err)
+	events, err := provider.Stream(t.Context(), cfg, conv)
+	require.NoError(t, err)
+	assert.Equal(t, []SSEEvent{
+		{Type: "text", Text: "Hi"},
+		{Type: "error", Text: "the chat provider is overloaded, try again later"},
+	}, collectEvents(events))
+}
+
+func TestProviderStreamCanceled(t *testing.T) {
+	upstreamCanceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `data: {"type":"message_start","message":{"usage":{"input_tokens":12}}}
+
+data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hello there"}}
+
+`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(upstreamCanceled)
+	}))
+	defer server.Close()
+	defer test.MockVariableValue(&setting.Chat.AnthropicBaseURL, server.URL)()
+
+	cfg, conv := newProviderTestConversation()
+	provider, err := NewProvider("anthropic", ProviderOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events, err := provider.Stream(ctx, cfg, conv)
+	require.NoError(t, err)
+
+	assert.Equal(t, SSEEvent{Type: "text", Text: "Hello there"}, <-events)
+	cancel()
+	assert.Equal(t, []SSEEvent{
+		{Type: "canceled", Usage: &Usage{InputTokens: 12, OutputTokens: 3, CostUSD: EstimateCost(12, 3, "test-model")}},
+	}, collectEvents(events))
+	<-upstreamCanceled
+}
+
+func TestSplitBlocks(t *testing.T) {
+	msg := ClaudeMessage{
+		Role:    "user",
+		Content: "Compare these",
+		Blocks: []ClaudeContentBlock{
+			{Type: "image", Source: &ClaudeBlockSource{Type: "base64", MediaType: "image/png", Data: "aW1n"}},
+			{Type: "document", Title: "README.md", Source: &ClaudeBlockSource{Type: "text", MediaType: "text/plain", Data: "# repo"}},
+			{Type: "document", Title: "spec.pdf", Source: &ClaudeBlockSource{Type: "base64", MediaType: "application/pdf", Data: "cGRm"}},
+		},
+	}
+
+	_, _, err := splitBlocks(msg, true)
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+
+	text, images, err := splitBlocks(msg, false)
+	require.NoError(t, err)
+	assert.Equal(t, "<document title=\"README.md\">\n# repo\n</document>\n\n[Attachment spec.pdf unavailable: not supported by this provider]\n\nCompare these", text)
+	assert.Equal(t, []*ClaudeBlockSource{msg.Blocks[0].Source}, images)
+}
@@ -3,7 +3,12 @@
 
 package chat
 
-import "time"
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
 
 // ChatConfig represents the parsed agent.chat.yaml file.
 type ChatConfig struct {
@@ -44,6 +49,8 @@ type LLMConfig struct {
 	Temperature float64 `yaml:"temperature"`
 	TopP        float64 `yaml:"top_p"`
 	SystemPrompt string `yaml:"system_prompt"`
+	// StopSequences end the reply when the model generates one of them.
+	StopSequences []string `yaml:"stop_sequences"`
 }
 
 // MCPChatConfig holds MCP tool configuration for the chat agent.
@@ -52,6 +59,8 @@ type MCPChatConfig struct {
 	AdditionalServers []MCPServerEntry  `yaml:"additional_servers"`
 	AllowedTools      []string          `yaml:"allowed_tools"`
 	DeniedTools       []string          `yaml:"denied_tools"`
+	// MaxToolCalls ends a reply making more tool calls than this, 0 for no limit.
+	MaxToolCalls int `yaml:"max_tool_calls"`
 }
 
 // MCPServerEntry represents an additional MCP server.
@@ -69,6 +78,7 @@ type HistoryConfig struct {
 	RetentionDays           int    `yaml:"retention_days"`
 	MaxConversationsPerUser int    `yaml:"max_conversations_per_user"`
 	Anonymize               bool   `yaml:"anonymize"`
+	LLMTitles               bool   `yaml:"llm_titles"` // generate titles with a model call instead of truncating the first message
 }
 
 // AccessConfig controls who can use the chatbot.
@@ -101,6 +111,7 @@ type Conversation struct {
 	User        ConversationUser `json:"user"`
 	AgentConfig string           `json:"agent_config"`
 	Model       string           `json:"model"`
+	Title       string           `json:"title,omitempty"`
 	Stats       ConversationStats `json:"stats"`
 	Messages    []Message        `json:"messages"`
 }
@@ -118,7 +129,7 @@ type ConversationStats struct {
 	TotalOutputTokens int    `json:"total_output_tokens"`
 	TotalCostUSD     float64 `json:"total_cost_usd"`
 	ToolsCalled      []string `json:"tools_called"`
-	DurationSeconds  int     `json:"duration_seconds"`
+	DurationSeconds  int     `json:"duration_seconds"` // summed over turns, from the question to the end of the reply
 }
 
 // Message represents a single message in a conversation.
@@ -128,6 +139,8 @@ type Message struct {
 	Timestamp time.Time  `json:"timestamp"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 	Usage     *Usage     `json:"usage,omitempty"`
+
+	Attachments []string `json:"attachments,omitempty"` // repository file paths
 }
 
 // ToolCall represents an MCP tool invocation within a message.
@@ -154,6 +167,24 @@ type ConversationSummary struct {
 	CreatedAt time.Time `json:"created_at"`
 	Turns     int       `json:"turns"`
 	CostUSD   float64   `json:"cost_usd"`
+
+	DurationSeconds int `json:"duration_seconds"` // time spent waiting for replies
+
+	// Shard is the sub-directory of the day directory holding the conversation file, empty
+	// for conversations stored directly in the day directory.
+	Shard string `json:"shard,omitempty"`
+}
+
+// Stats reports the chat spend of a repository: the current month as tracked by the server,
+// and the lifetime totals of the conversation index.
+type Stats struct {
+	Month         string  `json:"month"` // YYYY-MM
+	MonthCostUSD  float64 `json:"month_cost_usd"`
+	MonthRequests int     `json:"month_requests"`
+
+	TotalCostUSD       float64 `json:"total_cost_usd"`
+	TotalConversations int     `json:"total_conversations"`
+	TotalMessages      int     `json:"total_messages"`
 }
 
 // ConversationIndex stores the index of all conversations on the chat-history branch.
@@ -177,12 +208,79 @@ type ClaudeRequest struct {
 	Tools       []ClaudeTool      `json:"tools,omitempty"`
 	Stream      bool              `json:"stream"`
 	Temperature float64           `json:"temperature,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
 }
 
-// ClaudeMessage represents a message in the Claude API format.
+// ClaudeMessage represents a message in the Claude API format. Content is sent as a plain
+// string unless Blocks are set, in which case the content is an array of the blocks followed
+// by Content as a text block.
 type ClaudeMessage struct {
+	Role    string               `json:"role"`
+	Content string               `json:"content"`
+	Blocks  []ClaudeContentBlock `json:"-"`
+}
+
+// ClaudeContentBlock is an entry of a content-block array, e.g. text, an image or a document.
+type ClaudeContentBlock struct {
+	Type   string             `json:"type"` // "text", "image" or "document"
+	Text   string             `json:"text,omitempty"`
+	Title  string             `json:"title,omitempty"`
+	Source *ClaudeBlockSource `json:"source,omitempty"`
+}
+
+// ClaudeBlockSource holds the data of an image or document block.
+type ClaudeBlockSource struct {
+	Type      string `json:"type"` // "base64" or "text"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type claudeMessageJSON struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"`
+}
+
+// MarshalJSON encodes the content as a string, or as a block array when the message has blocks.
+func (m ClaudeMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) == 0 {
+		return json.Marshal(claudeMessageJSON{Role: m.Role, Content: m.Content})
+	}
+	blocks := slices.Clone(m.Blocks)
+	if m.Content != "" {
+		blocks = append(blocks, ClaudeContentBlock{Type: "text", Text: m.Content})
+	}
+	return json.Marshal(claudeMessageJSON{Role: m.Role, Content: blocks})
+}
+
+// UnmarshalJSON accepts string content as well as a block array, whose text blocks are
+// joined into Content and whose other blocks are kept in Blocks.
+func (m *ClaudeMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = ClaudeMessage{Role: raw.Role}
+	if len(raw.Content) == 0 || raw.Content[0] != '[' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+
+	var blocks []ClaudeContentBlock
+	if err := json.Unmarshal(raw.Content, &blocks); err != nil {
+		return err
+	}
+	var texts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		} else {
+			m.Blocks = append(m.Blocks, block)
+		}
+	}
+	m.Content = strings.Join(texts, "\n\n")
+	return nil
 }
 
 // ClaudeMCPServer represents an MCP server configuration for the Claude API.
@@ -221,6 +319,15 @@ type SSEEvent struct {
 	Server         string  `json:"server,omitempty"`
 	ConversationID string  `json:"conversation_id,omitempty"`
 	Usage          *Usage  `json:"usage,omitempty"`
+	Quota          *Quota  `json:"quota,omitempty"`
+}
+
+// Quota reports what a user has left after a chat turn. A nil field means the
+// corresponding limit is not configured.
+type Quota struct {
+	RemainingRequestsMinute *int     `json:"remaining_requests_minute,omitempty"`
+	RemainingRequestsDay    *int     `json:"remaining_requests_day,omitempty"`
+	RemainingBudgetUSD      *float64 `json:"remaining_budget_usd,omitempty"`
 }
 
 // ChatRequest represents the incoming request body for the chat endpoint.
@@ -228,4 +335,11 @@ type ChatRequest struct {
 	Message        string `json:"message"`
 	ConversationID string `json:"conversation_id"`
 	AgentFile      string `json:"agent_file"`
+
+	// Attachments are repository file paths, read at the default branch, sent along with the message.
+	Attachments []string `json:"attachments,omitempty"`
+
+	// Ephemeral keeps the conversation out of the history: it gets no ID and is never buffered
+	// for the history branch. It can't continue a saved conversation.
+	Ephemeral bool `json:"ephemeral,omitempty"`
 }
@@ -4,6 +4,9 @@
 package diagrams
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"path/filepath"
 	"strings"
 
@@ -19,11 +22,26 @@ const (
 	DiagramNone    DiagramType = "none"
 )
 
+// sniffWindow is how much of a file's head content detection looks at.
+const sniffWindow = 4096
+
 type DiagramType string
 
+// RulesetKind tells how a JSON ruleset defines its decisions.
+type RulesetKind string
+
+const (
+	// RulesetEmbedded is a self-contained decision table with inputs, outputs and rules.
+	RulesetEmbedded RulesetKind = "embedded"
+	// RulesetReference points to a DMN diagram through its source.
+	RulesetReference RulesetKind = "reference"
+)
+
 type DetectionResult struct {
 	Type   DiagramType
 	Format string
+	// RulesetKind is set for JSON rulesets whose head tells the kind.
+	RulesetKind RulesetKind
 }
 
 type rulesetMetadata struct {
@@ -40,17 +58,40 @@ func (d DiagramType) Editable() bool {
 	}
 }
 
+// Detect detects the diagram type of a file from its path and the head of its content.
 func Detect(treePath string, headBytes []byte) DetectionResult {
+	result, _ := DetectFromReader(treePath, bytes.NewReader(headBytes))
+	return result
+}
+
+// DetectFromReader is like Detect but reads at most the sniff window from r, and nothing at
+// all if the path already tells the type of a file other than a JSON ruleset, so callers don't
+// have to buffer whole files.
+func DetectFromReader(treePath string, r io.Reader) (DetectionResult, error) {
 	pathLower := strings.ToLower(treePath)
-	if typ, format := detectByExtension(pathLower); typ != DiagramNone {
-		return DetectionResult{Type: typ, Format: format}
+	typ, format := detectByExtension(pathLower)
+	if typ != DiagramNone && (typ != DiagramRuleset || format == "xml") {
+		return DetectionResult{Type: typ, Format: format}, nil
+	}
+
+	headBytes := make([]byte, sniffWindow)
+	n, err := io.ReadFull(r, headBytes)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return DetectionResult{Type: DiagramNone}, err
 	}
+	headBytes = headBytes[:n]
 
-	typ, format := detectByContent(pathLower, headBytes)
+	if typ == DiagramNone {
+		typ, format = detectByContent(pathLower, headBytes)
+	}
 	if format == "" {
 		format = defaultFormatForType(typ)
 	}
-	return DetectionResult{Type: typ, Format: format}
+	result := DetectionResult{Type: typ, Format: format}
+	if typ == DiagramRuleset && format == "json" {
+		result.RulesetKind = detectRulesetKind(headBytes)
+	}
+	return result, nil
 }
 
 func detectByExtension(pathLower string) (DiagramType, string) {
@@ -80,8 +121,8 @@ func detectByExtension(pathLower string) (DiagramType, string) {
 
 func detectByContent(pathLower string, headBytes []byte) (DiagramType, string) {
 	sample := strings.ToLower(string(headBytes))
-	if len(sample) > 4096 {
-		sample = sample[:4096]
+	if len(sample) > sniffWindow {
+		sample = sample[:sniffWindow]
 	}
 
 	switch {
@@ -103,8 +144,8 @@ func detectByContent(pathLower string, headBytes []byte) (DiagramType, string) {
 }
 
 func detectDiagramJSON(headBytes []byte) DiagramType {
-	if len(headBytes) > 4096 {
-		headBytes = headBytes[:4096]
+	if len(headBytes) > sniffWindow {
+		headBytes = headBytes[:sniffWindow]
 	}
 	if len(strings.TrimSpace(string(headBytes))) == 0 {
 		return DiagramNone
@@ -154,6 +195,120 @@ func detectDiagramJSON(headBytes []byte) DiagramType {
 	return DiagramNone
 }
 
+// detectRulesetKind tells an embedded decision table, with inputs, outputs and rules arrays at
+// the top level or in its first decision, from a ruleset referencing a DMN diagram through a
+// non-empty source. It only looks at the top-level fields, so a head cut off in the middle of a
+// large table still tells the kind.
+func detectRulesetKind(headBytes []byte) RulesetKind {
+	fields := topLevelFields(headBytes)
+	if isDecisionTable(fields) {
+		return RulesetEmbedded
+	}
+	if decisions := fields["decisions"]; len(decisions) > 0 && decisions[0] == '[' {
+		if isDecisionTable(topLevelFields(bytes.TrimLeft(decisions[1:], " \t\r\n"))) {
+			return RulesetEmbedded
+		}
+	}
+	if source := fields["source"]; len(source) > 1 && source[0] == '"' && source[1] != '"' {
+		return RulesetReference
+	}
+	return ""
+}
+
+func isDecisionTable(fields map[string][]byte) bool {
+	for _, key := range []string{"inputs", "outputs", "rules"} {
+		if value := fields[key]; len(value) == 0 || value[0] != '[' {
+			return false
+		}
+	}
+	return true
+}
+
+// topLevelFields returns the raw values of the fields of the JSON object at the start of data,
+// keyed by their unescaped names. Unlike unmarshalling, it tolerates data cut off at any point:
+// the value of the field being read when data ends is returned as far as it goes.
+func topLevelFields(data []byte) map[string][]byte {
+	fields := make(map[string][]byte)
+	i := skipJSONSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return fields
+	}
+	i++
+	for {
+		i = skipJSONSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipJSONSpace(data, i+1)
+		}
+		if i >= len(data) || data[i] != '"' {
+			return fields
+		}
+		keyEnd := skipJSONString(data, i)
+		var key string
+		if err := json.Unmarshal(data[i:keyEnd], &key); err != nil {
+			return fields
+		}
+		i = skipJSONSpace(data, keyEnd)
+		if i >= len(data) || data[i] != ':' {
+			return fields
+		}
+		i = skipJSONSpace(data, i+1)
+		valueEnd := skipJSONValue(data, i)
+		fields[key] = data[i:valueEnd]
+		i = valueEnd
+	}
+}
+
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\r' || data[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// skipJSONString returns the index after the string starting with the quote at data[i].
+func skipJSONString(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// skipJSONValue returns the index after the value starting at data[i].
+func skipJSONValue(data []byte, i int) int {
+	depth := 0
+	for i < len(data) {
+		switch data[i] {
+		case '"':
+			i = skipJSONString(data, i)
+			if depth == 0 {
+				return i
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return len(data)
+}
+
 func defaultFormatForType(diagramType DiagramType) string {
 	switch diagramType {
 	case DiagramBPMN, DiagramCMMN, DiagramDMN:
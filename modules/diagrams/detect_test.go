@@ -0,0 +1,110 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package diagrams
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingReader records how many bytes were read from the underlying reader.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestDetectFromReader(t *testing.T) {
+	cases := []struct {
+		path     string
+		content  string
+		expected DetectionResult
+	}{
+		{"process.bpmn", "", DetectionResult{Type: DiagramBPMN, Format: "xml"}},
+		{"model.xml", `<?xml version="1.0"?><bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL">`, DetectionResult{Type: DiagramBPMN, Format: "xml"}},
+		{"graph.json", `{"nodes":[{"id":"a"}],"edges":[{"from":"a","to":"a"}]}`, DetectionResult{Type: DiagramNGraph, Format: "json"}},
+		{"rules.json", `{"type":"ruleset","rules":[]}`, DetectionResult{Type: DiagramRuleset, Format: "json"}},
+		{"README.md", "# Readme", DetectionResult{Type: DiagramNone}},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			result, err := DetectFromReader(c.path, strings.NewReader(c.content))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, result)
+			assert.Equal(t, c.expected, Detect(c.path, []byte(c.content)))
+		})
+	}
+}
+
+func TestDetectRulesetKind(t *testing.T) {
+	embedded := `{"type": "ruleset", "hitPolicy": "FIRST",
+  "inputs": [{"id": "age", "label": "Age \"years\"", "type": "number"}],
+  "outputs": [{"id": "risk", "type": "string"}],
+  "rules": [{"when": ["< 18"], "then": ["high"]}]}`
+	nested := `{"decisions": [{"id": "d1", "inputs": [], "outputs": [], "rules": []}]}`
+	reference := `{"type": "ruleset", "source": "decisions/risk.dmn", "rules": []}`
+	// a table cut off by the sniff window still has its fields named before the cut
+	truncated := `{"inputs": [], "outputs": [], "rules": [` + strings.Repeat(`{"when": ["1"], "then": ["x"]},`, sniffWindow/20)
+
+	cases := []struct {
+		path     string
+		content  string
+		expected RulesetKind
+	}{
+		{"risk.ruleset.json", embedded, RulesetEmbedded},
+		{"risk.json", embedded, RulesetEmbedded},
+		{"nested.ruleset.json", nested, RulesetEmbedded},
+		{"large.ruleset.json", truncated, RulesetEmbedded},
+		{"risk.ruleset.json", reference, RulesetReference},
+		{"rules.json", reference, RulesetReference},
+		{"empty-source.ruleset.json", `{"source": "", "rules": []}`, ""},
+		{"rules.json", `{"type":"ruleset","rules":[]}`, ""},
+		{"partial.ruleset.json", `{"inputs": [], "rules": []}`, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			result, err := DetectFromReader(c.path, strings.NewReader(c.content))
+			require.NoError(t, err)
+			assert.Equal(t, DiagramRuleset, result.Type)
+			assert.Equal(t, "json", result.Format)
+			assert.Equal(t, c.expected, result.RulesetKind)
+		})
+	}
+}
+
+func TestDetectFromReaderReadsOnlyHead(t *testing.T) {
+	content := `<dmn:definitions xmlns:dmn="https://www.omg.org/spec/DMN/20191111/MODEL/">` + strings.Repeat(" ", 10*sniffWindow)
+	r := &countingReader{r: strings.NewReader(content)}
+	result, err := DetectFromReader("decision.xml", r)
+	require.NoError(t, err)
+	assert.Equal(t, DetectionResult{Type: DiagramDMN, Format: "xml"}, result)
+	assert.Equal(t, sniffWindow, r.read)
+
+	// the content is not read when the extension tells the type
+	r = &countingReader{r: strings.NewReader(content)}
+	_, err = DetectFromReader("decision.dmn", r)
+	require.NoError(t, err)
+	assert.Zero(t, r.read)
+}
+
+func TestDetectFromReaderError(t *testing.T) {
+	readErr := errors.New("read failed")
+	result, err := DetectFromReader("model.xml", io.MultiReader(strings.NewReader("<bpmn"), &errReader{readErr}))
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, DiagramNone, result.Type)
+}
+
+type errReader struct{ err error }
+
+func (e *errReader) Read([]byte) (int, error) { return 0, e.err }
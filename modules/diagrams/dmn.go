@@ -0,0 +1,112 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package diagrams
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// DMNDecision is a decision of a DMN diagram with the column labels of its decision table.
+// Inputs and Outputs are empty for decisions with other logic, e.g. a literal expression.
+type DMNDecision struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+}
+
+// ExtractDMNDecisions lists the decisions of a DMN diagram in document order. The root must be
+// <definitions> in an OMG DMN namespace; elements are matched by local name, so both the default
+// namespace and prefixes such as "dmn:" work.
+// An input is labeled by its label attribute, else its input expression, else its ID; an
+// output by its label, else its name, else its ID. Errors wrap util.ErrInvalidArgument if
+// data is not a DMN diagram.
+func ExtractDMNDecisions(data []byte) ([]DMNDecision, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	decisions := []DMNDecision{}
+	var (
+		current       *DMNDecision
+		inTable       bool
+		inputLabel    string // label of the open <input>, filled from its expression if empty
+		inputID       string
+		inInput       bool
+		inExpression  bool
+		expression    strings.Builder
+		seenRootStart bool
+	)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, util.NewInvalidArgumentErrorf("invalid DMN XML: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if !seenRootStart {
+				seenRootStart = true
+				// BPMN and CMMN diagrams have a <definitions> root too, tell them apart by namespace
+				if t.Name.Local != "definitions" || !strings.Contains(strings.ToLower(t.Name.Space), "/spec/dmn/") {
+					return nil, util.NewInvalidArgumentErrorf("not a DMN diagram: root element is <%s> in namespace %q", t.Name.Local, t.Name.Space)
+				}
+				continue
+			}
+			switch {
+			case t.Name.Local == "decision" && current == nil:
+				current = &DMNDecision{ID: xmlAttr(t, "id"), Name: xmlAttr(t, "name"), Inputs: []string{}, Outputs: []string{}}
+			case t.Name.Local == "decisionTable" && current != nil:
+				inTable = true
+			case t.Name.Local == "input" && inTable:
+				inInput = true
+				inputID, inputLabel = xmlAttr(t, "id"), xmlAttr(t, "label")
+				expression.Reset()
+			case t.Name.Local == "inputExpression" && inInput:
+				inExpression = true
+			case t.Name.Local == "output" && inTable:
+				current.Outputs = append(current.Outputs, cmp.Or(xmlAttr(t, "label"), xmlAttr(t, "name"), xmlAttr(t, "id")))
+			}
+
+		case xml.CharData:
+			if inExpression {
+				expression.Write(t)
+			}
+
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == "inputExpression" && inExpression:
+				inExpression = false
+			case t.Name.Local == "input" && inInput:
+				inInput = false
+				current.Inputs = append(current.Inputs, cmp.Or(inputLabel, strings.TrimSpace(expression.String()), inputID))
+			case t.Name.Local == "decisionTable" && inTable:
+				inTable = false
+			case t.Name.Local == "decision" && current != nil:
+				decisions = append(decisions, *current)
+				current = nil
+			}
+		}
+	}
+	if !seenRootStart {
+		return nil, util.NewInvalidArgumentErrorf("not a DMN diagram: no root element")
+	}
+	return decisions, nil
+}
+
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
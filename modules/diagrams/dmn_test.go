@@ -0,0 +1,80 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package diagrams
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDMN = `<?xml version="1.0" encoding="UTF-8"?>
+<dmn:definitions xmlns:dmn="https://www.omg.org/spec/DMN/20191111/MODEL/" id="defs" name="Loans">
+  <dmn:decision id="eligibility" name="Loan eligibility">
+    <dmn:decisionTable id="eligibilityTable" hitPolicy="FIRST">
+      <dmn:input id="in1" label="Applicant age">
+        <dmn:inputExpression typeRef="number"><dmn:text>age</dmn:text></dmn:inputExpression>
+      </dmn:input>
+      <dmn:input id="in2">
+        <dmn:inputExpression typeRef="number"><dmn:text> income </dmn:text></dmn:inputExpression>
+      </dmn:input>
+      <dmn:output id="out1" label="Eligible" name="eligible" typeRef="boolean"/>
+      <dmn:output id="out2" name="reason" typeRef="string"/>
+      <dmn:rule id="r1">
+        <dmn:inputEntry><dmn:text>&lt; 18</dmn:text></dmn:inputEntry>
+        <dmn:inputEntry><dmn:text>-</dmn:text></dmn:inputEntry>
+        <dmn:outputEntry><dmn:text>false</dmn:text></dmn:outputEntry>
+        <dmn:outputEntry><dmn:text>"minor"</dmn:text></dmn:outputEntry>
+      </dmn:rule>
+    </dmn:decisionTable>
+  </dmn:decision>
+  <dmn:decision id="rate" name="Interest rate">
+    <dmn:literalExpression><dmn:text>0.05</dmn:text></dmn:literalExpression>
+  </dmn:decision>
+  <dmn:businessKnowledgeModel id="bkm">
+    <dmn:encapsulatedLogic>
+      <dmn:decisionTable><dmn:input label="Not a decision"/></dmn:decisionTable>
+    </dmn:encapsulatedLogic>
+  </dmn:businessKnowledgeModel>
+</dmn:definitions>`
+
+func TestExtractDMNDecisions(t *testing.T) {
+	decisions, err := ExtractDMNDecisions([]byte(testDMN))
+	require.NoError(t, err)
+	assert.Equal(t, []DMNDecision{
+		{ID: "eligibility", Name: "Loan eligibility", Inputs: []string{"Applicant age", "income"}, Outputs: []string{"Eligible", "reason"}},
+		{ID: "rate", Name: "Interest rate", Inputs: []string{}, Outputs: []string{}},
+	}, decisions)
+
+	t.Run("DefaultNamespace", func(t *testing.T) {
+		decisions, err := ExtractDMNDecisions([]byte(`<definitions xmlns="https://www.omg.org/spec/DMN/20191111/MODEL/">
+  <decision id="d1" name="Discount">
+    <decisionTable><input id="i1"/><output id="o1"/></decisionTable>
+  </decision>
+</definitions>`))
+		require.NoError(t, err)
+		assert.Equal(t, []DMNDecision{{ID: "d1", Name: "Discount", Inputs: []string{"i1"}, Outputs: []string{"o1"}}}, decisions)
+	})
+
+	t.Run("NoDecisions", func(t *testing.T) {
+		decisions, err := ExtractDMNDecisions([]byte(`<definitions xmlns="http://www.omg.org/spec/DMN/20180521/MODEL/"/>`))
+		require.NoError(t, err)
+		assert.Empty(t, decisions)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, data := range []string{
+			`<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"/>`,
+			`<definitions/>`,
+			`<definitions xmlns="http://www.omg.org/spec/DMN/20180521/MODEL/"><decision id="d1">`,
+			``,
+		} {
+			_, err := ExtractDMNDecisions([]byte(data))
+			assert.ErrorIs(t, err, util.ErrInvalidArgument, data)
+		}
+	})
+}
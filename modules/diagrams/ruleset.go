@@ -0,0 +1,47 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package diagrams
+
+import (
+	"path"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ValidateRuleset checks that the DMN source declared in a ruleset's metadata exists in the
+// commit and is a DMN diagram. The source is resolved relative to the directory of rulesetPath.
+// Errors wrap util.ErrNotExist if the source is missing and util.ErrInvalidArgument otherwise.
+func ValidateRuleset(commit *git.Commit, rulesetPath string, data []byte) error {
+	source := ParseRulesetMetadata(data)
+	if source == "" {
+		return util.NewInvalidArgumentErrorf("ruleset %s does not declare a valid source", rulesetPath)
+	}
+	sourcePath := path.Join(path.Dir(rulesetPath), source)
+
+	entry, err := commit.GetTreeEntryByPath(sourcePath)
+	if git.IsErrNotExist(err) {
+		return util.NewNotExistErrorf("source %s of ruleset %s does not exist", sourcePath, rulesetPath)
+	} else if err != nil {
+		return err
+	}
+	if entry.IsDir() || entry.IsSubModule() {
+		return util.NewInvalidArgumentErrorf("source %s of ruleset %s is not a file", sourcePath, rulesetPath)
+	}
+
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	result, err := DetectFromReader(sourcePath, reader)
+	if err != nil {
+		return err
+	}
+	if result.Type != DiagramDMN {
+		return util.NewInvalidArgumentErrorf("source %s of ruleset %s is not a DMN diagram", sourcePath, rulesetPath)
+	}
+	return nil
+}
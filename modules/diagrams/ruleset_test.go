@@ -0,0 +1,94 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package diagrams
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tempdir"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	gitHomePath, cleanup, err := tempdir.OsTempDir("gitea-test").MkdirTempRandom("git-home")
+	if err != nil {
+		log.Fatal("Unable to create temp dir: %v", err)
+	}
+	defer cleanup()
+
+	setting.Git.HomePath = gitHomePath
+	if err := git.InitFull(); err != nil {
+		log.Fatal("Unable to init git: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestCommit commits files to a new repository and returns the commit.
+func newTestCommit(t *testing.T, files map[string]string) *git.Commit {
+	repoPath := t.TempDir()
+	require.NoError(t, git.InitRepository(t.Context(), repoPath, false, git.Sha1ObjectFormat.Name()))
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoPath, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0o644))
+	}
+	require.NoError(t, git.AddChanges(t.Context(), repoPath, true))
+	signature := &git.Signature{Name: "Test", Email: "test@example.com"}
+	require.NoError(t, git.CommitChanges(t.Context(), repoPath, git.CommitChangesOptions{Committer: signature, Message: "add files"}))
+
+	gitRepo, err := git.OpenRepository(t.Context(), repoPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { gitRepo.Close() })
+	commit, err := gitRepo.GetCommit("HEAD")
+	require.NoError(t, err)
+	return commit
+}
+
+func TestValidateRuleset(t *testing.T) {
+	commit := newTestCommit(t, map[string]string{
+		"decisions/approval.dmn":  `<definitions xmlns="https://www.omg.org/spec/DMN/20191111/MODEL/"></definitions>`,
+		"decisions/approval.xml":  `<?xml version="1.0"?><dmn:definitions xmlns:dmn="https://www.omg.org/spec/DMN/20191111/MODEL/"></dmn:definitions>`,
+		"decisions/process.bpmn":  `<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"></bpmn:definitions>`,
+		"decisions/notes/readme":  "not a diagram",
+		"decisions/sub/empty.txt": "",
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.NoError(t, ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"approval.dmn"}`)))
+		assert.NoError(t, ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"approval.xml"}`)))
+		assert.NoError(t, ValidateRuleset(commit, "approval.ruleset", []byte(`{"source":"decisions/approval.dmn"}`)))
+	})
+
+	t.Run("MissingSource", func(t *testing.T) {
+		err := ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"missing.dmn"}`))
+		assert.ErrorIs(t, err, util.ErrNotExist)
+		assert.EqualError(t, err, "source decisions/missing.dmn of ruleset decisions/approval.ruleset.json does not exist")
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		err := ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"process.bpmn"}`))
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+		assert.EqualError(t, err, "source decisions/process.bpmn of ruleset decisions/approval.ruleset.json is not a DMN diagram")
+
+		err = ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"notes/readme"}`))
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+
+		err = ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(`{"source":"sub"}`))
+		assert.EqualError(t, err, "source decisions/sub of ruleset decisions/approval.ruleset.json is not a file")
+	})
+
+	t.Run("InvalidSource", func(t *testing.T) {
+		for _, data := range []string{`{}`, `{"source":"/etc/passwd"}`, `{"source":"../approval.dmn"}`, `not json`} {
+			err := ValidateRuleset(commit, "decisions/approval.ruleset.json", []byte(data))
+			assert.ErrorIs(t, err, util.ErrInvalidArgument, data)
+		}
+	})
+}
@@ -75,6 +75,25 @@ func HandleGenericETagCache(req *http.Request, w http.ResponseWriter, etag strin
 	return false
 }
 
+// HandleImmutableETagCache handles ETag-based caching for content that never changes for its URL,
+// for example a blob addressed by a full commit SHA. It returns true if the request was handled.
+// Otherwise no headers are set: the caller marks the response with SetImmutableCacheHeaders once
+// it is known to succeed, so that errors aren't cached.
+func HandleImmutableETagCache(req *http.Request, w http.ResponseWriter, etag string) (handled bool) {
+	if checkIfNoneMatchIsValid(req, etag) {
+		SetImmutableCacheHeaders(w.Header(), etag)
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// SetImmutableCacheHeaders sets the ETag and lets clients cache the response forever.
+func SetImmutableCacheHeaders(h http.Header, etag string) {
+	h.Set("Etag", etag)
+	h.Set("Cache-Control", "private, max-age=31536000, immutable")
+}
+
 // checkIfNoneMatchIsValid tests if the header If-None-Match matches the ETag
 func checkIfNoneMatchIsValid(req *http.Request, etag string) bool {
 	ifNoneMatch := req.Header.Get("If-None-Match")
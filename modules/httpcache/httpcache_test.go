@@ -95,3 +95,35 @@ func TestHandleGenericETagCache(t *testing.T) {
 		assert.Equal(t, http.StatusNotModified, w.Code)
 	})
 }
+
+func TestHandleImmutableETagCache(t *testing.T) {
+	etag := `"test"`
+
+	t.Run("No_If-None-Match", func(t *testing.T) {
+		req := &http.Request{Header: make(http.Header)}
+		w := httptest.NewRecorder()
+
+		handled := HandleImmutableETagCache(req, w, etag)
+
+		assert.False(t, handled)
+		assert.Empty(t, w.Header().Get("Etag"))
+		assert.Empty(t, w.Header().Get("Cache-Control"))
+
+		SetImmutableCacheHeaders(w.Header(), etag)
+		assert.Equal(t, etag, w.Header().Get("Etag"))
+		assert.Equal(t, "private, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	})
+	t.Run("Correct_If-None-Match", func(t *testing.T) {
+		req := &http.Request{Header: make(http.Header)}
+		w := httptest.NewRecorder()
+
+		req.Header.Set("If-None-Match", etag)
+
+		handled := HandleImmutableETagCache(req, w, etag)
+
+		assert.True(t, handled)
+		assert.Equal(t, etag, w.Header().Get("Etag"))
+		assert.Equal(t, "private, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+}
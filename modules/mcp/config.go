@@ -5,9 +5,14 @@ package mcp
 
 import (
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/git"
 
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,6 +21,30 @@ const ConfigFileName = "processgit.mcp.yaml"
 
 const maxConfigSize int64 = 64 * 1024 // 64 KB
 
+const (
+	missingConfigCacheSize = 1000
+	missingConfigTTL       = 5 * time.Minute
+)
+
+// missingConfigs remembers the repo+commits without a config file, so agents probing a
+// repository without MCP don't cause a tree lookup per request. Commits are immutable, so
+// entries only expire to bound memory.
+var missingConfigs = expirable.NewLRU[string, struct{}](missingConfigCacheSize, nil, missingConfigTTL)
+
+// LoadRepoConfig is LoadConfig for a commit of the repository repoID that caches the absence
+// of the config file.
+func LoadRepoConfig(repoID int64, commit *git.Commit) (*MCPConfig, error) {
+	cacheKey := fmt.Sprintf("%d:%s", repoID, commit.ID.String())
+	if missingConfigs.Contains(cacheKey) {
+		return nil, nil
+	}
+	cfg, err := LoadConfig(commit)
+	if err == nil && cfg == nil {
+		missingConfigs.Add(cacheKey, struct{}{})
+	}
+	return cfg, err
+}
+
 // LoadConfig loads processgit.mcp.yaml from the repo root at the given commit.
 // Returns nil, nil if the file doesn't exist (MCP not enabled for this repo).
 func LoadConfig(commit *git.Commit) (*MCPConfig, error) {
@@ -40,8 +69,13 @@ func LoadConfig(commit *git.Commit) (*MCPConfig, error) {
 	}
 	defer reader.Close()
 
+	return ParseConfig(reader)
+}
+
+// ParseConfig decodes and validates the contents of a processgit.mcp.yaml file.
+func ParseConfig(r io.Reader) (*MCPConfig, error) {
 	var cfg MCPConfig
-	decoder := yaml.NewDecoder(reader)
+	decoder := yaml.NewDecoder(r)
 	decoder.KnownFields(true)
 	if err := decoder.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("invalid %s: %w", ConfigFileName, err)
@@ -65,6 +99,34 @@ func validateConfig(cfg *MCPConfig) error {
 		return fmt.Errorf("%s: at least one source is required", ConfigFileName)
 	}
 
+	switch cfg.DuplicateIDs {
+	case "", DuplicateIDsError, DuplicateIDsFirst, DuplicateIDsLast:
+	default:
+		return fmt.Errorf("%s: duplicate_ids %q is not supported (must be \"error\", \"first\" or \"last\")", ConfigFileName, cfg.DuplicateIDs)
+	}
+
+	seenRootTypes := make(map[string]bool, len(cfg.DocumentRootTypes))
+	for i, rootType := range cfg.DocumentRootTypes {
+		if strings.TrimSpace(rootType) == "" {
+			return fmt.Errorf("%s: document_root_types[%d] must not be empty", ConfigFileName, i)
+		}
+		if seenRootTypes[rootType] {
+			return fmt.Errorf("%s: document_root_types lists %q more than once", ConfigFileName, rootType)
+		}
+		seenRootTypes[rootType] = true
+	}
+
+	for _, tool := range cfg.EnabledTools {
+		if _, ok := toolRegistry[tool]; !ok {
+			return fmt.Errorf("%s: enabled_tools lists unknown tool %q", ConfigFileName, tool)
+		}
+	}
+	for _, tool := range cfg.DisabledTools {
+		if _, ok := toolRegistry[tool]; !ok {
+			return fmt.Errorf("%s: disabled_tools lists unknown tool %q", ConfigFileName, tool)
+		}
+	}
+
 	for i, src := range cfg.Sources {
 		if src.Path == "" {
 			return fmt.Errorf("%s: sources[%d].path is required", ConfigFileName, i)
@@ -72,11 +134,38 @@ func validateConfig(cfg *MCPConfig) error {
 		if src.Type == "" {
 			return fmt.Errorf("%s: sources[%d].type is required", ConfigFileName, i)
 		}
-		// For MVP, only "xml" type is supported
-		if src.Type != "xml" {
-			return fmt.Errorf("%s: sources[%d].type %q is not supported (must be \"xml\")", ConfigFileName, i, src.Type)
+		switch src.Type {
+		case "xml":
+		case "json":
+			if strings.TrimSpace(src.EntityType) == "" {
+				return fmt.Errorf("%s: sources[%d].entity_type is required for json sources", ConfigFileName, i)
+			}
+		default:
+			return fmt.Errorf("%s: sources[%d].type %q is not supported (must be \"xml\" or \"json\")", ConfigFileName, i, src.Type)
+		}
+		if src.IDAttribute != "" {
+			cfg.Sources[i].IDAttribute = strings.TrimSpace(src.IDAttribute)
+			if cfg.Sources[i].IDAttribute == "" {
+				return fmt.Errorf("%s: sources[%d].id_attribute must not be empty", ConfigFileName, i)
+			}
+		}
+		for j, ref := range src.References {
+			if ref.Attribute == "" {
+				return fmt.Errorf("%s: sources[%d].references[%d].attribute is required", ConfigFileName, i, j)
+			}
+			if ref.Type == "" {
+				return fmt.Errorf("%s: sources[%d].references[%d].type is required", ConfigFileName, i, j)
+			}
 		}
 	}
 
 	return nil
 }
+
+// ToolEnabled reports whether the server offers the named tool, see EnabledTools and DisabledTools.
+func (cfg *MCPConfig) ToolEnabled(name string) bool {
+	if slices.Contains(cfg.DisabledTools, name) {
+		return false
+	}
+	return len(cfg.EnabledTools) == 0 || slices.Contains(cfg.EnabledTools, name)
+}
@@ -4,6 +4,7 @@
 package mcp
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -82,8 +83,153 @@ func TestValidateConfig_UnsupportedSourceType(t *testing.T) {
 	cfg := &MCPConfig{
 		Version: 1,
 		Server:  MCPServerConfig{Name: "Test"},
-		Sources: []MCPSource{{Path: "data.json", Type: "json"}},
+		Sources: []MCPSource{{Path: "data.csv", Type: "csv"}},
 	}
 	err := validateConfig(cfg)
 	assert.ErrorContains(t, err, "not supported")
 }
+
+func TestValidateConfig_JSONSource(t *testing.T) {
+	cfg := &MCPConfig{
+		Version: 1,
+		Server:  MCPServerConfig{Name: "Test"},
+		Sources: []MCPSource{{Path: "data.json", Type: "json"}},
+	}
+	assert.ErrorContains(t, validateConfig(cfg), "sources[0].entity_type is required for json sources")
+
+	cfg.Sources[0].EntityType = "organization"
+	assert.NoError(t, validateConfig(cfg))
+}
+
+func TestValidateConfig_InvalidReference(t *testing.T) {
+	cfg := &MCPConfig{
+		Version: 1,
+		Server:  MCPServerConfig{Name: "Test"},
+		Sources: []MCPSource{{
+			Path:       "data.xml",
+			Type:       "xml",
+			References: []MCPReference{{Attribute: "departmentRef"}},
+		}},
+	}
+	err := validateConfig(cfg)
+	assert.ErrorContains(t, err, "sources[0].references[0].type is required")
+}
+
+func TestValidateConfig_BlankIDAttribute(t *testing.T) {
+	cfg := &MCPConfig{
+		Version: 1,
+		Server:  MCPServerConfig{Name: "Test"},
+		Sources: []MCPSource{{Path: "data.xml", Type: "xml", IDAttribute: "  "}},
+	}
+	err := validateConfig(cfg)
+	assert.ErrorContains(t, err, "sources[0].id_attribute must not be empty")
+
+	cfg.Sources[0].IDAttribute = "id"
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, "id", cfg.Sources[0].EntityIDAttribute())
+
+	cfg.Sources[0].IDAttribute = " code "
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, "code", cfg.Sources[0].EntityIDAttribute())
+
+	cfg.Sources[0].IDAttribute = ""
+	assert.Equal(t, DefaultIDAttribute, cfg.Sources[0].EntityIDAttribute())
+}
+
+func TestValidateConfig_DuplicateIDs(t *testing.T) {
+	cfg := &MCPConfig{
+		Version:      1,
+		Server:       MCPServerConfig{Name: "Test"},
+		Sources:      []MCPSource{{Path: "data.xml", Type: "xml"}},
+		DuplicateIDs: "merge",
+	}
+	err := validateConfig(cfg)
+	assert.ErrorContains(t, err, `duplicate_ids "merge" is not supported`)
+
+	cfg.DuplicateIDs = DuplicateIDsLast
+	assert.NoError(t, validateConfig(cfg))
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`version: 1
+server:
+  name: Test
+sources:
+  - path: data.xml
+    type: xml
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "Test", cfg.Server.Name)
+
+	_, err = ParseConfig(strings.NewReader("version: 1\nserver:\n  name: Test\n"))
+	assert.ErrorContains(t, err, "at least one source is required")
+
+	_, err = ParseConfig(strings.NewReader("version: 1\nunknown: field\n"))
+	assert.ErrorContains(t, err, "invalid processgit.mcp.yaml")
+}
+
+func TestLoadRepoConfig_MissingCached(t *testing.T) {
+	commit := newTestCommit(t, map[string]string{
+		ConfigFileName: "version: 1\nserver:\n  name: Test\nsources:\n  - path: data.xml\n    type: xml\n",
+	})
+	cacheKey := "1:" + commit.ID.String()
+	t.Cleanup(func() { missingConfigs.Remove(cacheKey) })
+
+	cfg, err := LoadRepoConfig(1, commit)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.False(t, missingConfigs.Contains(cacheKey))
+
+	// a cached miss is answered without reading the tree, so the existing file isn't seen
+	missingConfigs.Add(cacheKey, struct{}{})
+	cfg, err = LoadRepoConfig(1, commit)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	// the entry is per repo+commit
+	cfg, err = LoadRepoConfig(2, commit)
+	require.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	empty := newTestCommit(t, map[string]string{"README.md": "no MCP here"})
+	emptyKey := "1:" + empty.ID.String()
+	t.Cleanup(func() { missingConfigs.Remove(emptyKey) })
+	cfg, err = LoadRepoConfig(1, empty)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+	assert.True(t, missingConfigs.Contains(emptyKey))
+}
+
+func TestValidateConfig_DocumentRootTypes(t *testing.T) {
+	cfg := &MCPConfig{
+		Version:           1,
+		Server:            MCPServerConfig{Name: "Test"},
+		Sources:           []MCPSource{{Path: "data.xml", Type: "xml"}},
+		DocumentRootTypes: []string{"ministry", "organization"},
+	}
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.DocumentRootTypes = []string{"ministry", " "}
+	assert.ErrorContains(t, validateConfig(cfg), "document_root_types[1] must not be empty")
+
+	cfg.DocumentRootTypes = []string{"ministry", "ministry"}
+	assert.ErrorContains(t, validateConfig(cfg), `document_root_types lists "ministry" more than once`)
+}
+
+func TestValidateConfig_EnabledTools(t *testing.T) {
+	cfg := &MCPConfig{
+		Version:       1,
+		Server:        MCPServerConfig{Name: "Test"},
+		Sources:       []MCPSource{{Path: "data.xml", Type: "xml"}},
+		EnabledTools:  []string{"help", "search"},
+		DisabledTools: []string{"generate_document"},
+	}
+	assert.NoError(t, validateConfig(cfg))
+
+	cfg.EnabledTools = []string{"help", "serach"}
+	assert.ErrorContains(t, validateConfig(cfg), `enabled_tools lists unknown tool "serach"`)
+
+	cfg.EnabledTools = nil
+	cfg.DisabledTools = []string{"generate_docs"}
+	assert.ErrorContains(t, validateConfig(cfg), `disabled_tools lists unknown tool "generate_docs"`)
+}
@@ -0,0 +1,29 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import "strings"
+
+// Entity IDs are "type:code". Colons in the code are escaped as "%3A", and percent signs as
+// "%25" so escaped codes stay unambiguous, e.g. code "P:1:13" of type "item" has the ID
+// "item:P%3A1%3A13". IDs of codes without either character are the plain "type:code".
+var (
+	entityCodeEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	entityCodeUnescaper = strings.NewReplacer("%25", "%", "%3A", ":", "%3a", ":")
+)
+
+// EntityID returns the ID of the entity of entityType identified by code.
+func EntityID(entityType, code string) string {
+	return entityType + ":" + entityCodeEscaper.Replace(code)
+}
+
+// ParseEntityID splits an entity ID into its type and unescaped code. It reports false if id
+// has no colon separating the two.
+func ParseEntityID(id string) (entityType, code string, ok bool) {
+	entityType, escaped, ok := strings.Cut(id, ":")
+	if !ok {
+		return "", "", false
+	}
+	return entityType, entityCodeUnescaper.Replace(escaped), true
+}
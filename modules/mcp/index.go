@@ -5,78 +5,192 @@ package mcp
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
-	"sync"
+	"unicode"
 
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/sync/singleflight"
 )
 
+// indexCacheSize bounds the number of cached indexes; the least recently used one is evicted.
+const indexCacheSize = 100
+
 // indexCache caches EntityIndex per repo+commit to avoid re-parsing.
-var indexCache = struct {
-	sync.RWMutex
-	entries map[string]*EntityIndex
-}{
-	entries: make(map[string]*EntityIndex),
+var indexCache *lru.Cache[string, *EntityIndex]
+
+// indexBuilds deduplicates concurrent builds of the same repo+commit index.
+var indexBuilds singleflight.Group
+
+func init() {
+	var err error
+	indexCache, err = lru.New[string, *EntityIndex](indexCacheSize)
+	if err != nil {
+		log.Fatal("failed to create MCP index cache: %v", err)
+	}
 }
 
 // GetOrBuildIndex returns a cached index or builds a new one.
 func GetOrBuildIndex(repoID int64, commit *git.Commit, cfg *MCPConfig) (*EntityIndex, error) {
 	cacheKey := fmt.Sprintf("%d:%s", repoID, commit.ID.String())
+	return getOrBuildCachedIndex(cacheKey, func() (*EntityIndex, error) {
+		return buildIndex(commit, cfg)
+	})
+}
 
-	indexCache.RLock()
-	if idx, ok := indexCache.entries[cacheKey]; ok {
-		indexCache.RUnlock()
+// getOrBuildCachedIndex looks up cacheKey in the index cache and calls build on a miss.
+// Concurrent misses for the same key share a single build.
+func getOrBuildCachedIndex(cacheKey string, build func() (*EntityIndex, error)) (*EntityIndex, error) {
+	if idx, ok := indexCache.Get(cacheKey); ok {
 		return idx, nil
 	}
-	indexCache.RUnlock()
 
-	// Build index from all sources
-	merged := &EntityIndex{
-		Entities:  make(map[string]*Entity),
-		ByType:    make(map[string][]string),
-		ByParent:  make(map[string][]string),
-		CommitSHA: commit.ID.String(),
-		Stats:     IndexStats{TypeCounts: make(map[string]int)},
+	v, err, _ := indexBuilds.Do(cacheKey, func() (any, error) {
+		// another caller may have finished building while we were waiting to enter
+		if idx, ok := indexCache.Get(cacheKey); ok {
+			return idx, nil
+		}
+		idx, err := build()
+		if err != nil {
+			return nil, err
+		}
+		indexCache.Add(cacheKey, idx)
+		return idx, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return v.(*EntityIndex), nil
+}
 
+// buildIndex parses all sources of cfg at commit into a single merged index.
+func buildIndex(commit *git.Commit, cfg *MCPConfig) (*EntityIndex, error) {
+	var sources []MCPSource
+	var indexes []*EntityIndex
 	for _, source := range cfg.Sources {
+		var idx *EntityIndex
+		var err error
 		switch source.Type {
 		case "xml":
-			idx, err := ParseXMLSource(commit, source)
-			if err != nil {
-				return nil, err
-			}
-			// Merge into combined index
-			for id, entity := range idx.Entities {
-				merged.Entities[id] = entity
-				merged.ByType[entity.Type] = append(merged.ByType[entity.Type], id)
-				if entity.ParentID != "" {
-					merged.ByParent[entity.ParentID] = append(merged.ByParent[entity.ParentID], id)
+			idx, err = ParseXMLSource(commit, source)
+		case "json":
+			idx, err = ParseJSONSource(commit, source)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+		indexes = append(indexes, idx)
+	}
+
+	merged, err := mergeIndexes(sources, indexes, cfg.DuplicateIDs)
+	if err != nil {
+		return nil, err
+	}
+	merged.CommitSHA = commit.ID.String()
+	return merged, nil
+}
+
+// mergeIndexes combines the indexes parsed from sources into one. An entity ID defined by more
+// than one source is an error unless duplicateIDs keeps the first or last definition. The lookup
+// tables and stats are rebuilt from the merged entities so that replaced entities aren't counted.
+func mergeIndexes(sources []MCPSource, indexes []*EntityIndex, duplicateIDs string) (*EntityIndex, error) {
+	merged := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+
+	origins := make(map[string]string)
+	for i, idx := range indexes {
+		for id, entity := range idx.Entities {
+			if origin, ok := origins[id]; ok {
+				switch duplicateIDs {
+				case DuplicateIDsFirst:
+					continue
+				case DuplicateIDsLast:
+				default:
+					return nil, fmt.Errorf("entity %s is defined in both %s and %s (set duplicate_ids to \"first\" or \"last\" to keep one)", id, origin, sources[i].Path)
 				}
 			}
-			merged.Stats.TotalEntities += idx.Stats.TotalEntities
-			for t, c := range idx.Stats.TypeCounts {
-				merged.Stats.TypeCounts[t] += c
-			}
-			if merged.SourceFile == "" {
-				merged.SourceFile = source.Path
-			}
+			merged.Entities[id] = entity
+			origins[id] = sources[i].Path
+		}
+		if merged.SourceFile == "" {
+			merged.SourceFile = sources[i].Path
 		}
 	}
 
-	indexCache.Lock()
-	// Simple cache eviction: keep max 100 entries
-	if len(indexCache.entries) > 100 {
-		indexCache.entries = make(map[string]*EntityIndex)
+	ids := make([]string, 0, len(merged.Entities))
+	for id := range merged.Entities {
+		ids = append(ids, id)
 	}
-	indexCache.entries[cacheKey] = merged
-	indexCache.Unlock()
+	sort.Strings(ids)
+	for _, id := range ids {
+		entity := merged.Entities[id]
+		merged.ByType[entity.Type] = append(merged.ByType[entity.Type], id)
+		if entity.ParentID != "" {
+			merged.ByParent[entity.ParentID] = append(merged.ByParent[entity.ParentID], id)
+		}
+		merged.Stats.TotalEntities++
+		merged.Stats.TypeCounts[entity.Type]++
+	}
+	merged.Origins = origins
+	merged.buildSearchTokens(ids)
 
 	return merged, nil
 }
 
+// buildSearchTokens fills the inverted index of SearchEntities from the entities with the given sorted IDs.
+func (idx *EntityIndex) buildSearchTokens(ids []string) {
+	idx.searchTokens = make(map[string][]string)
+	add := func(id, text string) {
+		for _, token := range searchTokenize(text) {
+			tokenIDs := idx.searchTokens[token]
+			if len(tokenIDs) == 0 || tokenIDs[len(tokenIDs)-1] != id {
+				idx.searchTokens[token] = append(tokenIDs, id)
+			}
+		}
+	}
+	for _, id := range ids {
+		entity := idx.Entities[id]
+		add(id, entity.Name)
+		add(id, entity.ID)
+		for _, v := range entity.Attributes {
+			add(id, v)
+		}
+		for _, values := range entity.MultiAttributes {
+			for _, v := range values {
+				add(id, v)
+			}
+		}
+	}
+}
+
+// searchTokenize splits text into lower-cased runs of letters and digits.
+func searchTokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isNotSearchTokenRune)
+}
+
+func isNotSearchTokenRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
 // SearchEntities performs a case-insensitive search across entity names and attributes.
+// A query of the form "field:value" restricts matching to a single field, where field is
+// "name", "id", "type" or the name of an entity attribute (e.g. "code:01", "nmr:90000038578").
+// If the prefix does not name a known field, the whole query is matched against all fields.
 func (idx *EntityIndex) SearchEntities(query string, limit int) []*Entity {
+	if idx == nil {
+		return nil
+	}
 	if limit <= 0 {
 		limit = 25
 	}
@@ -85,18 +199,137 @@ func (idx *EntityIndex) SearchEntities(query string, limit int) []*Entity {
 		return nil
 	}
 
-	var results []*Entity
-	for _, entity := range idx.Entities {
-		if matchesQuery(entity, query) {
-			results = append(results, entity)
-			if len(results) >= limit {
-				break
+	match := func(entity *Entity) bool { return matchesQuery(entity, query) }
+	if field, value, ok := parseFieldQuery(query); ok && idx.hasField(field) {
+		match = func(entity *Entity) bool { return matchesField(entity, field, value) }
+	} else if idx.searchTokens != nil && !strings.ContainsFunc(query, isNotSearchTokenRune) {
+		return idx.searchByTokens(query, limit)
+	}
+
+	// like searchByTokens, sort by ID before applying the limit so every call returns the same results
+	var ids []string
+	for id, entity := range idx.Entities {
+		if match(entity) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	results := make([]*Entity, 0, min(len(ids), limit))
+	for _, id := range ids[:min(len(ids), limit)] {
+		results = append(results, idx.Entities[id])
+	}
+	return results
+}
+
+// searchByTokens answers a query without separators from the inverted index. Such a query can
+// only occur within a single token of a field, so the entities of the tokens containing it are
+// exactly those matchesQuery accepts, while only the distinct tokens are scanned rather than
+// every field of every entity. Results are sorted by ID.
+func (idx *EntityIndex) searchByTokens(query string, limit int) []*Entity {
+	var ids []string
+	seen := make(map[string]bool)
+	for token, tokenIDs := range idx.searchTokens {
+		if !strings.Contains(token, query) {
+			continue
+		}
+		for _, id := range tokenIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
 			}
 		}
 	}
+	sort.Strings(ids)
+
+	results := make([]*Entity, 0, min(len(ids), limit))
+	for _, id := range ids[:min(len(ids), limit)] {
+		results = append(results, idx.Entities[id])
+	}
 	return results
 }
 
+// parseFieldQuery splits a lower-cased "field:value" query. The field must be a plain
+// identifier and the value must be non-empty.
+func parseFieldQuery(query string) (field, value string, ok bool) {
+	field, value, ok = strings.Cut(query, ":")
+	if !ok || field == "" {
+		return "", "", false
+	}
+	for _, r := range field {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' && r != '.' {
+			return "", "", false
+		}
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", false
+	}
+	return field, value, true
+}
+
+// ancestors returns the ancestors of the entity with the given ID, from the root down to its
+// parent. The walk stops at a parent missing from the index and at a cycle in malformed parent links.
+func (idx *EntityIndex) ancestors(id string) []*Entity {
+	var chain []*Entity
+	visited := map[string]bool{id: true}
+	entity := idx.Entities[id]
+	for entity != nil && entity.ParentID != "" && !visited[entity.ParentID] {
+		parent, ok := idx.Entities[entity.ParentID]
+		if !ok {
+			break
+		}
+		visited[parent.ID] = true
+		chain = append(chain, parent)
+		entity = parent
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// hasField reports whether field is a built-in search field or an attribute of any entity.
+func (idx *EntityIndex) hasField(field string) bool {
+	switch field {
+	case "name", "id", "type":
+		return true
+	}
+	for _, entity := range idx.Entities {
+		for k := range entity.Attributes {
+			if strings.ToLower(k) == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesField(entity *Entity, field, value string) bool {
+	switch field {
+	case "name":
+		return strings.Contains(strings.ToLower(entity.Name), value)
+	case "id":
+		return strings.Contains(strings.ToLower(entity.ID), value)
+	case "type":
+		return strings.Contains(strings.ToLower(entity.Type), value)
+	}
+	for k, v := range entity.Attributes {
+		if strings.ToLower(k) == field && strings.Contains(strings.ToLower(v), value) {
+			return true
+		}
+	}
+	for k, values := range entity.MultiAttributes {
+		if strings.ToLower(k) != field {
+			continue
+		}
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func matchesQuery(entity *Entity, query string) bool {
 	if strings.Contains(strings.ToLower(entity.Name), query) {
 		return true
@@ -106,6 +339,13 @@ func matchesQuery(entity *Entity, query string) bool {
 			return true
 		}
 	}
+	for _, values := range entity.MultiAttributes {
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), query) {
+				return true
+			}
+		}
+	}
 	if strings.Contains(strings.ToLower(entity.ID), query) {
 		return true
 	}
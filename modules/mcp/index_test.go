@@ -0,0 +1,244 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	indexCache.Purge()
+	defer indexCache.Purge()
+
+	builds := make(map[string]int)
+	get := func(key string) *EntityIndex {
+		idx, err := getOrBuildCachedIndex(key, func() (*EntityIndex, error) {
+			builds[key]++
+			return &EntityIndex{CommitSHA: key}, nil
+		})
+		require.NoError(t, err)
+		return idx
+	}
+
+	first := get("1:commit-0")
+	for i := 1; i <= indexCacheSize; i++ {
+		get(fmt.Sprintf("1:commit-%d", i))
+		// keep the first entry hot while filling the cache
+		assert.Same(t, first, get("1:commit-0"))
+	}
+
+	assert.Equal(t, 1, builds["1:commit-0"], "recently used entry must not be evicted")
+	assert.Equal(t, indexCacheSize, indexCache.Len())
+
+	// commit-1 was the least recently used entry when commit-100 was added
+	get("1:commit-1")
+	assert.Equal(t, 2, builds["1:commit-1"])
+}
+
+func TestIndexCache_ConcurrentBuildsDeduplicated(t *testing.T) {
+	indexCache.Purge()
+	defer indexCache.Purge()
+
+	var builds atomic.Int32
+	release := make(chan struct{})
+	build := func() (*EntityIndex, error) {
+		builds.Add(1)
+		<-release
+		return &EntityIndex{CommitSHA: "abc"}, nil
+	}
+
+	const workers = 50
+	results := make([]*EntityIndex, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			idx, err := getOrBuildCachedIndex("1:abc", build)
+			assert.NoError(t, err)
+			results[i] = idx
+		}()
+	}
+
+	// give all goroutines a chance to block on the in-flight build
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, builds.Load())
+	for _, idx := range results {
+		assert.Same(t, results[0], idx)
+	}
+}
+
+func newSearchTestIndex() *EntityIndex {
+	return &EntityIndex{
+		Entities: map[string]*Entity{
+			"ministry:01": {
+				ID:         "ministry:01",
+				Type:       "ministry",
+				Name:       "Valsts kanceleja",
+				Attributes: map[string]string{"code": "01", "nmr": "90000038578"},
+			},
+			"organization:0001": {
+				ID:         "organization:0001",
+				Type:       "organization",
+				Name:       "Kancelejas birojs 01",
+				Attributes: map[string]string{"code": "0001", "nmr": "90000005678", "note": "see 01"},
+			},
+		},
+	}
+}
+
+func searchIDs(idx *EntityIndex, query string) []string {
+	var ids []string
+	for _, e := range idx.SearchEntities(query, 10) {
+		ids = append(ids, e.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSearchEntities_FieldScoped(t *testing.T) {
+	idx := newSearchTestIndex()
+
+	// unscoped matches any field
+	assert.Equal(t, []string{"ministry:01", "organization:0001"}, searchIDs(idx, "01"))
+
+	assert.Equal(t, []string{"ministry:01", "organization:0001"}, searchIDs(idx, "code:01"))
+	assert.Equal(t, []string{"organization:0001"}, searchIDs(idx, "code:0001"))
+
+	assert.Equal(t, []string{"ministry:01"}, searchIDs(idx, "nmr:90000038578"))
+	assert.Empty(t, searchIDs(idx, "nmr:01"))
+
+	assert.Equal(t, []string{"ministry:01", "organization:0001"}, searchIDs(idx, "name:kanceleja"))
+	assert.Equal(t, []string{"organization:0001"}, searchIDs(idx, "NAME: Birojs"))
+	assert.Empty(t, searchIDs(idx, "name:90000038578"))
+
+	// unknown field prefixes fall back to matching the whole query
+	assert.Equal(t, []string{"ministry:01"}, searchIDs(idx, "ministry:01"))
+}
+
+func TestSearchEntities_InvertedIndex(t *testing.T) {
+	scan := newSearchTestIndex()
+	scan.Entities["organization:0002"] = &Entity{
+		ID:              "organization:0002",
+		Type:            "organization",
+		Name:            "Ārlietu ministrija",
+		MultiAttributes: map[string][]string{"aliases": {"MFA", "Foreign-Affairs"}},
+	}
+	indexed := newSearchTestIndex()
+	indexed.Entities = scan.Entities
+	ids := make([]string, 0, len(indexed.Entities))
+	for id := range indexed.Entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	indexed.buildSearchTokens(ids)
+	require.NotEmpty(t, indexed.searchTokens)
+
+	for _, query := range []string{"01", "0001", "kancel", "ĀRLIETU", "mfa", "affairs", "foreign-affairs", "organization:00", "ministr", "birojs 01", "missing"} {
+		assert.Equal(t, searchIDs(scan, query), searchIDs(indexed, query), query)
+	}
+
+	// token results are sorted by ID before the limit is applied
+	results := indexed.SearchEntities("0", 2)
+	require.Len(t, results, 2)
+	assert.Equal(t, "ministry:01", results[0].ID)
+	assert.Equal(t, "organization:0001", results[1].ID)
+
+	// so are the results of field and multi-word queries, which are scanned
+	scan, indexed = newBenchmarkIndex(50)
+	for _, query := range []string{"organization 1", "name:birojs"} {
+		want := searchIDs(scan, query)
+		require.Greater(t, len(want), 3, query)
+		for range 5 {
+			var got []string
+			for _, e := range indexed.SearchEntities(query, 3) {
+				got = append(got, e.ID)
+			}
+			assert.Equal(t, want[:3], got, query)
+		}
+	}
+}
+
+func newBenchmarkIndex(n int) (scan, indexed *EntityIndex) {
+	scan = &EntityIndex{Entities: make(map[string]*Entity, n)}
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("organization:%06d", i)
+		scan.Entities[id] = &Entity{
+			ID:         id,
+			Type:       "organization",
+			Name:       fmt.Sprintf("Organization %d birojs", i),
+			Attributes: map[string]string{"code": fmt.Sprintf("%06d", i), "nmr": fmt.Sprintf("9000%07d", i*7)},
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	indexed = &EntityIndex{Entities: scan.Entities}
+	indexed.buildSearchTokens(ids)
+	return scan, indexed
+}
+
+func BenchmarkSearchEntities(b *testing.B) {
+	scan, indexed := newBenchmarkIndex(20000)
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scan.SearchEntities("019999", 25)
+		}
+	})
+	b.Run("inverted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			indexed.SearchEntities("019999", 25)
+		}
+	})
+}
+
+func TestMergeIndexes_DuplicateIDs(t *testing.T) {
+	sources := []MCPSource{
+		{Path: "a.json", Type: "json", EntityType: "organization", ParentAttribute: "parent"},
+		{Path: "b.json", Type: "json", EntityType: "organization"},
+	}
+	parse := func() []*EntityIndex {
+		first, second := newJSONTestIndex(), newJSONTestIndex()
+		require.NoError(t, parseJSONEntities([]byte(`[{"code": "0001", "name": "FIRST"}, {"code": "0002", "name": "SECOND", "parent": "0001"}]`), first, sources[0]))
+		require.NoError(t, parseJSONEntities([]byte(`[{"code": "0001", "name": "FIRST AGAIN"}, {"code": "0003", "name": "THIRD"}]`), second, sources[1]))
+		return []*EntityIndex{first, second}
+	}
+
+	_, err := mergeIndexes(sources, parse(), "")
+	assert.ErrorContains(t, err, "entity organization:0001 is defined in both a.json and b.json")
+	_, err = mergeIndexes(sources, parse(), DuplicateIDsError)
+	assert.Error(t, err)
+
+	merged, err := mergeIndexes(sources, parse(), DuplicateIDsFirst)
+	require.NoError(t, err)
+	assert.Equal(t, "FIRST", merged.Entities["organization:0001"].Name)
+	assert.Equal(t, 3, merged.Stats.TotalEntities)
+	assert.Equal(t, map[string]int{"organization": 3}, merged.Stats.TypeCounts)
+	assert.Equal(t, []string{"organization:0001", "organization:0002", "organization:0003"}, merged.ByType["organization"])
+	assert.Equal(t, []string{"organization:0002"}, merged.ByParent["organization:0001"])
+	assert.Equal(t, "a.json", merged.SourceFile)
+	assert.Equal(t, map[string]string{
+		"organization:0001": "a.json",
+		"organization:0002": "a.json",
+		"organization:0003": "b.json",
+	}, merged.Origins)
+
+	merged, err = mergeIndexes(sources, parse(), DuplicateIDsLast)
+	require.NoError(t, err)
+	assert.Equal(t, "FIRST AGAIN", merged.Entities["organization:0001"].Name)
+	assert.Equal(t, "b.json", merged.Origins["organization:0001"])
+	assert.Equal(t, 3, merged.Stats.TotalEntities)
+	assert.Equal(t, []string{"organization:0001", "organization:0002", "organization:0003"}, merged.ByType["organization"])
+}
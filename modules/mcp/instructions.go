@@ -0,0 +1,49 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// DefaultInstructionsFile is the repository file read for agent instructions when
+// server.instructions_file is not set.
+const DefaultInstructionsFile = ".processgit/mcp-instructions.md"
+
+// maxInstructionsFileSize caps the instructions file content sent to agents, longer files are truncated.
+const maxInstructionsFileSize = 32 * 1024
+
+// readInstructionsFile returns the content of the instructions file of cfg at commit,
+// or "" if the commit has no such file.
+func readInstructionsFile(commit *git.Commit, cfg *MCPConfig) string {
+	if commit == nil {
+		return ""
+	}
+	filePath := util.IfZero(cfg.Server.InstructionsFile, DefaultInstructionsFile)
+	data, err := ReadFileContent(commit, filePath)
+	if err != nil {
+		if !git.IsErrNotExist(err) {
+			log.Warn("MCP: unable to read instructions file %s: %v", filePath, err)
+		}
+		return ""
+	}
+	if len(data) > maxInstructionsFileSize {
+		log.Warn("MCP: instructions file %s exceeds %d bytes and is truncated", filePath, maxInstructionsFileSize)
+		// drop a rune cut in half at the end
+		data = []byte(strings.ToValidUTF8(string(data[:maxInstructionsFileSize]), ""))
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// joinInstructions appends the instructions file content to the configured instructions.
+func joinInstructions(instructions, fileContent string) string {
+	if instructions == "" || fileContent == "" {
+		return instructions + fileContent
+	}
+	return strings.TrimRight(instructions, "\n") + "\n\n" + fileContent
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initializeInstructions(t *testing.T, ctx *ToolContext) string {
+	resp := HandleJSONRPC(&JSONRPCRequest{JSONRPC: "2.0", ID: float64(1), Method: "initialize"}, ctx)
+	require.NotNil(t, resp)
+	result, ok := resp.Result.(InitializeResult)
+	require.True(t, ok)
+	return result.Instructions
+}
+
+func helpText(t *testing.T, ctx *ToolContext) string {
+	result, err := toolHelp(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	return result.Content[0].Text
+}
+
+func TestInstructionsFile(t *testing.T) {
+	t.Run("Present", func(t *testing.T) {
+		ctx := newTestToolContext()
+		ctx.Commit = newTestCommit(t, map[string]string{
+			DefaultInstructionsFile: "# Register\n\nCodes are zero-padded to four digits.\n",
+		})
+
+		assert.Equal(t, "A test server\n\n# Register\n\nCodes are zero-padded to four digits.", initializeInstructions(t, ctx))
+		assert.Contains(t, helpText(t, ctx), "## Additional instructions\n\n# Register\n\nCodes are zero-padded to four digits.\n")
+
+		ctx.Config.Server.Instructions = "Use find_by_attribute for NMR lookups.\n"
+		assert.Equal(t, "Use find_by_attribute for NMR lookups.\n\n# Register\n\nCodes are zero-padded to four digits.", initializeInstructions(t, ctx))
+		assert.Contains(t, helpText(t, ctx), "## Additional instructions\n\nUse find_by_attribute for NMR lookups.\n\n# Register")
+	})
+
+	t.Run("Absent", func(t *testing.T) {
+		ctx := newTestToolContext()
+		ctx.Commit = newTestCommit(t, map[string]string{"README.md": "readme"})
+
+		assert.Equal(t, "A test server", initializeInstructions(t, ctx))
+		assert.NotContains(t, helpText(t, ctx), "## Additional instructions")
+
+		// without a commit there is nothing to read
+		ctx.Commit = nil
+		assert.Equal(t, "A test server", initializeInstructions(t, ctx))
+	})
+
+	t.Run("ConfiguredPath", func(t *testing.T) {
+		ctx := newTestToolContext()
+		ctx.Config.Server.InstructionsFile = "AGENTS.md"
+		ctx.Commit = newTestCommit(t, map[string]string{
+			"AGENTS.md":             "Agents file",
+			DefaultInstructionsFile: "Default file",
+		})
+		assert.Equal(t, "A test server\n\nAgents file", initializeInstructions(t, ctx))
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		ctx := newTestToolContext()
+		// the multi-byte rune straddles the size cap
+		ctx.Commit = newTestCommit(t, map[string]string{
+			DefaultInstructionsFile: strings.Repeat("a", maxInstructionsFileSize-1) + "ā" + "tail",
+		})
+		content := readInstructionsFile(ctx.Commit, ctx.Config)
+		assert.Equal(t, strings.Repeat("a", maxInstructionsFileSize-1), content)
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"slices"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// logLevels lists the MCP (syslog) log levels from least to most severe.
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// defaultLogLevel is the minimum level sent to a session that never called logging/setLevel.
+const defaultLogLevel = "info"
+
+func isValidLogLevel(level string) bool {
+	return slices.Contains(logLevels, level)
+}
+
+// logLevelEnabled reports whether a message at level passes the minimum level.
+func logLevelEnabled(minimum, level string) bool {
+	return slices.Index(logLevels, level) >= slices.Index(logLevels, minimum)
+}
+
+// SetLogLevel sets the minimum level of log notifications sent to the session.
+func (s *SSESession) SetLogLevel(level string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logLevel = level
+}
+
+// sendLog writes a notifications/message event to the session if level is enabled.
+// It must only be called from the session's event loop, which owns the writer.
+func (s *SSESession) sendLog(level, logger string, data any) {
+	s.mu.Lock()
+	minimum := s.logLevel
+	s.mu.Unlock()
+	if minimum == "" {
+		minimum = defaultLogLevel
+	}
+	if !logLevelEnabled(minimum, level) {
+		return
+	}
+
+	notification := &JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params: LoggingMessageParams{
+			Level:  level,
+			Logger: logger,
+			Data:   data,
+		},
+	}
+	if err := writeSSEEvent(s.Writer, s.Flusher, "message", notification); err != nil {
+		log.Error("MCP SSE: failed to send log notification for session %s: %v", s.ID, err)
+	}
+}
+
+// logToClient sends a log notification to the client's SSE session. It is a no-op for plain
+// POST requests, which have no channel to push notifications on.
+func (ctx *ToolContext) logToClient(level, logger string, data any) {
+	if ctx.Session == nil {
+		return
+	}
+	ctx.Session.sendLog(level, logger, data)
+}
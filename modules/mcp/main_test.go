@@ -0,0 +1,51 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tempdir"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	gitHomePath, cleanup, err := tempdir.OsTempDir("gitea-test").MkdirTempRandom("git-home")
+	if err != nil {
+		log.Fatal("Unable to create temp dir: %v", err)
+	}
+	defer cleanup()
+
+	setting.Git.HomePath = gitHomePath
+	if err := git.InitFull(); err != nil {
+		log.Fatal("Unable to init git: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestCommit commits files to a new repository and returns the commit.
+func newTestCommit(t *testing.T, files map[string]string) *git.Commit {
+	repoPath := t.TempDir()
+	require.NoError(t, git.InitRepository(t.Context(), repoPath, false, git.Sha1ObjectFormat.Name()))
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoPath, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0o644))
+	}
+	require.NoError(t, git.AddChanges(t.Context(), repoPath, true))
+	signature := &git.Signature{Name: "Test", Email: "test@example.com"}
+	require.NoError(t, git.CommitChanges(t.Context(), repoPath, git.CommitChangesOptions{Committer: signature, Message: "add files"}))
+
+	gitRepo, err := git.OpenRepository(t.Context(), repoPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { gitRepo.Close() })
+	commit, err := gitRepo.GetCommit("HEAD")
+	require.NoError(t, err)
+	return commit
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// listPageSize is the number of items per page of paginated list methods such as prompts/list.
+var listPageSize = 50
+
+// PaginatedParams holds the cursor sent by the client to list methods.
+type PaginatedParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// parseCursor reads the cursor from the params of a list request, empty for the first page.
+func parseCursor(rawParams interface{}) (string, error) {
+	if rawParams == nil {
+		return "", nil
+	}
+	paramsBytes, err := json.Marshal(rawParams)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	var params PaginatedParams
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return "", errInvalidCursor
+	}
+	return params.Cursor, nil
+}
+
+// pageBounds returns the range of the page starting at cursor within total items, and the
+// cursor of the following page, empty if this is the last one. Cursors are opaque to clients
+// and only valid for listings in the same deterministic order.
+func pageBounds(cursor string, total int) (start, end int, nextCursor string, err error) {
+	if cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return 0, 0, "", errInvalidCursor
+		}
+		start, err = strconv.Atoi(string(decoded))
+		if err != nil || start < 0 || start > total {
+			return 0, 0, "", errInvalidCursor
+		}
+	}
+	end = min(start+listPageSize, total)
+	if end < total {
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+	return start, end, nextCursor, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
+)
+
+// ParseJSONSource reads a JSON array of flat records from Git and builds an EntityIndex.
+func ParseJSONSource(commit *git.Commit, source MCPSource) (*EntityIndex, error) {
+	jsonData, err := ReadFileContent(commit, source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read source %s: %w", source.Path, err)
+	}
+
+	index := &EntityIndex{
+		Entities:   make(map[string]*Entity),
+		ByType:     make(map[string][]string),
+		ByParent:   make(map[string][]string),
+		SourceFile: source.Path,
+		CommitSHA:  commit.ID.String(),
+		Stats:      IndexStats{TypeCounts: make(map[string]int)},
+	}
+
+	if err := parseJSONEntities(jsonData, index, source); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// parseJSONEntities reads a JSON array of objects, e.g. [{"code": "01", "name": "..."}, ...].
+// Every object with the source's id attribute becomes an entity of source.EntityType keyed
+// as "type:value". Scalar fields are stored as attributes, arrays of scalars as multi-value
+// attributes; nested objects are ignored. If source.ParentAttribute is set, its value is the
+// id of the parent record of the same type.
+func parseJSONEntities(data []byte, index *EntityIndex, source MCPSource) error {
+	var records []map[string]any
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("JSON parse error: %w", err)
+	}
+
+	entityType := source.EntityType
+	idAttr := source.EntityIDAttribute()
+
+	for _, record := range records {
+		entity := &Entity{
+			Type:       entityType,
+			Attributes: make(map[string]string, len(record)),
+		}
+		for key, value := range record {
+			switch v := value.(type) {
+			case []any:
+				values := make([]string, 0, len(v))
+				for _, item := range v {
+					if s, ok := jsonScalarString(item); ok {
+						values = append(values, s)
+					}
+				}
+				if len(values) == 0 {
+					continue
+				}
+				entity.Attributes[key] = strings.Join(values, ", ")
+				if len(values) > 1 {
+					if entity.MultiAttributes == nil {
+						entity.MultiAttributes = make(map[string][]string)
+					}
+					entity.MultiAttributes[key] = values
+				}
+			default:
+				if s, ok := jsonScalarString(v); ok {
+					entity.Attributes[key] = s
+				}
+			}
+		}
+
+		key := entity.Attributes[idAttr]
+		if key == "" {
+			continue
+		}
+		entity.ID = EntityID(entityType, key)
+		entity.Name = entity.Attributes["name"]
+		if source.ParentAttribute != "" {
+			if parentKey := entity.Attributes[source.ParentAttribute]; parentKey != "" {
+				entity.ParentID = EntityID(entityType, parentKey)
+			}
+		}
+
+		index.Entities[entity.ID] = entity
+		index.ByType[entityType] = append(index.ByType[entityType], entity.ID)
+		index.Stats.TotalEntities++
+		index.Stats.TypeCounts[entityType]++
+	}
+
+	// Records may appear before their parent, so link children once all are indexed
+	for _, id := range index.ByType[entityType] {
+		entity := index.Entities[id]
+		if entity.ParentID == "" {
+			continue
+		}
+		index.ByParent[entity.ParentID] = append(index.ByParent[entity.ParentID], id)
+		if parent, ok := index.Entities[entity.ParentID]; ok {
+			parent.Children = append(parent.Children, id)
+		}
+	}
+
+	return nil
+}
+
+// ValidateJSONSource checks that a JSON source is a well-formed array of records and collects statistics.
+func ValidateJSONSource(commit *git.Commit, source MCPSource) (bool, []ValidationError, IndexStats, error) {
+	jsonData, err := ReadFileContent(commit, source.Path)
+	if err != nil {
+		return false, nil, IndexStats{}, fmt.Errorf("cannot read %s: %w", source.Path, err)
+	}
+
+	index := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+	if err := parseJSONEntities(jsonData, index, source); err != nil {
+		return false, []ValidationError{{Source: source.Path, Message: err.Error()}}, index.Stats, nil
+	}
+	return true, nil, index.Stats, nil
+}
+
+// jsonScalarString converts a decoded JSON string, number or boolean to its string form.
+func jsonScalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
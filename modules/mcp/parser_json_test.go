@@ -0,0 +1,87 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flatOrganizationsJSON = `[
+  {"code": "0002", "name": "SECOND ORG", "nmr": 90000028300, "parent": "0001", "active": true},
+  {"code": "0001", "name": "FIRST ORG", "nmr": "90000038578", "tags": ["state", "ministry"], "address": {"city": "Riga"}},
+  {"code": "0003", "name": "THIRD ORG", "parent": "0001", "tags": ["agency"]},
+  {"name": "NO CODE"}
+]`
+
+func newJSONTestIndex() *EntityIndex {
+	return &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+}
+
+func TestParseJSONEntities(t *testing.T) {
+	index := newJSONTestIndex()
+	err := parseJSONEntities([]byte(flatOrganizationsJSON), index, MCPSource{EntityType: "organization"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, index.Stats.TotalEntities)
+	assert.Equal(t, 3, index.Stats.TypeCounts["organization"])
+	assert.ElementsMatch(t, []string{"organization:0001", "organization:0002", "organization:0003"}, index.ByType["organization"])
+
+	first := index.Entities["organization:0001"]
+	require.NotNil(t, first)
+	assert.Equal(t, "organization", first.Type)
+	assert.Equal(t, "FIRST ORG", first.Name)
+	assert.Empty(t, first.ParentID)
+	assert.Equal(t, "90000038578", first.Attributes["nmr"])
+	assert.Equal(t, "state, ministry", first.Attributes["tags"])
+	assert.Equal(t, []string{"state", "ministry"}, first.MultiAttributes["tags"])
+	assert.NotContains(t, first.Attributes, "address")
+
+	second := index.Entities["organization:0002"]
+	require.NotNil(t, second)
+	assert.Equal(t, "90000028300", second.Attributes["nmr"])
+	assert.Equal(t, "true", second.Attributes["active"])
+	assert.Empty(t, second.ParentID, "no parent_attribute configured")
+
+	third := index.Entities["organization:0003"]
+	require.NotNil(t, third)
+	assert.Equal(t, "agency", third.Attributes["tags"])
+	assert.Nil(t, third.MultiAttributes)
+
+	assert.Empty(t, index.ByParent)
+}
+
+func TestParseJSONEntities_Parent(t *testing.T) {
+	index := newJSONTestIndex()
+	err := parseJSONEntities([]byte(flatOrganizationsJSON), index, MCPSource{EntityType: "organization", ParentAttribute: "parent"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "organization:0001", index.Entities["organization:0002"].ParentID)
+	assert.Equal(t, "organization:0001", index.Entities["organization:0003"].ParentID)
+	assert.ElementsMatch(t, []string{"organization:0002", "organization:0003"}, index.ByParent["organization:0001"])
+	assert.ElementsMatch(t, []string{"organization:0002", "organization:0003"}, index.Entities["organization:0001"].Children)
+}
+
+func TestParseJSONEntities_IDAttribute(t *testing.T) {
+	index := newJSONTestIndex()
+	err := parseJSONEntities([]byte(flatOrganizationsJSON), index, MCPSource{EntityType: "organization", IDAttribute: "nmr"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, index.Stats.TotalEntities)
+	assert.Contains(t, index.Entities, "organization:90000038578")
+	assert.Contains(t, index.Entities, "organization:90000028300")
+}
+
+func TestParseJSONEntities_NotAnArray(t *testing.T) {
+	index := newJSONTestIndex()
+	err := parseJSONEntities([]byte(`{"code": "01"}`), index, MCPSource{EntityType: "organization"})
+	assert.ErrorContains(t, err, "JSON parse error")
+}
@@ -10,9 +10,14 @@ import (
 	"io"
 	"strings"
 
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
 )
 
+// displayNameElements are child element names whose text is used as the parent entity's Name.
+var displayNameElements = container.SetOf("n", "name")
+
 // ParseXMLSource reads an XML file from Git and builds an EntityIndex.
 func ParseXMLSource(commit *git.Commit, source MCPSource) (*EntityIndex, error) {
 	xmlData, err := ReadFileContent(commit, source.Path)
@@ -29,7 +34,7 @@ func ParseXMLSource(commit *git.Commit, source MCPSource) (*EntityIndex, error)
 		Stats:      IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	if err := parseXMLEntities(xmlData, index); err != nil {
+	if err := parseXMLEntities(xmlData, index, source.EntityIDAttribute()); err != nil {
 		return nil, err
 	}
 
@@ -37,9 +42,10 @@ func ParseXMLSource(commit *git.Commit, source MCPSource) (*EntityIndex, error)
 }
 
 // parseXMLEntities walks the XML tree and extracts entities.
-// Heuristic: any element that has a "code" attribute is treated as an entity.
+// Heuristic: any element that has an idAttr attribute (usually "code") is treated as an entity
+// and keyed as "element:value".
 // Child element text is stored as entity attributes (e.g., <description>, <departmentRef>).
-func parseXMLEntities(data []byte, index *EntityIndex) error {
+func parseXMLEntities(data []byte, index *EntityIndex, idAttr string) error {
 	decoder := xml.NewDecoder(bytes.NewReader(data))
 
 	type stackFrame struct {
@@ -80,10 +86,10 @@ func parseXMLEntities(data []byte, index *EntityIndex) error {
 			}
 			stack = append(stack, frame)
 
-			// Entity heuristic: has a "code" attribute
-			if code, hasCode := attrs["code"]; hasCode {
+			// Entity heuristic: has the identifying attribute
+			if key, hasKey := attrs[idAttr]; hasKey {
 				entityType := localName
-				entityID := entityType + ":" + code
+				entityID := EntityID(entityType, key)
 				entity := &Entity{
 					ID:         entityID,
 					Type:       entityType,
@@ -123,8 +129,8 @@ func parseXMLEntities(data []byte, index *EntityIndex) error {
 				stack = stack[:len(stack)-1]
 
 				// If this frame was an entity, restore parent context
-				if _, hasCode := frame.attrs["code"]; hasCode {
-					entityID := frame.name + ":" + frame.attrs["code"]
+				if key, hasKey := frame.attrs[idAttr]; hasKey {
+					entityID := EntityID(frame.name, key)
 					if _, ok := index.Entities[entityID]; ok {
 						currentParentID = frame.parentID
 					}
@@ -133,9 +139,10 @@ func parseXMLEntities(data []byte, index *EntityIndex) error {
 				// Store child element text content in parent entity
 				if frame.text != "" && frame.parentID != "" {
 					if parentEntity, ok := index.Entities[frame.parentID]; ok {
-						// Set Name from <n> or <name> child element
+						// Set Name from a display name child element such as <n> or <name>
 						// (Go's encoding/xml strips namespace prefixes, so vdvc:name → "name")
-						if (frame.name == "n" || frame.name == "name") && parentEntity.Name == "" {
+						isNameElement := displayNameElements.Contains(frame.name)
+						if isNameElement && parentEntity.Name == "" {
 							parentEntity.Name = frame.text
 						}
 
@@ -144,9 +151,17 @@ func parseXMLEntities(data []byte, index *EntityIndex) error {
 						// Only store if not already set by an XML attribute on the entity
 						if _, exists := parentEntity.Attributes[frame.name]; !exists {
 							parentEntity.Attributes[frame.name] = frame.text
-						} else {
+						} else if !isNameElement {
 							// Append with separator for multi-value elements
-							// (e.g., multiple <departmentRef> under one category)
+							// (e.g., multiple <departmentRef> under one category),
+							// and keep the individual values in MultiAttributes
+							if parentEntity.MultiAttributes == nil {
+								parentEntity.MultiAttributes = make(map[string][]string)
+							}
+							if _, ok := parentEntity.MultiAttributes[frame.name]; !ok {
+								parentEntity.MultiAttributes[frame.name] = []string{parentEntity.Attributes[frame.name]}
+							}
+							parentEntity.MultiAttributes[frame.name] = append(parentEntity.MultiAttributes[frame.name], frame.text)
 							parentEntity.Attributes[frame.name] += ", " + frame.text
 						}
 					}
@@ -158,12 +173,16 @@ func parseXMLEntities(data []byte, index *EntityIndex) error {
 	return nil
 }
 
-// ReadFileContent reads raw file bytes from the Git commit.
+// ReadFileContent reads raw file bytes from the Git commit. Files larger than
+// [mcp] MAX_SOURCE_SIZE_MB are rejected before they are read.
 func ReadFileContent(commit *git.Commit, path string) ([]byte, error) {
 	entry, err := commit.GetTreeEntryByPath(path)
 	if err != nil {
 		return nil, err
 	}
+	if maxSize := int64(setting.MCP.MaxSourceSizeMB) << 20; maxSize > 0 && entry.Blob().Size() > maxSize {
+		return nil, fmt.Errorf("%s is %d bytes, more than the maximum source size of %d MB ([mcp] MAX_SOURCE_SIZE_MB)", path, entry.Blob().Size(), setting.MCP.MaxSourceSizeMB)
+	}
 	reader, err := entry.Blob().DataAsync()
 	if err != nil {
 		return nil, err
@@ -172,29 +191,32 @@ func ReadFileContent(commit *git.Commit, path string) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
-// ValidateXMLAgainstXSD performs basic XML well-formedness check
-// and structural validation. For MVP: validates XML is well-formed
-// and collects statistics. Full XSD validation can be added later.
-func ValidateXMLAgainstXSD(commit *git.Commit, source MCPSource) (bool, []string, IndexStats, error) {
+// ValidateXMLAgainstXSD validates an XML source. When the source declares a schema,
+// the XSD is read from the same commit and the document is validated against it;
+// otherwise only well-formedness is checked. Statistics are collected in both cases.
+func ValidateXMLAgainstXSD(commit *git.Commit, source MCPSource) (bool, []ValidationError, IndexStats, error) {
 	xmlData, err := ReadFileContent(commit, source.Path)
 	if err != nil {
 		return false, nil, IndexStats{}, fmt.Errorf("cannot read %s: %w", source.Path, err)
 	}
 
-	// Well-formedness check
-	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
-	var errors []string
-	for {
-		_, err := decoder.Token()
-		if err == io.EOF {
-			break
+	var schema *xsdSchema
+	if source.Schema != "" {
+		xsdData, err := ReadFileContent(commit, source.Schema)
+		if err != nil {
+			return false, nil, IndexStats{}, fmt.Errorf("cannot read schema %s: %w", source.Schema, err)
 		}
+		schema, err = parseXSD(xsdData)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("XML error: %s", err.Error()))
-			break
+			return false, nil, IndexStats{}, fmt.Errorf("cannot parse schema %s: %w", source.Schema, err)
 		}
 	}
 
+	errs := validateXMLData(xmlData, schema)
+	for i := range errs {
+		errs[i].Source = source.Path
+	}
+
 	// Parse for statistics
 	index := &EntityIndex{
 		Entities: make(map[string]*Entity),
@@ -202,8 +224,27 @@ func ValidateXMLAgainstXSD(commit *git.Commit, source MCPSource) (bool, []string
 		ByParent: make(map[string][]string),
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
-	_ = parseXMLEntities(xmlData, index) // best-effort for stats
+	_ = parseXMLEntities(xmlData, index, source.EntityIDAttribute()) // best-effort for stats
 
-	valid := len(errors) == 0
-	return valid, errors, index.Stats, nil
+	valid := len(errs) == 0
+	return valid, errs, index.Stats, nil
+}
+
+// validateXMLData validates xmlData against schema, or only checks well-formedness if schema is nil.
+func validateXMLData(xmlData []byte, schema *xsdSchema) []ValidationError {
+	if schema != nil {
+		return schema.validate(xmlData)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			line, _ := decoder.InputPos()
+			return []ValidationError{{Line: line, Message: "XML error: " + err.Error()}}
+		}
+	}
 }
@@ -4,8 +4,12 @@
 package mcp
 
 import (
+	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,7 +39,7 @@ func TestParseXMLEntities(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	// 2 ministries + 3 organizations = 5 entities
@@ -70,7 +74,7 @@ func TestParseXMLEntities_Empty(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 	assert.Equal(t, 0, index.Stats.TotalEntities)
 }
@@ -85,10 +89,42 @@ func TestParseXMLEntities_InvalidXML(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	assert.Error(t, err)
 }
 
+func TestParseXMLEntities_CustomIDAttribute(t *testing.T) {
+	xmlData := []byte(`<?xml version="1.0"?>
+<root>
+  <ministry id="M1" code="ignored">
+    <n>First Ministry</n>
+    <organization id="O1"><n>First Org</n></organization>
+  </ministry>
+  <organization code="X1"><n>Not an entity here</n></organization>
+</root>`)
+
+	index := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+
+	err := parseXMLEntities(xmlData, index, "id")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, index.Stats.TotalEntities)
+	ministry := index.Entities["ministry:M1"]
+	require.NotNil(t, ministry)
+	assert.Equal(t, "First Ministry", ministry.Name)
+
+	org := index.Entities["organization:O1"]
+	require.NotNil(t, org)
+	assert.Equal(t, "ministry:M1", org.ParentID)
+	assert.Equal(t, []string{"organization:O1"}, index.ByParent["ministry:M1"])
+	assert.Nil(t, index.Entities["organization:X1"])
+}
+
 func TestParseXMLEntities_NameFromChildElement(t *testing.T) {
 	xmlData := []byte(`<?xml version="1.0"?>
 <root>
@@ -104,7 +140,7 @@ func TestParseXMLEntities_NameFromChildElement(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	item := index.Entities["item:A1"]
@@ -125,7 +161,7 @@ func TestParseXMLEntities_NameFromAttribute(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	item := index.Entities["item:B1"]
@@ -151,7 +187,7 @@ func TestParseXMLEntities_NameFromNameElement(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	item := index.Entities["item:C1"]
@@ -159,6 +195,40 @@ func TestParseXMLEntities_NameFromNameElement(t *testing.T) {
 	assert.Equal(t, "Item Name From Name Element", item.Name)
 }
 
+func TestParseXMLEntities_NameElementNotConcatenated(t *testing.T) {
+	// Display name elements keep their first value instead of being joined like multi-value fields
+	xmlData := []byte(`<?xml version="1.0"?>
+<root>
+  <item code="D1" name="Name From Attr">
+    <name>Name From Element</name>
+  </item>
+  <item code="D2">
+    <n>First Name</n>
+    <n>Second Name</n>
+  </item>
+</root>`)
+
+	index := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
+	require.NoError(t, err)
+
+	d1 := index.Entities["item:D1"]
+	require.NotNil(t, d1)
+	assert.Equal(t, "Name From Attr", d1.Name)
+	assert.Equal(t, "Name From Attr", d1.Attributes["name"])
+
+	d2 := index.Entities["item:D2"]
+	require.NotNil(t, d2)
+	assert.Equal(t, "First Name", d2.Name)
+	assert.Equal(t, "First Name", d2.Attributes["n"])
+}
+
 func TestParseXMLEntities_ChildElementsAsAttributes(t *testing.T) {
 	// Tests that child element text is stored in Attributes map
 	xmlData := []byte(`<?xml version="1.0"?>
@@ -177,7 +247,7 @@ func TestParseXMLEntities_ChildElementsAsAttributes(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	cat := index.Entities["category:P-1-1"]
@@ -206,17 +276,49 @@ func TestParseXMLEntities_MultiValueChildElements(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	cat := index.Entities["category:P-1-1"]
 	require.NotNil(t, cat)
 	assert.Equal(t, "LN, IPD, DTD", cat.Attributes["departmentRef"])
+	assert.Equal(t, []string{"LN", "IPD", "DTD"}, cat.MultiAttributes["departmentRef"])
+	assert.NotContains(t, cat.MultiAttributes, "n", "single-valued elements are not repeated")
 }
 
-func TestParseXMLEntities_NamespacedElements(t *testing.T) {
-	// Go's encoding/xml strips namespace prefixes: vdvc:name → name
+func TestParseXMLEntities_MultiValueWithComma(t *testing.T) {
+	// Values containing the separator stay intact in MultiAttributes and remain searchable
 	xmlData := []byte(`<?xml version="1.0"?>
+<root>
+  <category code="P-1-2">
+    <n>Comma Category</n>
+    <keyword>finance, budget</keyword>
+    <keyword>audit</keyword>
+  </category>
+</root>`)
+
+	index := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
+	require.NoError(t, err)
+
+	cat := index.Entities["category:P-1-2"]
+	require.NotNil(t, cat)
+	assert.Equal(t, "finance, budget, audit", cat.Attributes["keyword"])
+	assert.Equal(t, []string{"finance, budget", "audit"}, cat.MultiAttributes["keyword"])
+
+	results := index.SearchEntities("keyword:finance, budget", 10)
+	require.Len(t, results, 1)
+	assert.Equal(t, "category:P-1-2", results[0].ID)
+}
+
+// namespacedClassificationXML is a three-level domain → group → category classification.
+const namespacedClassificationXML = `<?xml version="1.0"?>
 <vdvc:classification xmlns:vdvc="urn:vdvc:classification:2026" version="1.0.0">
   <vdvc:domain code="P">
     <vdvc:name>Pārvalde</vdvc:name>
@@ -229,7 +331,11 @@ func TestParseXMLEntities_NamespacedElements(t *testing.T) {
       </vdvc:category>
     </vdvc:group>
   </vdvc:domain>
-</vdvc:classification>`)
+</vdvc:classification>`
+
+func TestParseXMLEntities_NamespacedElements(t *testing.T) {
+	// Go's encoding/xml strips namespace prefixes: vdvc:name → name
+	xmlData := []byte(namespacedClassificationXML)
 
 	index := &EntityIndex{
 		Entities: make(map[string]*Entity),
@@ -238,7 +344,7 @@ func TestParseXMLEntities_NamespacedElements(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	// Domain
@@ -285,7 +391,7 @@ func TestParseXMLEntities_SearchableDescriptions(t *testing.T) {
 		Stats:    IndexStats{TypeCounts: make(map[string]int)},
 	}
 
-	err := parseXMLEntities(xmlData, index)
+	err := parseXMLEntities(xmlData, index, DefaultIDAttribute)
 	require.NoError(t, err)
 
 	// Search by description keyword — should find P-1-13
@@ -302,3 +408,22 @@ func TestParseXMLEntities_SearchableDescriptions(t *testing.T) {
 	results = index.SearchEntities("sarakste", 10)
 	assert.True(t, len(results) >= 1)
 }
+
+func TestParseXMLSource_MaxSourceSize(t *testing.T) {
+	defer test.MockVariableValue(&setting.MCP.MaxSourceSizeMB, 1)()
+	small := `<register><item code="01" name="Small"/></register>`
+	large := `<register>` + strings.Repeat(`<item code="01" name="Large"/>`, 40000) + `</register>`
+	commit := newTestCommit(t, map[string]string{"small.xml": small, "large.xml": large})
+
+	index, err := ParseXMLSource(commit, MCPSource{Path: "small.xml", Type: "xml"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, index.Stats.TotalEntities)
+
+	_, err = ParseXMLSource(commit, MCPSource{Path: "large.xml", Type: "xml"})
+	assert.ErrorContains(t, err, "cannot read source large.xml: large.xml is 1200021 bytes, more than the maximum source size of 1 MB")
+
+	// no limit
+	defer test.MockVariableValue(&setting.MCP.MaxSourceSizeMB, 0)()
+	_, err = ReadFileContent(commit, "large.xml")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,41 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import "code.gitea.io/gitea/modules/log"
+
+// maxProgressNotifications bounds the notifications sent for one tool call, so a register with
+// many thousands of entities doesn't flood the stream.
+const maxProgressNotifications = 100
+
+// sendProgress writes a notifications/progress event to the session.
+// It must only be called from the session's event loop, which owns the writer.
+func (s *SSESession) sendProgress(params ProgressNotificationParams) {
+	notification := &JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/progress",
+		Params:  params,
+	}
+	if err := writeSSEEvent(s.Writer, s.Flusher, "message", notification); err != nil {
+		log.Error("MCP SSE: failed to send progress notification for session %s: %v", s.ID, err)
+	}
+}
+
+// reportProgress tells the client that done of total steps of the current tool call are
+// complete. It is a no-op unless the call carries a progress token and arrived on an SSE
+// session, and only every total/maxProgressNotifications steps and the last step are sent.
+func (ctx *ToolContext) reportProgress(done, total int, message string) {
+	if ctx.Session == nil || ctx.progressToken == nil {
+		return
+	}
+	if step := max(total/maxProgressNotifications, 1); done%step != 0 && done != total {
+		return
+	}
+	ctx.Session.sendProgress(ProgressNotificationParams{
+		ProgressToken: ctx.progressToken,
+		Progress:      done,
+		Total:         total,
+		Message:       message,
+	})
+}
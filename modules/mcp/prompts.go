@@ -0,0 +1,117 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// promptTemplate is a prompt whose text contains {{argument}} placeholders.
+// Besides the declared arguments, {{server}} expands to the server name and, when an
+// "id" argument is given, {{entity}} expands to the JSON of that entity.
+type promptTemplate struct {
+	PromptDefinition
+	Text string
+}
+
+var promptTemplates = []promptTemplate{
+	{
+		PromptDefinition: PromptDefinition{
+			Name:        "summarize_entity",
+			Description: "Summarize one entity of the register, including its attributes and children.",
+			Arguments: []PromptArgument{
+				{Name: "id", Description: "Entity ID in 'type:code' format, e.g. 'ministry:01'", Required: true},
+			},
+		},
+		Text: "Summarize the entity {{id}} from '{{server}}' for a non-technical reader. " +
+			"Explain what it is, list its most important attributes and describe its children. " +
+			"Use get_entity to fetch more details if needed.\n\nEntity data:\n{{entity}}",
+	},
+	{
+		PromptDefinition: PromptDefinition{
+			Name:        "find_duplicates",
+			Description: "Look for entities of one type that are likely duplicates of each other.",
+			Arguments: []PromptArgument{
+				{Name: "type", Description: "Entity type to check, e.g. 'organization'", Required: true},
+			},
+		},
+		Text: "Find {{type}} entities in '{{server}}' that are likely duplicates: similar names, " +
+			"identical registration numbers or identical attribute values. " +
+			"Use list_entities(type=\"{{type}}\") to get the entities, then report each group of " +
+			"suspected duplicates with their IDs and the reason they look alike.",
+	},
+	{
+		PromptDefinition: PromptDefinition{
+			Name:        "explore_register",
+			Description: "Get an overview of the register: its entity types, hierarchy and size.",
+		},
+		Text: "Give an overview of '{{server}}'. Call describe_model first, then explain the entity " +
+			"types, how they are nested and how many entities of each type exist.",
+	},
+}
+
+// GetPromptDefinitions returns the MCP prompt definitions for prompts/list, sorted by name
+// so that paginated listings are stable.
+func GetPromptDefinitions() []PromptDefinition {
+	defs := make([]PromptDefinition, 0, len(promptTemplates))
+	for _, p := range promptTemplates {
+		defs = append(defs, p.PromptDefinition)
+	}
+	slices.SortFunc(defs, func(a, b PromptDefinition) int { return strings.Compare(a.Name, b.Name) })
+	return defs
+}
+
+// RenderPrompt substitutes the arguments into the named prompt.
+func RenderPrompt(ctx *ToolContext, name string, args map[string]string) (*PromptGetResult, error) {
+	var tmpl *promptTemplate
+	for i := range promptTemplates {
+		if promptTemplates[i].Name == name {
+			tmpl = &promptTemplates[i]
+			break
+		}
+	}
+	if tmpl == nil {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+
+	replacements := []string{"{{server}}", ctx.Config.Server.Name}
+	values := make(map[string]string, len(tmpl.Arguments))
+	for _, arg := range tmpl.Arguments {
+		value := strings.TrimSpace(args[arg.Name])
+		if value == "" && arg.Required {
+			return nil, fmt.Errorf("missing required argument: %s", arg.Name)
+		}
+		values[arg.Name] = value
+		replacements = append(replacements, "{{"+arg.Name+"}}", value)
+	}
+
+	if strings.Contains(tmpl.Text, "{{entity}}") {
+		if !ctx.HasIndexedData() {
+			return nil, ErrNoIndexedData
+		}
+		entity, ok := ctx.Index.Entities[values["id"]]
+		if !ok {
+			return nil, fmt.Errorf("entity '%s' not found", values["id"])
+		}
+		entityJSON, err := json.MarshalIndent(entity, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		replacements = append(replacements, "{{entity}}", string(entityJSON))
+	}
+
+	return &PromptGetResult{
+		Description: tmpl.Description,
+		Messages: []PromptMessage{
+			{
+				Role:    "user",
+				Content: ToolContent{Type: ContentTypeText, Text: strings.NewReplacer(replacements...).Replace(tmpl.Text)},
+			},
+		},
+	}, nil
+}
@@ -5,35 +5,55 @@ package mcp
 
 import (
 	"fmt"
+	"slices"
 
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
 )
 
 const (
-	// MCPProtocolVersion is the MCP protocol version this server implements.
+	// MCPProtocolVersion is the latest MCP protocol version this server implements.
 	MCPProtocolVersion = "2025-03-26"
 	// ServerVersion is the version of this MCP server implementation.
 	ServerVersion = "0.1.0"
 )
 
+// JSONContentCapability is the experimental capability a client advertises during initialize
+// to receive tool results as "json" content blocks instead of JSON encoded in "text" blocks.
+const JSONContentCapability = "jsonContent"
+
+// supportedProtocolVersions lists the MCP protocol versions this server can speak.
+var supportedProtocolVersions = []string{MCPProtocolVersion, "2024-11-05"}
+
 // HandleJSONRPC processes a single JSON-RPC request and returns a response.
 func HandleJSONRPC(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse {
 	switch req.Method {
 
 	case "initialize":
+		params := parseInitializeParams(req.Params)
+		// the choice is remembered per SSE session; plain POST requests are stateless and get text
+		if toolCtx.Session != nil {
+			toolCtx.Session.SetJSONContent(clientAcceptsJSONContent(params))
+		}
 		return &JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result: InitializeResult{
-				ProtocolVersion: MCPProtocolVersion,
+				ProtocolVersion: negotiateProtocolVersion(params),
 				Capabilities: ServerCapabilities{
-					Tools: &ToolCapability{},
+					Tools:        &ToolCapability{},
+					Logging:      &LoggingCapability{},
+					Prompts:      &PromptCapability{},
+					Experimental: map[string]interface{}{JSONContentCapability: map[string]interface{}{}},
 				},
 				ServerInfo: ServerInfo{
 					Name:    toolCtx.Config.Server.Name,
 					Version: ServerVersion,
 				},
-				Instructions: toolCtx.Config.Server.Description,
+				Instructions: joinInstructions(
+					util.IfZero(toolCtx.Config.Server.Instructions, toolCtx.Config.Server.Description),
+					readInstructionsFile(toolCtx.Commit, toolCtx.Config),
+				),
 			},
 		}
 
@@ -53,6 +73,15 @@ func HandleJSONRPC(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse {
 	case "tools/call":
 		return handleToolCall(req, toolCtx)
 
+	case "prompts/list":
+		return handlePromptList(req)
+
+	case "prompts/get":
+		return handlePromptGet(req, toolCtx)
+
+	case "logging/setLevel":
+		return handleSetLevel(req, toolCtx)
+
 	case "ping":
 		return &JSONRPCResponse{
 			JSONRPC: "2.0",
@@ -88,10 +117,32 @@ func handleToolCall(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse
 		return jsonRPCError(req.ID, -32602, "Missing tool name")
 	}
 
+	if err := validateToolArguments(toolInputSchema(toolCtx.Config, params.Name), params.Arguments); err != nil {
+		if params.Arguments == nil {
+			// some clients leave out "arguments" entirely instead of sending an empty object
+			return jsonRPCError(req.ID, -32602, fmt.Sprintf("Invalid arguments for tool %s: no arguments given, %v", params.Name, err))
+		}
+		return jsonRPCError(req.ID, -32602, fmt.Sprintf("Invalid arguments for tool %s: %v", params.Name, err))
+	}
+	if params.Arguments == nil {
+		params.Arguments = map[string]interface{}{}
+	}
+
+	if params.Meta != nil && params.Meta.ProgressToken != nil {
+		// the context is shared by the calls of a session, the token belongs to this one
+		callCtx := *toolCtx
+		callCtx.progressToken = params.Meta.ProgressToken
+		toolCtx = &callCtx
+	}
+
 	result, err := ExecuteTool(toolCtx, params.Name, params.Arguments)
 	if err != nil {
+		toolCtx.logToClient("error", "tools", map[string]any{"tool": params.Name, "error": err.Error()})
 		return jsonRPCError(req.ID, -32000, "Tool execution error: "+err.Error())
 	}
+	if result.IsError {
+		toolCtx.logToClient("error", "tools", map[string]any{"tool": params.Name, "result": result.Content})
+	}
 
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
@@ -100,6 +151,115 @@ func handleToolCall(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse
 	}
 }
 
+// handlePromptList returns a page of the prompts sorted by name, with the cursor of the next page if more remain.
+func handlePromptList(req *JSONRPCRequest) *JSONRPCResponse {
+	cursor, err := parseCursor(req.Params)
+	if err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid params: "+err.Error())
+	}
+
+	prompts := GetPromptDefinitions()
+	start, end, nextCursor, err := pageBounds(cursor, len(prompts))
+	if err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid params: "+err.Error())
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: PromptListResult{
+			Prompts:    prompts[start:end],
+			NextCursor: nextCursor,
+		},
+	}
+}
+
+func handlePromptGet(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse {
+	paramsBytes, err := json.Marshal(req.Params)
+	if err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid params")
+	}
+
+	var params PromptGetParams
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid prompt params: "+err.Error())
+	}
+	if params.Name == "" {
+		return jsonRPCError(req.ID, -32602, "Missing prompt name")
+	}
+
+	result, err := RenderPrompt(toolCtx, params.Name, params.Arguments)
+	if err != nil {
+		return jsonRPCError(req.ID, -32602, err.Error())
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+// parseInitializeParams decodes the initialize params. Malformed params are treated as empty,
+// so the handshake falls back to the defaults instead of failing.
+func parseInitializeParams(rawParams interface{}) InitializeParams {
+	var params InitializeParams
+	paramsBytes, err := json.Marshal(rawParams)
+	if err != nil {
+		return params
+	}
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return InitializeParams{}
+	}
+	return params
+}
+
+// clientAcceptsJSONContent reports whether the client advertised the JSONContentCapability
+// experimental capability. Any value but an explicit false counts as support.
+func clientAcceptsJSONContent(params InitializeParams) bool {
+	value, ok := params.Capabilities.Experimental[JSONContentCapability]
+	if !ok {
+		return false
+	}
+	enabled, isBool := value.(bool)
+	return !isBool || enabled
+}
+
+// negotiateProtocolVersion returns the protocol version requested in the initialize params
+// when the server supports it, and the server's latest supported version otherwise.
+func negotiateProtocolVersion(params InitializeParams) string {
+	if slices.Contains(supportedProtocolVersions, params.ProtocolVersion) {
+		return params.ProtocolVersion
+	}
+	return MCPProtocolVersion
+}
+
+func handleSetLevel(req *JSONRPCRequest, toolCtx *ToolContext) *JSONRPCResponse {
+	paramsBytes, err := json.Marshal(req.Params)
+	if err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid params")
+	}
+
+	var params SetLevelParams
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return jsonRPCError(req.ID, -32602, "Invalid logging/setLevel params: "+err.Error())
+	}
+	if !isValidLogLevel(params.Level) {
+		return jsonRPCError(req.ID, -32602, fmt.Sprintf("Invalid log level: %q", params.Level))
+	}
+
+	// without an SSE session there is nowhere to send log notifications to
+	if toolCtx.Session != nil {
+		toolCtx.Session.SetLogLevel(params.Level)
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
 func jsonRPCError(id interface{}, code int, message string) *JSONRPCResponse {
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/test"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,6 +60,38 @@ func TestHandleJSONRPC_Initialize(t *testing.T) {
 	assert.Equal(t, MCPProtocolVersion, result.ProtocolVersion)
 	assert.Equal(t, "Test Server", result.ServerInfo.Name)
 	assert.NotNil(t, result.Capabilities.Tools)
+	assert.Equal(t, "A test server", result.Instructions)
+
+	ctx.Config.Server.Instructions = "Look up organizations by NMR with find_by_attribute."
+	result, ok = HandleJSONRPC(req, ctx).Result.(InitializeResult)
+	require.True(t, ok)
+	assert.Equal(t, "Look up organizations by NMR with find_by_attribute.", result.Instructions)
+}
+
+func TestHandleJSONRPC_InitializeProtocolVersion(t *testing.T) {
+	ctx := newTestToolContext()
+	initialize := func(requested string) string {
+		req := &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      float64(1),
+			Method:  "initialize",
+			Params:  map[string]interface{}{"protocolVersion": requested},
+		}
+		resp := HandleJSONRPC(req, ctx)
+		require.NotNil(t, resp)
+		require.Nil(t, resp.Error)
+		result, ok := resp.Result.(InitializeResult)
+		require.True(t, ok)
+		return result.ProtocolVersion
+	}
+
+	// supported versions are echoed back
+	assert.Equal(t, "2024-11-05", initialize("2024-11-05"))
+	assert.Equal(t, MCPProtocolVersion, initialize(MCPProtocolVersion))
+
+	// unsupported or missing versions get the server's latest
+	assert.Equal(t, MCPProtocolVersion, initialize("1999-01-01"))
+	assert.Equal(t, MCPProtocolVersion, initialize(""))
 }
 
 func TestHandleJSONRPC_InitializeCapabilitiesJSON(t *testing.T) {
@@ -104,7 +137,7 @@ func TestHandleJSONRPC_ToolsList(t *testing.T) {
 
 	result, ok := resp.Result.(ToolListResult)
 	require.True(t, ok)
-	assert.Equal(t, 8, len(result.Tools))
+	assert.Equal(t, 13, len(result.Tools))
 
 	// Verify tool names
 	toolNames := make(map[string]bool)
@@ -117,8 +150,37 @@ func TestHandleJSONRPC_ToolsList(t *testing.T) {
 	assert.True(t, toolNames["search"])
 	assert.True(t, toolNames["get_entity"])
 	assert.True(t, toolNames["list_entities"])
+	assert.True(t, toolNames["find_by_attribute"])
+	assert.True(t, toolNames["list_sources"])
+	assert.True(t, toolNames["read_source"])
 	assert.True(t, toolNames["validate"])
 	assert.True(t, toolNames["generate_document"])
+	assert.True(t, toolNames["diff"])
+}
+
+func TestHandleJSONRPC_ToolsListDisabledTools(t *testing.T) {
+	toolNames := func(ctx *ToolContext) []string {
+		resp := HandleJSONRPC(&JSONRPCRequest{JSONRPC: "2.0", ID: float64(2), Method: "tools/list"}, ctx)
+		require.NotNil(t, resp)
+		result, ok := resp.Result.(ToolListResult)
+		require.True(t, ok)
+		names := make([]string, 0, len(result.Tools))
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	ctx := newTestToolContext()
+	ctx.Config.DisabledTools = []string{"generate_document", "validate"}
+	names := toolNames(ctx)
+	assert.Len(t, names, 11)
+	assert.NotContains(t, names, "generate_document")
+	assert.NotContains(t, names, "validate")
+
+	// disabled_tools wins over enabled_tools
+	ctx.Config.EnabledTools = []string{"help", "search", "get_entity", "validate"}
+	assert.Equal(t, []string{"help", "search", "get_entity"}, toolNames(ctx))
 }
 
 func TestHandleJSONRPC_ToolsCall(t *testing.T) {
@@ -139,6 +201,86 @@ func TestHandleJSONRPC_ToolsCall(t *testing.T) {
 	assert.NotNil(t, resp.Result)
 }
 
+func TestHandleJSONRPC_ToolsCallJSONContent(t *testing.T) {
+	initializeAndCall := func(t *testing.T, ctx *ToolContext, capabilities map[string]interface{}) ToolContent {
+		resp := HandleJSONRPC(&JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      float64(1),
+			Method:  "initialize",
+			Params:  map[string]interface{}{"capabilities": capabilities},
+		}, ctx)
+		require.NotNil(t, resp)
+		require.Nil(t, resp.Error)
+		result, ok := resp.Result.(InitializeResult)
+		require.True(t, ok)
+		assert.Contains(t, result.Capabilities.Experimental, JSONContentCapability)
+
+		resp = HandleJSONRPC(&JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      float64(2),
+			Method:  "tools/call",
+			Params: map[string]interface{}{
+				"name":      "get_entity",
+				"arguments": map[string]interface{}{"id": "item:01"},
+			},
+		}, ctx)
+		require.NotNil(t, resp)
+		require.Nil(t, resp.Error)
+		toolResult, ok := resp.Result.(*ToolCallResult)
+		require.True(t, ok)
+		require.Len(t, toolResult.Content, 1)
+		return toolResult.Content[0]
+	}
+	jsonContent := map[string]interface{}{"experimental": map[string]interface{}{JSONContentCapability: map[string]interface{}{}}}
+
+	t.Run("Text", func(t *testing.T) {
+		// without a session the capability can't be remembered between requests
+		content := initializeAndCall(t, newTestToolContext(), jsonContent)
+		assert.Equal(t, ContentTypeText, content.Type)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(content.Text), &decoded))
+		assert.Equal(t, "Test Item", decoded["name"])
+
+		ctx := newTestToolContext()
+		ctx.Session = &SSESession{}
+		content = initializeAndCall(t, ctx, nil)
+		assert.Equal(t, ContentTypeText, content.Type)
+
+		ctx.Session = &SSESession{}
+		content = initializeAndCall(t, ctx, map[string]interface{}{"experimental": map[string]interface{}{JSONContentCapability: false}})
+		assert.Equal(t, ContentTypeText, content.Type)
+
+		data, err := json.Marshal(content)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), `"json"`)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		ctx := newTestToolContext()
+		ctx.Session = &SSESession{}
+		content := initializeAndCall(t, ctx, jsonContent)
+		assert.Equal(t, ContentTypeJSON, content.Type)
+		assert.Empty(t, content.Text)
+		decoded, ok := content.JSON.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "Test Item", decoded["name"])
+
+		data, err := json.Marshal(content)
+		require.NoError(t, err)
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &raw))
+		assert.Equal(t, "json", raw["type"])
+		assert.NotContains(t, raw, "text")
+		assert.IsType(t, map[string]interface{}{}, raw["json"])
+
+		// errors stay text so clients can read them the same way in both modes
+		result, err := ExecuteTool(ctx, "get_entity", map[string]interface{}{"id": "missing"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Equal(t, ContentTypeText, result.Content[0].Type)
+	})
+}
+
 func TestHandleJSONRPC_Ping(t *testing.T) {
 	ctx := newTestToolContext()
 	req := &JSONRPCRequest{
@@ -199,6 +341,30 @@ func TestHandleJSONRPC_ToolsCallUnknownTool(t *testing.T) {
 	assert.True(t, result.IsError)
 }
 
+func TestHandleJSONRPC_ToolsCallDisabledTool(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Config.EnabledTools = []string{"help", "search"}
+	req := &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(5),
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "generate_document", "arguments": map[string]interface{}{}},
+	}
+
+	resp := HandleJSONRPC(req, ctx)
+	require.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	result, ok := resp.Result.(*ToolCallResult)
+	require.True(t, ok)
+	toolErr := requireToolError(t, result)
+	assert.Equal(t, ToolErrorToolDisabled, toolErr.Code)
+	assert.Contains(t, toolErr.Message, "generate_document")
+
+	result, err := ExecuteTool(ctx, "search", map[string]interface{}{"query": "Test"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
+
 func TestHandleJSONRPC_ToolsCallMissingName(t *testing.T) {
 	ctx := newTestToolContext()
 	req := &JSONRPCRequest{
@@ -215,3 +381,289 @@ func TestHandleJSONRPC_ToolsCallMissingName(t *testing.T) {
 	assert.NotNil(t, resp.Error)
 	assert.Equal(t, -32602, resp.Error.Code)
 }
+
+func TestHandleJSONRPC_ToolsCallInvalidArguments(t *testing.T) {
+	call := func(name string, args map[string]interface{}) *JSONRPCResponse {
+		return HandleJSONRPC(&JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      float64(8),
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": name, "arguments": args},
+		}, newTestToolContext())
+	}
+
+	t.Run("MissingRequired", func(t *testing.T) {
+		resp := call("search", map[string]interface{}{"limit": float64(5)})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Equal(t, "Invalid arguments for tool search: 'query' is required", resp.Error.Message)
+		assert.Nil(t, resp.Result)
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		resp := call("search", map[string]interface{}{"query": "test", "limit": "ten"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Equal(t, "Invalid arguments for tool search: 'limit' must be of type number", resp.Error.Message)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		resp := call("search", map[string]interface{}{"query": "test", "limit": float64(5), "extra": true})
+		assert.Nil(t, resp.Error)
+		assert.NotNil(t, resp.Result)
+	})
+
+	t.Run("ArgumentsOmitted", func(t *testing.T) {
+		callWithoutArgs := func(name string) *JSONRPCResponse {
+			return HandleJSONRPC(&JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      float64(9),
+				Method:  "tools/call",
+				Params:  map[string]interface{}{"name": name},
+			}, newTestToolContext())
+		}
+
+		resp := callWithoutArgs("search")
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Equal(t, "Invalid arguments for tool search: no arguments given, 'query' is required", resp.Error.Message)
+
+		resp = callWithoutArgs("help")
+		assert.Nil(t, resp.Error)
+		assert.NotNil(t, resp.Result)
+	})
+}
+
+func TestHandleJSONRPC_LoggingSetLevel(t *testing.T) {
+	ctx := newTestToolContext()
+
+	resp := HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(8),
+		Method:  "logging/setLevel",
+		Params:  map[string]interface{}{"level": "debug"},
+	}, ctx)
+	require.NotNil(t, resp)
+	assert.Nil(t, resp.Error, "setLevel without an SSE session is a successful no-op")
+
+	resp = HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(9),
+		Method:  "logging/setLevel",
+		Params:  map[string]interface{}{"level": "verbose"},
+	}, ctx)
+	require.NotNil(t, resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, -32602, resp.Error.Code)
+}
+
+func TestLogLevelEnabled(t *testing.T) {
+	assert.True(t, logLevelEnabled("info", "error"))
+	assert.True(t, logLevelEnabled("warning", "warning"))
+	assert.False(t, logLevelEnabled("error", "warning"))
+}
+
+func TestHandleJSONRPC_PromptsList(t *testing.T) {
+	ctx := newTestToolContext()
+	resp := HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(10),
+		Method:  "prompts/list",
+	}, ctx)
+	require.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+
+	result, ok := resp.Result.(PromptListResult)
+	require.True(t, ok)
+	names := make(map[string]bool)
+	for _, p := range result.Prompts {
+		names[p.Name] = true
+	}
+	assert.True(t, names["summarize_entity"])
+	assert.True(t, names["find_duplicates"])
+	assert.True(t, names["explore_register"])
+}
+
+func TestHandleJSONRPC_PromptsListPagination(t *testing.T) {
+	defer test.MockVariableValue(&listPageSize, 2)()
+	ctx := newTestToolContext()
+
+	list := func(params interface{}) *JSONRPCResponse {
+		return HandleJSONRPC(&JSONRPCRequest{JSONRPC: "2.0", ID: float64(10), Method: "prompts/list", Params: params}, ctx)
+	}
+
+	var names []string
+	var cursors []string
+	resp := list(nil)
+	for {
+		require.Nil(t, resp.Error)
+		result := resp.Result.(PromptListResult)
+		require.LessOrEqual(t, len(result.Prompts), 2)
+		for _, p := range result.Prompts {
+			names = append(names, p.Name)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursors = append(cursors, result.NextCursor)
+		resp = list(map[string]interface{}{"cursor": result.NextCursor})
+	}
+	assert.Equal(t, []string{"explore_register", "find_duplicates", "summarize_entity"}, names)
+	assert.Len(t, cursors, 1)
+
+	// the same cursor returns the same page
+	again := list(map[string]interface{}{"cursor": cursors[0]})
+	require.Nil(t, again.Error)
+	assert.Equal(t, resp.Result, again.Result)
+
+	for _, cursor := range []string{"not base64!", "eHl6", "OTk"} {
+		resp := list(map[string]interface{}{"cursor": cursor})
+		require.NotNil(t, resp.Error, cursor)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Equal(t, "Invalid params: invalid cursor", resp.Error.Message)
+	}
+}
+
+func TestHandleJSONRPC_PromptsGet(t *testing.T) {
+	ctx := newTestToolContext()
+	resp := HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(11),
+		Method:  "prompts/get",
+		Params: map[string]interface{}{
+			"name":      "summarize_entity",
+			"arguments": map[string]interface{}{"id": "item:01"},
+		},
+	}, ctx)
+	require.NotNil(t, resp)
+	require.Nil(t, resp.Error)
+
+	result, ok := resp.Result.(*PromptGetResult)
+	require.True(t, ok)
+	require.Len(t, result.Messages, 1)
+	text := result.Messages[0].Content.Text
+	assert.Contains(t, text, "Summarize the entity item:01 from 'Test Server'")
+	assert.Contains(t, text, `"name": "Test Item"`)
+	assert.NotContains(t, text, "{{")
+
+	// the id is trimmed before the lookup, as for the validation
+	resp = HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(13),
+		Method:  "prompts/get",
+		Params: map[string]interface{}{
+			"name":      "summarize_entity",
+			"arguments": map[string]interface{}{"id": " item:01 "},
+		},
+	}, ctx)
+	require.NotNil(t, resp)
+	require.Nil(t, resp.Error)
+	result, ok = resp.Result.(*PromptGetResult)
+	require.True(t, ok)
+	assert.Contains(t, result.Messages[0].Content.Text, `"name": "Test Item"`)
+
+	// missing required argument
+	resp = HandleJSONRPC(&JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      float64(12),
+		Method:  "prompts/get",
+		Params:  map[string]interface{}{"name": "find_duplicates"},
+	}, ctx)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, -32602, resp.Error.Code)
+}
+
+func TestToolDescribeModel_Example(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index.Entities["item:00"] = &Entity{
+		ID:         "item:00",
+		Type:       "item",
+		Name:       "First Item",
+		Attributes: map[string]string{"code": "00"},
+	}
+	ctx.Index.ByType["item"] = append(ctx.Index.ByType["item"], "item:00")
+	ctx.Index.Stats.TypeCounts["item"]++
+
+	result, err := toolDescribeModel(ctx, nil)
+	require.NoError(t, err)
+
+	var model struct {
+		EntityTypes []struct {
+			Type    string `json:"type"`
+			Count   int    `json:"count"`
+			Example struct {
+				ID         string            `json:"id"`
+				Name       string            `json:"name"`
+				Attributes map[string]string `json:"attributes"`
+			} `json:"example"`
+		} `json:"entity_types"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &model))
+	require.Len(t, model.EntityTypes, 1)
+	assert.Equal(t, 2, model.EntityTypes[0].Count)
+	assert.Equal(t, "item:00", model.EntityTypes[0].Example.ID)
+	assert.Equal(t, "First Item", model.EntityTypes[0].Example.Name)
+	assert.Equal(t, "00", model.EntityTypes[0].Example.Attributes["code"])
+}
+
+func TestToolDescribeModel_TopLevelTypes(t *testing.T) {
+	ctx := newCountTestToolContext()
+	ctx.Index.Stats.TypeCounts = map[string]int{"ministry": 2, "organization": 3, "unit": 1}
+
+	result, err := toolDescribeModel(ctx, nil)
+	require.NoError(t, err)
+
+	var model struct {
+		EntityTypes []struct {
+			Type       string `json:"type"`
+			IsRoot     bool   `json:"is_root"`
+			ParentType string `json:"parent_type"`
+		} `json:"entity_types"`
+		TopLevelTypes []string `json:"top_level_types"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &model))
+	assert.Equal(t, []string{"ministry"}, model.TopLevelTypes)
+	require.Len(t, model.EntityTypes, 3)
+	assert.Equal(t, "ministry", model.EntityTypes[0].Type)
+	assert.True(t, model.EntityTypes[0].IsRoot)
+	assert.Equal(t, "organization", model.EntityTypes[1].Type)
+	assert.False(t, model.EntityTypes[1].IsRoot)
+	assert.Equal(t, "ministry", model.EntityTypes[1].ParentType)
+	assert.False(t, model.EntityTypes[2].IsRoot)
+}
+
+func TestToolDescribeModel_Deterministic(t *testing.T) {
+	ctx := newTestToolContext()
+	for _, typeName := range []string{"zeta", "alpha", "mid"} {
+		for _, code := range []string{"3", "1", "2"} {
+			id := typeName + ":" + code
+			ctx.Index.Entities[id] = &Entity{
+				ID:         id,
+				Type:       typeName,
+				Attributes: map[string]string{"code": code, "z": "1", "a": "2", "m": "3"},
+			}
+			ctx.Index.ByType[typeName] = append(ctx.Index.ByType[typeName], id)
+			ctx.Index.Stats.TypeCounts[typeName]++
+		}
+	}
+
+	first, err := toolDescribeModel(ctx, nil)
+	require.NoError(t, err)
+	for range 10 {
+		again, err := toolDescribeModel(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, first.Content[0].Text, again.Content[0].Text)
+	}
+
+	var model struct {
+		EntityTypes []struct {
+			Type       string   `json:"type"`
+			Attributes []string `json:"attributes"`
+		} `json:"entity_types"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(first.Content[0].Text), &model))
+	require.Len(t, model.EntityTypes, 4)
+	assert.Equal(t, "alpha", model.EntityTypes[0].Type)
+	assert.Equal(t, "zeta", model.EntityTypes[3].Type)
+	assert.Equal(t, []string{"a", "code", "m", "z"}, model.EntityTypes[0].Attributes)
+}
@@ -10,33 +10,37 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 )
 
 const (
-	// sseKeepaliveInterval is how often to send keepalive comments.
-	sseKeepaliveInterval = 30 * time.Second
-
-	// maxSessions limits the number of concurrent SSE sessions.
-	maxSessions = 100
-
 	// sessionRequestBuffer is the channel buffer size for incoming requests.
 	sessionRequestBuffer = 16
+
+	// maxSweepInterval caps how often idle sessions are looked for.
+	maxSweepInterval = time.Minute
 )
 
 // SSESession represents an active SSE connection with a client.
 type SSESession struct {
-	ID      string
-	Writer  http.ResponseWriter
-	Flusher http.Flusher
-	ToolCtx *ToolContext
-	reqCh   chan *JSONRPCRequest
-	done    chan struct{}
-	mu      sync.Mutex
-	closed  bool
+	ID       string
+	Writer   http.ResponseWriter
+	Flusher  http.Flusher
+	ToolCtx  *ToolContext
+	reqCh    chan *JSONRPCRequest
+	done     chan struct{}
+	mu       sync.Mutex
+	closed   bool
+	logLevel string
+	// jsonContent is set when the client accepted JSON content blocks during initialize
+	jsonContent bool
+
+	lastActivity atomic.Int64 // unix nanoseconds
 }
 
 // SSESessionManager tracks active SSE sessions.
@@ -50,11 +54,13 @@ var sessionManager = &SSESessionManager{
 	sessions: make(map[string]*SSESession),
 }
 
-// Register adds a session to the manager. Returns false if at capacity.
+var sessionSweeperOnce sync.Once
+
+// Register adds a session to the manager. Returns false if at capacity ([mcp] MAX_SSE_SESSIONS).
 func (m *SSESessionManager) Register(s *SSESession) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if len(m.sessions) >= maxSessions {
+	if len(m.sessions) >= setting.MCP.MaxSSESessions {
 		return false
 	}
 	m.sessions[s.ID] = s
@@ -75,6 +81,82 @@ func (m *SSESessionManager) Get(id string) *SSESession {
 	return m.sessions[id]
 }
 
+// ReapIdle unregisters and closes every session without activity for longer than idleTimeout.
+// It returns the number of reaped sessions.
+func (m *SSESessionManager) ReapIdle(idleTimeout time.Duration) int {
+	cutoff := time.Now().Add(-idleTimeout)
+
+	m.mu.Lock()
+	var idle []*SSESession
+	for id, s := range m.sessions {
+		if s.LastActivity().Before(cutoff) {
+			idle = append(idle, s)
+			delete(m.sessions, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range idle {
+		log.Info("MCP SSE: reaping session %s idle since %s", s.ID, s.LastActivity().Format(time.RFC3339))
+		s.Close()
+	}
+	return len(idle)
+}
+
+// startSessionSweeper starts the background goroutine reaping idle sessions of the global manager.
+// It is a no-op when the idle timeout is disabled.
+func startSessionSweeper() {
+	idleTimeout := setting.MCP.SessionIdleTimeout
+	if idleTimeout <= 0 {
+		return
+	}
+	sessionSweeperOnce.Do(func() {
+		interval := min(idleTimeout/2, maxSweepInterval)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				sessionManager.ReapIdle(idleTimeout)
+			}
+		}()
+	})
+}
+
+// touch records activity on the session.
+func (s *SSESession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns when the session last processed a request or sent a keepalive.
+func (s *SSESession) LastActivity() time.Time {
+	return time.Unix(0, s.lastActivity.Load())
+}
+
+// Close marks the session closed and stops its event loop. It is safe to call more than once.
+func (s *SSESession) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+}
+
+// SetJSONContent sets whether tool results are sent to the session as JSON content blocks.
+func (s *SSESession) SetJSONContent(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jsonContent = enabled
+}
+
+// JSONContent reports whether the client of the session accepts JSON content blocks.
+func (s *SSESession) JSONContent() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jsonContent
+}
+
 // SendRequest sends a JSON-RPC request to the session for processing.
 // Returns false if the session is closed or the channel is full.
 func (s *SSESession) SendRequest(req *JSONRPCRequest) bool {
@@ -91,6 +173,17 @@ func (s *SSESession) SendRequest(req *JSONRPCRequest) bool {
 	}
 }
 
+// setSSEHeaders sets the response and CORS headers of an SSE stream.
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id")
+}
+
 // serveSSE handles a GET request to establish an SSE streaming connection.
 func serveSSE(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	// Validate Accept header
@@ -118,31 +211,29 @@ func serveSSE(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 		ID:      sessionID,
 		Writer:  w,
 		Flusher: flusher,
-		ToolCtx: toolCtx,
 		reqCh:   make(chan *JSONRPCRequest, sessionRequestBuffer),
 		done:    make(chan struct{}),
 	}
+	session.touch()
+
+	// give tools access to the session so they can push notifications to the client
+	sessionToolCtx := *toolCtx
+	sessionToolCtx.Session = session
+	session.ToolCtx = &sessionToolCtx
+	toolCtx = &sessionToolCtx
 
+	startSessionSweeper()
 	if !sessionManager.Register(session) {
 		http.Error(w, "Too many active SSE sessions", http.StatusServiceUnavailable)
 		return
 	}
 	defer func() {
-		session.mu.Lock()
-		session.closed = true
-		session.mu.Unlock()
+		session.Close()
 		sessionManager.Unregister(sessionID)
 		log.Info("MCP SSE: session %s closed", sessionID)
 	}()
 
-	// Set SSE response headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Mcp-Session-Id")
+	setSSEHeaders(w)
 	w.Header().Set("Mcp-Session-Id", sessionID)
 
 	log.Info("MCP SSE: session %s started for repo %d from %s", sessionID, toolCtx.RepoID, r.RemoteAddr)
@@ -157,7 +248,7 @@ func serveSSE(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	}
 
 	// Event loop: process incoming requests and send keepalives
-	ticker := time.NewTicker(sseKeepaliveInterval)
+	ticker := time.NewTicker(setting.MCP.SSEKeepaliveInterval)
 	defer ticker.Stop()
 
 	ctx := r.Context()
@@ -165,7 +256,10 @@ func serveSSE(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-session.done:
+			return
 		case req := <-session.reqCh:
+			session.touch()
 			resp := HandleJSONRPC(req, toolCtx)
 			if resp != nil {
 				if err := writeSSEEvent(w, flusher, "message", resp); err != nil {
@@ -178,6 +272,7 @@ func serveSSE(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 				log.Error("MCP SSE: keepalive failed for session %s: %v", sessionID, err)
 				return
 			}
+			session.touch()
 		}
 	}
 }
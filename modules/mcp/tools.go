@@ -4,10 +4,13 @@
 package mcp
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
 )
 
 // ToolContext holds everything a tool needs to execute.
@@ -16,6 +19,19 @@ type ToolContext struct {
 	Commit *git.Commit
 	RepoID int64
 	Index  *EntityIndex
+
+	// GitRepo is the repository the index was built from; tools that compare refs need it.
+	GitRepo *git.Repository
+
+	// ExportURL is the URL of the document export endpoint, offered when a generated
+	// document is too large to return inline. Empty if unavailable.
+	ExportURL string
+
+	// Session is the SSE session the request arrived on, nil for plain POST requests.
+	Session *SSESession
+
+	// progressToken is the _meta.progressToken of the tool call being executed, if any.
+	progressToken interface{}
 }
 
 // ToolHandler is a function that executes a tool and returns a result.
@@ -30,17 +46,83 @@ func init() {
 	toolRegistry = map[string]ToolHandler{
 		"help":              toolHelp,
 		"identify":          toolIdentify,
-		"describe_model":    toolDescribeModel,
-		"search":            toolSearch,
-		"get_entity":        toolGetEntity,
-		"list_entities":     toolListEntities,
+		"describe_model":    requireIndexedData(toolDescribeModel),
+		"search":            requireIndexedData(toolSearch),
+		"get_entity":        requireIndexedData(toolGetEntity),
+		"list_entities":     requireIndexedData(toolListEntities),
+		"count":             requireIndexedData(toolCount),
+		"find_by_attribute": requireIndexedData(toolFindByAttribute),
+		"list_sources":      toolListSources,
+		"read_source":       toolReadSource,
 		"validate":          toolValidate,
-		"generate_document": toolGenerateDocument,
+		"generate_document": requireIndexedData(toolGenerateDocument),
+		"diff":              toolDiff,
 	}
 }
 
-// GetToolDefinitions returns the MCP tool definitions for tools/list.
+// Codes of ToolError, stable for agents to branch on.
+const (
+	ToolErrorUnknownTool           = "unknown_tool"
+	ToolErrorMissingArgument       = "missing_argument"
+	ToolErrorUnknownFormat         = "unknown_format"
+	ToolErrorUnknownType           = "unknown_type"
+	ToolErrorNotFound              = "not_found"
+	ToolErrorAmbiguous             = "ambiguous"
+	ToolErrorNoIndexedData         = "no_indexed_data"
+	ToolErrorDocumentTooLarge      = "document_too_large"
+	ToolErrorInvalidRef            = "invalid_ref"
+	ToolErrorRepositoryUnavailable = "repository_unavailable"
+	ToolErrorSourceUnreadable      = "source_unreadable"
+	ToolErrorUnknownSource         = "unknown_source"
+	ToolErrorToolDisabled          = "tool_disabled"
+)
+
+// ErrNoIndexedData is returned by entity-backed tools when the index is missing or empty.
+var ErrNoIndexedData = errors.New("no data indexed for this repository")
+
+// HasIndexedData reports whether the context has an index with at least one entity.
+func (ctx *ToolContext) HasIndexedData() bool {
+	return ctx.Index != nil && len(ctx.Index.Entities) > 0
+}
+
+// resultLimit returns the "limit" argument of a tool, or def when it is absent or not positive,
+// capped at [mcp] MAX_RESULT_LIMIT.
+func resultLimit(args map[string]interface{}, def int) int {
+	maxLimit := setting.MCP.MaxResultLimit
+	l, ok := args["limit"].(float64)
+	switch {
+	case !ok || !(l > 0):
+		return min(def, maxLimit)
+	case l >= float64(maxLimit):
+		// compared before converting, a huge value would overflow int
+		return maxLimit
+	default:
+		return max(int(l), 1)
+	}
+}
+
+// requireIndexedData wraps an entity-backed tool so that it returns a tool-level error
+// instead of dereferencing a nil index or answering from an empty one.
+func requireIndexedData(handler ToolHandler) ToolHandler {
+	return func(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+		if !ctx.HasIndexedData() {
+			return toolError(ToolErrorNoIndexedData, ErrNoIndexedData.Error()), nil
+		}
+		return handler(ctx, args)
+	}
+}
+
+// GetToolDefinitions returns the MCP tool definitions for tools/list, leaving out the tools
+// the config disables.
 func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
+	defs := allToolDefinitions(cfg)
+	if cfg == nil {
+		return defs
+	}
+	return slices.DeleteFunc(defs, func(def ToolDefinition) bool { return !cfg.ToolEnabled(def.Name) })
+}
+
+func allToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 	return []ToolDefinition{
 		{
 			Name:        "help",
@@ -61,6 +143,7 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 		{
 			Name: "describe_model",
 			Description: "Returns the data model: entity types, their attributes, hierarchy, and counts. " +
+				"top_level_types and the per-type is_root flag name the root types to start navigating from. " +
 				"Use this to understand what data is available before searching or listing.",
 			InputSchema: map[string]interface{}{
 				"type":       "object",
@@ -71,7 +154,7 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 			Name: "search",
 			Description: fmt.Sprintf(
 				"Full-text search across all entities in '%s'. Searches by name, code, registration number (NMR), "+
-					"document prefix, or any attribute value. Returns matching entities with full details.",
+					"document prefix, or any attribute value. Returns matching entities with full details and the path of their ancestors.",
 				cfg.Server.Name,
 			),
 			InputSchema: map[string]interface{}{
@@ -80,33 +163,43 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 				"properties": map[string]interface{}{
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Search query — entity name, code number, registration number (NMR), or any attribute value",
+						"description": "Search query — entity name, code number, registration number (NMR), or any attribute value. Use a field:value prefix such as name:, code:, nmr: or id: to match a single field",
 					},
 					"limit": map[string]interface{}{
 						"type":        "number",
-						"description": "Maximum results to return (default 25, max 100)",
+						"description": "Maximum results to return (default 25, capped by the server; the applied limit is returned)",
+					},
+					"include_path": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Add to each result a 'path' listing its ancestors (id and name) from the root down to its parent (default true)",
 					},
 				},
 			},
 		},
 		{
 			Name:        "get_entity",
-			Description: "Retrieve full details of a specific entity by its ID. Entity IDs are formatted as 'type:code', e.g., 'ministry:01', 'organization:0001'. Use list_entities or search to discover IDs.",
+			Description: "Retrieve full details of a specific entity by its ID. Entity IDs are formatted as 'type:code', e.g., 'ministry:01', 'organization:0001'; a colon in the code is escaped as %3A and a percent sign as %25. If the value is not a known ID, a unique entity with a matching 'code' or 'nmr' attribute is returned. Use list_entities or search to discover IDs.",
 			InputSchema: map[string]interface{}{
 				"type":     "object",
 				"required": []string{"id"},
 				"properties": map[string]interface{}{
 					"id": map[string]interface{}{
 						"type":        "string",
-						"description": "Entity ID in 'type:code' format, e.g., 'ministry:01' or 'organization:0001'",
+						"description": "Entity ID in 'type:code' format, e.g., 'ministry:01' or 'organization:0001', or a bare code or registration number (NMR)",
+					},
+					"depth": map[string]interface{}{
+						"type":        "number",
+						"description": "Levels of descendants to embed in 'children' (default 1 for direct children only, max 10)",
 					},
 				},
 			},
 		},
 		{
 			Name: "list_entities",
-			Description: "List all entities, optionally filtered by type and/or parent. " +
-				"Useful for getting all ministries, or all organizations under a specific ministry.",
+			Description: "List entities sorted by ID, optionally filtered by type and/or parent. " +
+				"Useful for getting all ministries, or all organizations under a specific ministry. " +
+				"Results are paged; use limit and offset together with the returned total to fetch more. " +
+				"Each entity has a children_count of its direct children, to see which are worth listing with parent.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -118,18 +211,97 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 						"type":        "string",
 						"description": "Filter by parent entity ID, e.g., 'ministry:13' to list only organizations under that ministry",
 					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum entities to return (default 100, capped by the server; the applied limit is returned)",
+					},
+					"offset": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of entities to skip, for paging through large results (default 0)",
+					},
 				},
 			},
 		},
 		{
-			Name: "validate",
-			Description: "Validate the XML data source against its schema. Returns validation status, " +
-				"any errors found, and data statistics (entity counts).",
+			Name: "count",
+			Description: "Count entities, optionally filtered by type and/or parent, without fetching them. " +
+				"Returns the matching count and the total number of entities, e.g. how many organizations are under 'ministry:13'.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Count only entities of this type, e.g., 'organization'",
+					},
+					"parent": map[string]interface{}{
+						"type":        "string",
+						"description": "Count only children of this parent entity ID, e.g., 'ministry:13'",
+					},
+				},
+			},
+		},
+		{
+			Name: "find_by_attribute",
+			Description: "Find all entities whose attribute exactly equals a value, e.g. the organization with a given registration number (NMR). " +
+				"Unlike search, this matches the whole value of a single attribute and never returns partial matches.",
+			InputSchema: map[string]interface{}{
+				"type":     "object",
+				"required": []string{"attribute", "value"},
+				"properties": map[string]interface{}{
+					"attribute": map[string]interface{}{
+						"type":        "string",
+						"description": "Attribute name, e.g., 'nmr' or 'code'",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Exact attribute value to look for, e.g., '90000038578'",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return entities of this type, e.g., 'organization'",
+					},
+				},
+			},
+		},
+		{
+			Name: "list_sources",
+			Description: "List the configured data sources with their path, type, description and declared schema, " +
+				"and whether the schema file exists in the repository. Use it to see which sources validate checks against a schema.",
 			InputSchema: map[string]interface{}{
 				"type":       "object",
 				"properties": map[string]interface{}{},
 			},
 		},
+		{
+			Name: "read_source",
+			Description: "Return the raw content of a configured source file, e.g. to quote an exact XML element. " +
+				"The path must be one of the sources listed by list_sources; files larger than 1 MB are not returned.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the source as listed by list_sources, e.g., 'data/register.xml'",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name: "validate",
+			Description: "Validate the XML data source against its schema. Returns validation status, " +
+				"any errors found, and data statistics (entity counts and, per type, how many entities have each attribute). " +
+				"Pass source to validate only one configured source; duplicate checks and completeness still cover all sources.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the source to validate, as listed by list_sources. Omit to validate every source.",
+					},
+				},
+			},
+		},
 		{
 			Name: "generate_document",
 			Description: "Generate a formatted Markdown document (table) of the register contents. " +
@@ -154,6 +326,26 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 				},
 			},
 		},
+		{
+			Name: "diff",
+			Description: "Compare the register at two git refs (branches, tags or commit SHAs). " +
+				"Returns entities added, removed and modified between base_ref and head_ref, " +
+				"with per-field old and new values for modified entities.",
+			InputSchema: map[string]interface{}{
+				"type":     "object",
+				"required": []string{"base_ref", "head_ref"},
+				"properties": map[string]interface{}{
+					"base_ref": map[string]interface{}{
+						"type":        "string",
+						"description": "The older ref to compare from, e.g., 'main~1', a tag or a commit SHA",
+					},
+					"head_ref": map[string]interface{}{
+						"type":        "string",
+						"description": "The newer ref to compare to, e.g., 'main'",
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -161,10 +353,13 @@ func GetToolDefinitions(cfg *MCPConfig) []ToolDefinition {
 func ExecuteTool(ctx *ToolContext, name string, args map[string]interface{}) (*ToolCallResult, error) {
 	handler, ok := toolRegistry[name]
 	if !ok {
-		return &ToolCallResult{
-			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Unknown tool: %s", name)}},
-			IsError: true,
-		}, nil
+		return toolError(ToolErrorUnknownTool, fmt.Sprintf("Unknown tool: %s", name)), nil
+	}
+	if ctx.Config != nil && !ctx.Config.ToolEnabled(name) {
+		return toolError(ToolErrorToolDisabled, fmt.Sprintf("Tool %s is disabled on this server.", name)), nil
+	}
+	if args == nil {
+		args = map[string]interface{}{}
 	}
 	return handler(ctx, args)
 }
@@ -172,15 +367,35 @@ func ExecuteTool(ctx *ToolContext, name string, args map[string]interface{}) (*T
 // textResult is a helper to return a simple text result.
 func textResult(text string) *ToolCallResult {
 	return &ToolCallResult{
-		Content: []ToolContent{{Type: "text", Text: text}},
+		Content: []ToolContent{{Type: ContentTypeText, Text: text}},
+	}
+}
+
+// toolError returns a failed tool result carrying a ToolError with the given code and message.
+func toolError(code, msg string) *ToolCallResult {
+	data, _ := json.Marshal(map[string]ToolError{"error": {Code: code, Message: msg}})
+	return &ToolCallResult{
+		Content: []ToolContent{{Type: ContentTypeText, Text: string(data)}},
+		IsError: true,
 	}
 }
 
-// jsonTextResult marshals data to JSON and returns it as text content.
-func jsonTextResult(data interface{}) (*ToolCallResult, error) {
+// jsonTextResult returns data as a JSON content block when the client accepted those during
+// initialize, and marshaled to JSON text content otherwise.
+func jsonTextResult(ctx *ToolContext, data interface{}) (*ToolCallResult, error) {
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
+	if ctx != nil && ctx.Session != nil && ctx.Session.JSONContent() {
+		// round-trip so the block holds plain JSON values, the same a text client would decode
+		var value interface{}
+		if err := json.Unmarshal(jsonBytes, &value); err != nil {
+			return nil, err
+		}
+		return &ToolCallResult{
+			Content: []ToolContent{{Type: ContentTypeJSON, JSON: value}},
+		}, nil
+	}
 	return textResult(string(jsonBytes)), nil
 }
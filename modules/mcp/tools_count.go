@@ -0,0 +1,47 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// toolCount returns how many entities list_entities would return for the same filters,
+// without fetching them, together with the total number of entities in the index.
+func toolCount(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+	typeFilter, _ := args["type"].(string)
+	parentFilter, _ := args["parent"].(string)
+
+	if typeFilter != "" {
+		if _, ok := ctx.Index.ByType[typeFilter]; !ok {
+			types := make([]string, 0, len(ctx.Index.ByType))
+			for t := range ctx.Index.ByType {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			return toolError(ToolErrorUnknownType, fmt.Sprintf("Unknown type '%s'. Available types: %v", typeFilter, types)), nil
+		}
+	}
+
+	var count int
+	switch {
+	case parentFilter != "":
+		for _, id := range ctx.Index.ByParent[parentFilter] {
+			if entity, ok := ctx.Index.Entities[id]; ok && (typeFilter == "" || entity.Type == typeFilter) {
+				count++
+			}
+		}
+	case typeFilter != "":
+		count = len(ctx.Index.ByType[typeFilter])
+	default:
+		count = len(ctx.Index.Entities)
+	}
+
+	return jsonTextResult(ctx, map[string]interface{}{
+		"count":   count,
+		"total":   len(ctx.Index.Entities),
+		"filters": map[string]interface{}{"type": typeFilter, "parent": parentFilter},
+	})
+}
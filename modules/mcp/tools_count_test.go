@@ -0,0 +1,71 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCountTestToolContext() *ToolContext {
+	ctx := newTestToolContext()
+	ctx.Index.Entities = map[string]*Entity{
+		"ministry:13":       {ID: "ministry:13", Type: "ministry"},
+		"ministry:14":       {ID: "ministry:14", Type: "ministry"},
+		"organization:0001": {ID: "organization:0001", Type: "organization", ParentID: "ministry:13"},
+		"organization:0002": {ID: "organization:0002", Type: "organization", ParentID: "ministry:13"},
+		"organization:0003": {ID: "organization:0003", Type: "organization", ParentID: "ministry:14"},
+		"unit:0001":         {ID: "unit:0001", Type: "unit", ParentID: "ministry:13"},
+	}
+	ctx.Index.ByType = map[string][]string{
+		"ministry":     {"ministry:13", "ministry:14"},
+		"organization": {"organization:0001", "organization:0002", "organization:0003"},
+		"unit":         {"unit:0001"},
+	}
+	ctx.Index.ByParent = map[string][]string{
+		"ministry:13": {"organization:0001", "organization:0002", "unit:0001"},
+		"ministry:14": {"organization:0003"},
+	}
+	return ctx
+}
+
+func TestToolCount(t *testing.T) {
+	ctx := newCountTestToolContext()
+
+	count := func(args map[string]interface{}) (int, int) {
+		result, err := toolCount(ctx, args)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var out struct {
+			Count int `json:"count"`
+			Total int `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+		return out.Count, out.Total
+	}
+
+	for _, c := range []struct {
+		args  map[string]interface{}
+		count int
+	}{
+		{map[string]interface{}{}, 6},
+		{map[string]interface{}{"type": "organization"}, 3},
+		{map[string]interface{}{"parent": "ministry:13"}, 3},
+		{map[string]interface{}{"type": "organization", "parent": "ministry:13"}, 2},
+		{map[string]interface{}{"type": "unit", "parent": "ministry:14"}, 0},
+		{map[string]interface{}{"parent": "organization:0001"}, 0},
+	} {
+		n, total := count(c.args)
+		assert.Equal(t, c.count, n, c.args)
+		assert.Equal(t, 6, total, c.args)
+	}
+
+	result, err := toolCount(ctx, map[string]interface{}{"type": "nope"})
+	require.NoError(t, err)
+	assert.Equal(t, ToolErrorUnknownType, requireToolError(t, result).Code)
+}
@@ -3,6 +3,76 @@
 
 package mcp
 
+import (
+	"bytes"
+	"slices"
+	"sort"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// describedEntityType describes one entity type in the describe_model output.
+// Structs are used instead of maps so that the JSON field order is stable.
+type describedEntityType struct {
+	Type       string           `json:"type"`
+	Count      int              `json:"count"`
+	IsRoot     bool             `json:"is_root"` // some entities of the type have no parent
+	Attributes []string         `json:"attributes"`
+	ParentType string           `json:"parent_type,omitempty"`
+	ChildType  string           `json:"child_type,omitempty"`
+	Example    *describedEntity `json:"example,omitempty"`
+}
+
+// describedEntity is a concrete example entity in the describe_model output.
+type describedEntity struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Attributes sortedAttributes `json:"attributes"`
+}
+
+// describedModel is the top-level describe_model output.
+type describedModel struct {
+	EntityTypes   []describedEntityType `json:"entity_types"`
+	TopLevelTypes []string              `json:"top_level_types"`
+	TotalEntities int                   `json:"total_entities"`
+	SourceFile    string                `json:"source_file"`
+	Commit        string                `json:"commit"`
+	IDFormat      string                `json:"id_format"`
+}
+
+// sortedAttributes marshals an attribute map with its keys in sorted order.
+type sortedAttributes map[string]string
+
+// MarshalJSON implements json.Marshaler
+func (a sortedAttributes) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(a[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 func toolDescribeModel(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	// Collect unique attribute names per entity type
 	typeAttrs := make(map[string]map[string]bool)
@@ -15,52 +85,96 @@ func toolDescribeModel(ctx *ToolContext, args map[string]interface{}) (*ToolCall
 		}
 	}
 
-	// Build entity type descriptions
-	var entityTypes []map[string]interface{}
-	for typeName, count := range ctx.Index.Stats.TypeCounts {
+	// Build entity type descriptions, sorted by type name for deterministic output
+	typeNames := make([]string, 0, len(ctx.Index.Stats.TypeCounts))
+	for typeName := range ctx.Index.Stats.TypeCounts {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	topLevelTypes := findTopLevelTypes(ctx.Index)
+	if topLevelTypes == nil {
+		topLevelTypes = []string{}
+	}
+
+	entityTypes := make([]describedEntityType, 0, len(typeNames))
+	for _, typeName := range typeNames {
+		count := ctx.Index.Stats.TypeCounts[typeName]
 		attrs := make([]string, 0)
 		if attrSet, ok := typeAttrs[typeName]; ok {
 			for attr := range attrSet {
 				attrs = append(attrs, attr)
 			}
 		}
+		sort.Strings(attrs)
 
-		typeDesc := map[string]interface{}{
-			"type":       typeName,
-			"count":      count,
-			"attributes": attrs,
+		typeDesc := describedEntityType{
+			Type:       typeName,
+			Count:      count,
+			IsRoot:     slices.Contains(topLevelTypes, typeName),
+			Attributes: attrs,
 		}
 
+		ids := sortedIDsOfType(ctx.Index, typeName)
+
 		// Find if entities of this type have a common parent type
-		for _, id := range ctx.Index.ByType[typeName] {
+		for _, id := range ids {
 			if e, ok := ctx.Index.Entities[id]; ok && e.ParentID != "" {
 				if parent, ok2 := ctx.Index.Entities[e.ParentID]; ok2 {
-					typeDesc["parent_type"] = parent.Type
+					typeDesc.ParentType = parent.Type
 				}
 				break
 			}
 		}
 
 		// Find if entities of this type have children
-		for _, id := range ctx.Index.ByType[typeName] {
+		for _, id := range ids {
 			if children, ok := ctx.Index.ByParent[id]; ok && len(children) > 0 {
 				if child, ok2 := ctx.Index.Entities[children[0]]; ok2 {
-					typeDesc["child_type"] = child.Type
+					typeDesc.ChildType = child.Type
 				}
 				break
 			}
 		}
 
+		// Include the first entity of this type (by sorted ID) as a concrete example
+		if example := firstEntityOfType(ctx.Index, typeName); example != nil {
+			typeDesc.Example = &describedEntity{
+				ID:         example.ID,
+				Name:       example.Name,
+				Attributes: example.Attributes,
+			}
+		}
+
 		entityTypes = append(entityTypes, typeDesc)
 	}
 
-	result := map[string]interface{}{
-		"entity_types":   entityTypes,
-		"total_entities": ctx.Index.Stats.TotalEntities,
-		"source_file":    ctx.Index.SourceFile,
-		"commit":         ctx.Index.CommitSHA,
-		"id_format":      "type:code (e.g., ministry:01, organization:0001)",
+	result := describedModel{
+		EntityTypes:   entityTypes,
+		TopLevelTypes: topLevelTypes,
+		TotalEntities: ctx.Index.Stats.TotalEntities,
+		SourceFile:    ctx.Index.SourceFile,
+		Commit:        ctx.Index.CommitSHA,
+		IDFormat:      "type:code (e.g., ministry:01, organization:0001); colons in the code are escaped as %3A and percent signs as %25",
 	}
 
-	return jsonTextResult(result)
+	return jsonTextResult(ctx, result)
+}
+
+// sortedIDsOfType returns the IDs of all entities of the given type in sorted order.
+func sortedIDsOfType(index *EntityIndex, typeName string) []string {
+	ids := make([]string, len(index.ByType[typeName]))
+	copy(ids, index.ByType[typeName])
+	sort.Strings(ids)
+	return ids
+}
+
+// firstEntityOfType returns the entity of the given type with the lowest ID, or nil.
+func firstEntityOfType(index *EntityIndex, typeName string) *Entity {
+	for _, id := range sortedIDsOfType(index, typeName) {
+		if entity, ok := index.Entities[id]; ok {
+			return entity
+		}
+	}
+	return nil
 }
@@ -0,0 +1,168 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"sort"
+
+	"code.gitea.io/gitea/modules/git"
+)
+
+// EntityChange describes a single changed field of a modified entity.
+type EntityChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// EntitySummary identifies an added or removed entity.
+type EntitySummary struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ModifiedEntity is an entity present at both refs whose fields differ.
+type ModifiedEntity struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Changes []EntityChange `json:"changes"`
+}
+
+// IndexDiff is the difference between two entity indexes.
+type IndexDiff struct {
+	Added    []EntitySummary  `json:"added"`
+	Removed  []EntitySummary  `json:"removed"`
+	Modified []ModifiedEntity `json:"modified"`
+}
+
+func toolDiff(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+	baseRef, _ := args["base_ref"].(string)
+	headRef, _ := args["head_ref"].(string)
+	if baseRef == "" || headRef == "" {
+		return toolError(ToolErrorMissingArgument, "'base_ref' and 'head_ref' parameters are required, e.g., base_ref='main~1', head_ref='main'."), nil
+	}
+	if ctx.GitRepo == nil {
+		return toolError(ToolErrorRepositoryUnavailable, "The repository is not available for diffing."), nil
+	}
+
+	base, baseCommit, err := ctx.indexAtRef(baseRef)
+	if err != nil {
+		return toolError(ToolErrorInvalidRef, fmt.Sprintf("Cannot read base_ref '%s': %v", baseRef, err)), nil
+	}
+	head, headCommit, err := ctx.indexAtRef(headRef)
+	if err != nil {
+		return toolError(ToolErrorInvalidRef, fmt.Sprintf("Cannot read head_ref '%s': %v", headRef, err)), nil
+	}
+
+	diff := diffIndexes(base, head)
+	return jsonTextResult(ctx, map[string]interface{}{
+		"base_ref":    baseRef,
+		"base_commit": baseCommit,
+		"head_ref":    headRef,
+		"head_commit": headCommit,
+		"summary": map[string]int{
+			"added":    len(diff.Added),
+			"removed":  len(diff.Removed),
+			"modified": len(diff.Modified),
+		},
+		"added":    diff.Added,
+		"removed":  diff.Removed,
+		"modified": diff.Modified,
+	})
+}
+
+// indexAtRef resolves ref in the tool's repository and returns the entity index at that commit.
+// The MCP config at the commit is used when present, otherwise the current config.
+func (ctx *ToolContext) indexAtRef(ref string) (*EntityIndex, string, error) {
+	commit, err := ctx.GitRepo.GetCommit(ref)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, "", fmt.Errorf("ref not found")
+		}
+		return nil, "", err
+	}
+	cfg, err := LoadRepoConfig(ctx.RepoID, commit)
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg == nil {
+		cfg = ctx.Config
+	}
+	index, err := GetOrBuildIndex(ctx.RepoID, commit, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return index, commit.ID.String(), nil
+}
+
+// diffIndexes compares two indexes by entity ID. Results are sorted by ID.
+func diffIndexes(base, head *EntityIndex) *IndexDiff {
+	diff := &IndexDiff{
+		Added:    []EntitySummary{},
+		Removed:  []EntitySummary{},
+		Modified: []ModifiedEntity{},
+	}
+
+	for _, id := range sortedEntityIDs(head) {
+		if _, ok := base.Entities[id]; !ok {
+			e := head.Entities[id]
+			diff.Added = append(diff.Added, EntitySummary{ID: e.ID, Type: e.Type, Name: e.Name})
+		}
+	}
+
+	for _, id := range sortedEntityIDs(base) {
+		old := base.Entities[id]
+		cur, ok := head.Entities[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, EntitySummary{ID: old.ID, Type: old.Type, Name: old.Name})
+			continue
+		}
+		if changes := entityChanges(old, cur); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, ModifiedEntity{ID: cur.ID, Name: cur.Name, Changes: changes})
+		}
+	}
+	return diff
+}
+
+// entityChanges lists the differing name, parent and attributes of two versions of an entity.
+func entityChanges(old, cur *Entity) []EntityChange {
+	var changes []EntityChange
+	if old.Name != cur.Name {
+		changes = append(changes, EntityChange{Field: "name", Old: old.Name, New: cur.Name})
+	}
+	if old.ParentID != cur.ParentID {
+		changes = append(changes, EntityChange{Field: "parent_id", Old: old.ParentID, New: cur.ParentID})
+	}
+
+	keys := make(map[string]struct{}, len(old.Attributes)+len(cur.Attributes))
+	for k := range old.Attributes {
+		keys[k] = struct{}{}
+	}
+	for k := range cur.Attributes {
+		keys[k] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, k := range sortedKeys {
+		if old.Attributes[k] != cur.Attributes[k] {
+			changes = append(changes, EntityChange{Field: "attributes." + k, Old: old.Attributes[k], New: cur.Attributes[k]})
+		}
+	}
+	return changes
+}
+
+func sortedEntityIDs(index *EntityIndex) []string {
+	ids := make([]string, 0, len(index.Entities))
+	for id := range index.Entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestIndex(t *testing.T, xmlData string) *EntityIndex {
+	index := &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+	require.NoError(t, parseXMLEntities([]byte(xmlData), index, DefaultIDAttribute))
+	return index
+}
+
+func TestDiffIndexes(t *testing.T) {
+	base := parseTestIndex(t, `<?xml version="1.0"?>
+<root>
+  <ministry code="01">
+    <n>Ministry One</n>
+    <organization code="0001" nmr="111"><n>Org One</n></organization>
+    <organization code="0002" nmr="222"><n>Org Two</n></organization>
+  </ministry>
+  <ministry code="02"><n>Ministry Two</n></ministry>
+</root>`)
+	head := parseTestIndex(t, `<?xml version="1.0"?>
+<root>
+  <ministry code="01">
+    <n>Ministry One</n>
+    <organization code="0001" nmr="999"><n>Org One Renamed</n></organization>
+  </ministry>
+  <ministry code="02">
+    <n>Ministry Two</n>
+    <organization code="0002" nmr="222"><n>Org Two</n></organization>
+    <organization code="0003"><n>Org Three</n></organization>
+  </ministry>
+</root>`)
+
+	diff := diffIndexes(base, head)
+
+	assert.Equal(t, []EntitySummary{{ID: "organization:0003", Type: "organization", Name: "Org Three"}}, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Equal(t, []ModifiedEntity{
+		{
+			ID:   "organization:0001",
+			Name: "Org One Renamed",
+			Changes: []EntityChange{
+				{Field: "name", Old: "Org One", New: "Org One Renamed"},
+				{Field: "attributes.n", Old: "Org One", New: "Org One Renamed"},
+				{Field: "attributes.nmr", Old: "111", New: "999"},
+			},
+		},
+		{
+			ID:      "organization:0002",
+			Name:    "Org Two",
+			Changes: []EntityChange{{Field: "parent_id", Old: "ministry:01", New: "ministry:02"}},
+		},
+	}, diff.Modified)
+
+	reverse := diffIndexes(head, base)
+	assert.Empty(t, reverse.Added)
+	assert.Equal(t, []EntitySummary{{ID: "organization:0003", Type: "organization", Name: "Org Three"}}, reverse.Removed)
+
+	same := diffIndexes(base, base)
+	assert.Empty(t, same.Added)
+	assert.Empty(t, same.Removed)
+	assert.Empty(t, same.Modified)
+}
+
+func TestToolDiff_Errors(t *testing.T) {
+	ctx := newTestToolContext()
+
+	result, err := toolDiff(ctx, map[string]interface{}{"base_ref": "main"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "'base_ref' and 'head_ref' parameters are required")
+
+	result, err = toolDiff(ctx, map[string]interface{}{"base_ref": "main~1", "head_ref": "main"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "not available")
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+func toolFindByAttribute(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+	attribute, _ := args["attribute"].(string)
+	value, _ := args["value"].(string)
+	if attribute == "" || value == "" {
+		return toolError(ToolErrorMissingArgument, "'attribute' and 'value' parameters are required"), nil
+	}
+	typeFilter, _ := args["type"].(string)
+
+	candidates := ctx.Index.Entities
+	if typeFilter != "" {
+		ids, ok := ctx.Index.ByType[typeFilter]
+		if !ok {
+			var types []string
+			for t := range ctx.Index.ByType {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			return toolError(ToolErrorUnknownType, fmt.Sprintf("Unknown type '%s'. Available types: %v", typeFilter, types)), nil
+		}
+		candidates = make(map[string]*Entity, len(ids))
+		for _, id := range ids {
+			if entity, ok := ctx.Index.Entities[id]; ok {
+				candidates[id] = entity
+			}
+		}
+	}
+
+	results := []*Entity{}
+	for _, entity := range candidates {
+		if hasAttributeValue(entity, attribute, value) {
+			results = append(results, entity)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	return jsonTextResult(ctx, map[string]interface{}{
+		"attribute": attribute,
+		"value":     value,
+		"type":      typeFilter,
+		"count":     len(results),
+		"entities":  results,
+	})
+}
+
+// hasAttributeValue reports whether the attribute of entity, or one of its values if it
+// occurs more than once, is exactly value.
+func hasAttributeValue(entity *Entity, attribute, value string) bool {
+	if values, ok := entity.MultiAttributes[attribute]; ok {
+		return slices.Contains(values, value)
+	}
+	v, ok := entity.Attributes[attribute]
+	return ok && v == value
+}
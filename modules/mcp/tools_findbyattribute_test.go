@@ -0,0 +1,57 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findByAttributeIDs(t *testing.T, ctx *ToolContext, args map[string]interface{}) []string {
+	result, err := ExecuteTool(ctx, "find_by_attribute", args)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var out struct {
+		Count    int       `json:"count"`
+		Entities []*Entity `json:"entities"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+	ids := []string{}
+	for _, entity := range out.Entities {
+		ids = append(ids, entity.ID)
+	}
+	assert.Equal(t, len(ids), out.Count)
+	return ids
+}
+
+func TestToolFindByAttribute(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index = newSearchTestIndex()
+	ctx.Index.Entities["organization:0002"] = &Entity{
+		ID:              "organization:0002",
+		Type:            "organization",
+		Attributes:      map[string]string{"code": "0002", "nmr": "90000038578", "tag": "state, agency"},
+		MultiAttributes: map[string][]string{"tag": {"state", "agency"}},
+	}
+	ctx.Index.ByType = map[string][]string{
+		"ministry":     {"ministry:01"},
+		"organization": {"organization:0001", "organization:0002"},
+	}
+
+	// exact matches only, unlike search which would also match "9000003857"
+	assert.Equal(t, []string{"ministry:01", "organization:0002"}, findByAttributeIDs(t, ctx, map[string]interface{}{"attribute": "nmr", "value": "90000038578"}))
+	assert.Empty(t, findByAttributeIDs(t, ctx, map[string]interface{}{"attribute": "nmr", "value": "9000003857"}))
+	assert.Equal(t, []string{"organization:0002"}, findByAttributeIDs(t, ctx, map[string]interface{}{"attribute": "nmr", "value": "90000038578", "type": "organization"}))
+	assert.Equal(t, []string{"organization:0002"}, findByAttributeIDs(t, ctx, map[string]interface{}{"attribute": "tag", "value": "agency"}))
+	assert.Empty(t, findByAttributeIDs(t, ctx, map[string]interface{}{"attribute": "tag", "value": "state, agency"}))
+
+	result, err := ExecuteTool(ctx, "find_by_attribute", map[string]interface{}{"attribute": "nmr", "value": "1", "type": "planet"})
+	require.NoError(t, err)
+	assert.Equal(t, ToolErrorUnknownType, requireToolError(t, result).Code)
+}
@@ -4,11 +4,31 @@
 package mcp
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"sort"
 	"strings"
 )
 
+// MaxInlineDocumentSize is the largest document generate_document returns as a tool result.
+// Larger documents must be downloaded from the export endpoint, which streams them.
+const MaxInlineDocumentSize = 1 << 20
+
+// documentChunkSize is the buffer size used when streaming a document to a writer.
+const documentChunkSize = 32 * 1024
+
+var errDocumentTooLarge = errors.New("document too large")
+
+// DocumentContentTypes maps the supported document formats to their MIME types.
+var DocumentContentTypes = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"csv":      "text/csv; charset=utf-8",
+}
+
 func toolGenerateDocument(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	typeFilter, _ := args["type"].(string)
 	parentFilter, _ := args["parent"].(string)
@@ -16,35 +36,87 @@ func toolGenerateDocument(ctx *ToolContext, args map[string]interface{}) (*ToolC
 	if format == "" {
 		format = "markdown"
 	}
+	if _, ok := DocumentContentTypes[format]; !ok {
+		return toolError(ToolErrorUnknownFormat, fmt.Sprintf("Unknown format '%s'. Use 'markdown' or 'csv'.", format)), nil
+	}
+
+	var buf bytes.Buffer
+	err := WriteDocument(&limitedWriter{w: &buf, remaining: MaxInlineDocumentSize}, ctx, format, typeFilter, parentFilter)
+	if errors.Is(err, errDocumentTooLarge) {
+		msg := fmt.Sprintf("The generated document exceeds %d bytes and is too large to return inline.", MaxInlineDocumentSize)
+		if ctx.ExportURL != "" {
+			query := url.Values{"format": {format}}
+			if typeFilter != "" {
+				query.Set("type", typeFilter)
+			}
+			if parentFilter != "" {
+				query.Set("parent", parentFilter)
+			}
+			msg += fmt.Sprintf(" Download it from %s?%s, or narrow it down with the type or parent arguments.", ctx.ExportURL, query.Encode())
+		} else {
+			msg += " Narrow it down with the type or parent arguments."
+		}
+		return toolError(ToolErrorDocumentTooLarge, msg), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return textResult(buf.String()), nil
+}
 
+// WriteDocument streams the register as a document in the given format ("markdown" or "csv")
+// to w, writing it in chunks rather than building it in memory.
+func WriteDocument(w io.Writer, ctx *ToolContext, format, typeFilter, parentFilter string) error {
+	if !ctx.HasIndexedData() {
+		return ErrNoIndexedData
+	}
+	bw := bufio.NewWriterSize(w, documentChunkSize)
+	var err error
 	switch format {
 	case "markdown":
-		return generateMarkdown(ctx, typeFilter, parentFilter)
+		err = writeMarkdown(bw, ctx, typeFilter, parentFilter)
 	case "csv":
-		return generateCSV(ctx, typeFilter, parentFilter)
+		err = writeCSV(bw, ctx, typeFilter, parentFilter)
 	default:
-		return textResult(fmt.Sprintf("Unknown format '%s'. Use 'markdown' or 'csv'.", format)), nil
+		return fmt.Errorf("unknown document format %q", format)
 	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// limitedWriter fails with errDocumentTooLarge once more than remaining bytes are written.
+type limitedWriter struct {
+	w         io.Writer
+	remaining int
 }
 
-func generateMarkdown(ctx *ToolContext, typeFilter, parentFilter string) (*ToolCallResult, error) {
-	var sb strings.Builder
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > l.remaining {
+		return 0, errDocumentTooLarge
+	}
+	l.remaining -= len(p)
+	return l.w.Write(p)
+}
 
-	sb.WriteString(fmt.Sprintf("# %s\n\n", ctx.Config.Server.Name))
+// writeMarkdown writes the register as Markdown tables. Write errors are sticky in bufio.Writer,
+// so only the final flush needs checking.
+func writeMarkdown(w *bufio.Writer, ctx *ToolContext, typeFilter, parentFilter string) error {
+	fmt.Fprintf(w, "# %s\n\n", ctx.Config.Server.Name)
 	if ctx.Config.Server.Description != "" {
-		sb.WriteString(ctx.Config.Server.Description + "\n\n")
+		w.WriteString(ctx.Config.Server.Description + "\n\n")
 	}
 
 	commitPrefix := ctx.Index.CommitSHA
 	if len(commitPrefix) > 8 {
 		commitPrefix = commitPrefix[:8]
 	}
-	sb.WriteString(fmt.Sprintf("*Source: %s | Commit: %s*\n\n", ctx.Index.SourceFile, commitPrefix))
-
-	// Determine what entity types to show (find the "top-level" types)
-	topTypes := findTopLevelTypes(ctx.Index)
+	fmt.Fprintf(w, "*Source: %s | Commit: %s*\n\n", ctx.Index.SourceFile, commitPrefix)
 
-	for _, topType := range topTypes {
+	// the sections are collected first so progress can be reported against their total
+	var topEntities []*Entity
+	for _, topType := range documentRootTypes(ctx.Config, ctx.Index) {
 		if typeFilter != "" && typeFilter != topType {
 			continue
 		}
@@ -58,84 +130,89 @@ func generateMarkdown(ctx *ToolContext, typeFilter, parentFilter string) (*ToolC
 			if parentFilter != "" && topID != parentFilter {
 				continue
 			}
-
-			topEntity := ctx.Index.Entities[topID]
-			if topEntity == nil {
-				continue
+			if topEntity := ctx.Index.Entities[topID]; topEntity != nil {
+				topEntities = append(topEntities, topEntity)
 			}
+		}
+	}
+
+	for n, topEntity := range topEntities {
+		topID := topEntity.ID
+		// Section header for top-level entity
+		headerName := topEntity.Name
+		if headerName == "" {
+			headerName = topEntity.ID
+		}
+		fmt.Fprintf(w, "## %s (code: %s)\n\n", headerName, topEntity.Attributes["code"])
+
+		// Children as table
+		childIDs, hasChildren := ctx.Index.ByParent[topID]
+		if hasChildren && len(childIDs) > 0 {
+			// Collect all attribute keys from children
+			attrKeys := collectChildAttributeKeys(ctx.Index, childIDs)
 
-			// Section header for top-level entity
-			headerName := topEntity.Name
-			if headerName == "" {
-				headerName = topEntity.ID
+			// Table header
+			w.WriteString("| # | Name |")
+			for _, key := range attrKeys {
+				fmt.Fprintf(w, " %s |", key)
 			}
-			sb.WriteString(fmt.Sprintf("## %s (code: %s)\n\n",
-				headerName, topEntity.Attributes["code"]))
+			w.WriteString("\n|---|------|")
+			for range attrKeys {
+				w.WriteString("------|")
+			}
+			w.WriteString("\n")
 
-			// Children as table
-			childIDs, hasChildren := ctx.Index.ByParent[topID]
-			if hasChildren && len(childIDs) > 0 {
-				// Collect all attribute keys from children
-				attrKeys := collectChildAttributeKeys(ctx.Index, childIDs)
+			// Table rows
+			sortedChildIDs := make([]string, len(childIDs))
+			copy(sortedChildIDs, childIDs)
+			sort.Strings(sortedChildIDs)
 
-				// Table header
-				sb.WriteString("| # | Name |")
-				for _, key := range attrKeys {
-					sb.WriteString(fmt.Sprintf(" %s |", key))
-				}
-				sb.WriteString("\n|---|------|")
-				for range attrKeys {
-					sb.WriteString("------|")
+			for i, childID := range sortedChildIDs {
+				child := ctx.Index.Entities[childID]
+				if child == nil {
+					continue
 				}
-				sb.WriteString("\n")
-
-				// Table rows
-				sortedChildIDs := make([]string, len(childIDs))
-				copy(sortedChildIDs, childIDs)
-				sort.Strings(sortedChildIDs)
-
-				for i, childID := range sortedChildIDs {
-					child := ctx.Index.Entities[childID]
-					if child == nil {
-						continue
-					}
-					sb.WriteString(fmt.Sprintf("| %d | %s |", i+1, child.Name))
-					for _, key := range attrKeys {
-						val := child.Attributes[key]
-						sb.WriteString(fmt.Sprintf(" %s |", val))
-					}
-					sb.WriteString("\n")
+				fmt.Fprintf(w, "| %d | %s |", i+1, child.Name)
+				for _, key := range attrKeys {
+					fmt.Fprintf(w, " %s |", child.Attributes[key])
 				}
-				sb.WriteString("\n")
+				w.WriteString("\n")
 			}
+			w.WriteString("\n")
 		}
+		ctx.reportProgress(n+1, len(topEntities), topID)
 	}
 
 	// Summary
-	sb.WriteString("---\n\n")
-	sb.WriteString("## Summary\n\n")
-	for typeName, count := range ctx.Index.Stats.TypeCounts {
-		sb.WriteString(fmt.Sprintf("- **%s**: %d\n", typeName, count))
+	w.WriteString("---\n\n")
+	w.WriteString("## Summary\n\n")
+	typeNames := make([]string, 0, len(ctx.Index.Stats.TypeCounts))
+	for typeName := range ctx.Index.Stats.TypeCounts {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	for _, typeName := range typeNames {
+		fmt.Fprintf(w, "- **%s**: %d\n", typeName, ctx.Index.Stats.TypeCounts[typeName])
 	}
-	sb.WriteString(fmt.Sprintf("- **Total entities**: %d\n", ctx.Index.Stats.TotalEntities))
+	fmt.Fprintf(w, "- **Total entities**: %d\n", ctx.Index.Stats.TotalEntities)
 
-	return textResult(sb.String()), nil
+	return w.Flush()
 }
 
-func generateCSV(ctx *ToolContext, typeFilter, parentFilter string) (*ToolCallResult, error) {
-	var sb strings.Builder
-
+// writeCSV writes one CSV row per entity, sorted by ID.
+func writeCSV(w *bufio.Writer, ctx *ToolContext, typeFilter, parentFilter string) error {
 	// CSV header
-	sb.WriteString("type,id,name,parent_id,code,nmr,docPrefix\n")
+	w.WriteString("type,id,name,parent_id,code,nmr,docPrefix\n")
 
-	for _, entity := range ctx.Index.Entities {
+	for _, id := range sortedEntityIDs(ctx.Index) {
+		entity := ctx.Index.Entities[id]
 		if typeFilter != "" && entity.Type != typeFilter {
 			continue
 		}
 		if parentFilter != "" && entity.ParentID != parentFilter {
 			continue
 		}
-		sb.WriteString(fmt.Sprintf("%s,%s,\"%s\",%s,%s,%s,%s\n",
+		fmt.Fprintf(w, "%s,%s,\"%s\",%s,%s,%s,%s\n",
 			entity.Type,
 			entity.ID,
 			strings.ReplaceAll(entity.Name, "\"", "\"\""),
@@ -143,10 +220,19 @@ func generateCSV(ctx *ToolContext, typeFilter, parentFilter string) (*ToolCallRe
 			entity.Attributes["code"],
 			entity.Attributes["nmr"],
 			entity.Attributes["docPrefix"],
-		))
+		)
 	}
 
-	return textResult(sb.String()), nil
+	return w.Flush()
+}
+
+// documentRootTypes returns the entity types whose entities are the sections of a document:
+// the document_root_types of cfg if declared, else the inferred top-level types.
+func documentRootTypes(cfg *MCPConfig, index *EntityIndex) []string {
+	if len(cfg.DocumentRootTypes) > 0 {
+		return cfg.DocumentRootTypes
+	}
+	return findTopLevelTypes(index)
 }
 
 // findTopLevelTypes returns entity types that have no parent (root types).
@@ -0,0 +1,117 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWriter records how many Write calls it receives.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func newLargeDocumentToolContext(n int) *ToolContext {
+	ctx := newTestToolContext()
+	ctx.Index.Entities = make(map[string]*Entity)
+	ctx.Index.ByType = make(map[string][]string)
+	ctx.Index.Stats = IndexStats{TypeCounts: make(map[string]int)}
+	name := strings.Repeat("x", 100)
+	for i := range n {
+		id := fmt.Sprintf("item:%05d", i)
+		ctx.Index.Entities[id] = &Entity{ID: id, Type: "item", Name: name, Attributes: map[string]string{"code": fmt.Sprintf("%05d", i)}}
+		ctx.Index.ByType["item"] = append(ctx.Index.ByType["item"], id)
+		ctx.Index.Stats.TotalEntities++
+		ctx.Index.Stats.TypeCounts["item"]++
+	}
+	return ctx
+}
+
+func TestWriteDocument_CSV(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index.Entities["item:00"] = &Entity{ID: "item:00", Type: "item", Name: `Say "hi"`, Attributes: map[string]string{"code": "00"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDocument(&buf, ctx, "csv", "", ""))
+	assert.Equal(t, "type,id,name,parent_id,code,nmr,docPrefix\n"+
+		"item,item:00,\"Say \"\"hi\"\"\",,00,,\n"+
+		"item,item:01,\"Test Item\",,01,,\n", buf.String())
+
+	assert.Error(t, WriteDocument(&buf, ctx, "pdf", "", ""))
+}
+
+func TestWriteDocument_StreamsInChunks(t *testing.T) {
+	ctx := newLargeDocumentToolContext(20000)
+
+	var w countingWriter
+	require.NoError(t, WriteDocument(&w, ctx, "csv", "", ""))
+	assert.Greater(t, w.Len(), MaxInlineDocumentSize)
+	assert.Greater(t, w.writes, 1, "document must be written progressively")
+	assert.Equal(t, 20001, strings.Count(w.String(), "\n"))
+}
+
+func TestToolGenerateDocument_SizeCutoff(t *testing.T) {
+	ctx := newLargeDocumentToolContext(20000)
+	ctx.ExportURL = "https://example.com/owner/repo/mcp/export"
+
+	result, err := toolGenerateDocument(ctx, map[string]interface{}{"format": "csv", "type": "item"})
+	require.NoError(t, err)
+	toolErr := requireToolError(t, result)
+	assert.Equal(t, ToolErrorDocumentTooLarge, toolErr.Code)
+	assert.Contains(t, toolErr.Message, "too large to return inline")
+	assert.Contains(t, toolErr.Message, "https://example.com/owner/repo/mcp/export?format=csv&type=item")
+
+	// small outputs are still returned inline
+	result, err = toolGenerateDocument(newTestToolContext(), map[string]interface{}{"format": "csv"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.Content[0].Text, "type,id,name"))
+}
+
+func TestWriteDocument_RootTypes(t *testing.T) {
+	ctx := newCountTestToolContext()
+	// an organization that lost its ministry makes "organization" look like a top-level type
+	ctx.Index.Entities["organization:0009"] = &Entity{ID: "organization:0009", Type: "organization"}
+	ctx.Index.ByType["organization"] = append(ctx.Index.ByType["organization"], "organization:0009")
+
+	sections := func() []string {
+		var buf bytes.Buffer
+		require.NoError(t, WriteDocument(&buf, ctx, "markdown", "", ""))
+		var headers []string
+		for line := range strings.SplitSeq(buf.String(), "\n") {
+			if strings.HasPrefix(line, "## ") && line != "## Summary" {
+				headers = append(headers, strings.TrimSuffix(strings.TrimPrefix(line, "## "), " (code: )"))
+			}
+		}
+		return headers
+	}
+
+	t.Run("Inferred", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"ministry:13", "ministry:14",
+			"organization:0001", "organization:0002", "organization:0003", "organization:0009",
+		}, sections())
+	})
+
+	t.Run("Explicit", func(t *testing.T) {
+		ctx.Config.DocumentRootTypes = []string{"ministry"}
+		defer func() { ctx.Config.DocumentRootTypes = nil }()
+		assert.Equal(t, []string{"ministry:13", "ministry:14"}, sections())
+
+		// the declared order is kept
+		ctx.Config.DocumentRootTypes = []string{"unit", "ministry"}
+		assert.Equal(t, []string{"unit:0001", "ministry:13", "ministry:14"}, sections())
+	})
+}
@@ -3,33 +3,56 @@
 
 package mcp
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// getEntityMaxDepth caps how many levels of descendants get_entity embeds.
+const getEntityMaxDepth = 10
 
 func toolGetEntity(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	id, _ := args["id"].(string)
 	if id == "" {
-		return &ToolCallResult{
-			Content: []ToolContent{{Type: "text", Text: "Error: 'id' parameter is required. Use format 'type:code', e.g., 'ministry:01'."}},
-			IsError: true,
-		}, nil
+		return toolError(ToolErrorMissingArgument, "'id' parameter is required. Use format 'type:code', e.g., 'ministry:01'."), nil
 	}
 
 	entity, ok := ctx.Index.Entities[id]
 	if !ok {
-		// Try to be helpful — suggest similar IDs
-		suggestions := ctx.Index.SearchEntities(id, 3)
-		msg := fmt.Sprintf("Entity '%s' not found.", id)
-		if len(suggestions) > 0 {
-			msg += " Did you mean: "
-			for i, s := range suggestions {
-				if i > 0 {
-					msg += ", "
+		// Normalize the escaping, so a code with unescaped colons is found too,
+		// e.g. "item:P:1:13" for "item:P%3A1%3A13"
+		if entityType, code, found := ParseEntityID(id); found {
+			entity, ok = ctx.Index.Entities[EntityID(entityType, code)]
+		}
+	}
+	if !ok {
+		// Fall back to a unique match on the entity's code or registration number
+		matches := ctx.Index.lookupByCodeOrNMR(id)
+		if len(matches) == 1 {
+			entity, ok = matches[0], true
+		} else {
+			suggestions := matches
+			if len(suggestions) == 0 {
+				// Try to be helpful — suggest similar IDs
+				suggestions = ctx.Index.SearchEntities(id, 3)
+			}
+			code, msg := ToolErrorNotFound, fmt.Sprintf("Entity '%s' not found.", id)
+			if len(matches) > 1 {
+				code, msg = ToolErrorAmbiguous, fmt.Sprintf("'%s' matches %d entities by code or NMR.", id, len(matches))
+			}
+			if len(suggestions) > 0 {
+				msg += " Did you mean: "
+				for i, s := range suggestions {
+					if i > 0 {
+						msg += ", "
+					}
+					msg += fmt.Sprintf("'%s' (%s)", s.ID, s.Name)
 				}
-				msg += fmt.Sprintf("'%s' (%s)", s.ID, s.Name)
+				msg += "?"
 			}
-			msg += "?"
+			return toolError(code, msg), nil
 		}
-		return textResult(msg), nil
 	}
 
 	// Build rich response with children
@@ -47,21 +70,61 @@ func toolGetEntity(ctx *ToolContext, args map[string]interface{}) (*ToolCallResu
 		}
 	}
 
-	// Include children with details
-	if childIDs, ok := ctx.Index.ByParent[id]; ok && len(childIDs) > 0 {
-		var children []map[string]interface{}
-		for _, childID := range childIDs {
-			if child, ok := ctx.Index.Entities[childID]; ok {
-				children = append(children, map[string]interface{}{
-					"id":         child.ID,
-					"name":       child.Name,
-					"attributes": child.Attributes,
-				})
-			}
-		}
+	depth := 1
+	if d, ok := args["depth"].(float64); ok && d > 1 {
+		depth = min(int(d), getEntityMaxDepth)
+	}
+
+	// Include children with details, recursing up to the requested depth
+	if children := entityChildren(ctx.Index, entity.ID, depth, map[string]bool{entity.ID: true}); len(children) > 0 {
 		response["children"] = children
 		response["children_count"] = len(children)
 	}
 
-	return jsonTextResult(response)
+	return jsonTextResult(ctx, response)
+}
+
+// lookupByCodeOrNMR returns the entities, sorted by ID, whose "code" or "nmr" attribute
+// equals key.
+func (idx *EntityIndex) lookupByCodeOrNMR(key string) []*Entity {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil
+	}
+	var matches []*Entity
+	for _, entity := range idx.Entities {
+		if entity.Attributes["code"] == key || entity.Attributes["nmr"] == key {
+			matches = append(matches, entity)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	return matches
+}
+
+// entityChildren returns the children of parentID with their details. When depth is greater
+// than 1 the descendants of each child are embedded recursively. visited holds the IDs on the
+// current path and guards against cycles in malformed parent links.
+func entityChildren(index *EntityIndex, parentID string, depth int, visited map[string]bool) []map[string]interface{} {
+	var children []map[string]interface{}
+	for _, childID := range index.ByParent[parentID] {
+		child, ok := index.Entities[childID]
+		if !ok || visited[childID] {
+			continue
+		}
+		item := map[string]interface{}{
+			"id":         child.ID,
+			"name":       child.Name,
+			"attributes": child.Attributes,
+		}
+		if depth > 1 {
+			visited[childID] = true
+			if grandchildren := entityChildren(index, childID, depth-1, visited); len(grandchildren) > 0 {
+				item["children"] = grandchildren
+				item["children_count"] = len(grandchildren)
+			}
+			delete(visited, childID)
+		}
+		children = append(children, item)
+	}
+	return children
 }
@@ -0,0 +1,166 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolGetEntity_FallbackLookup(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index = newSearchTestIndex()
+
+	getID := func(t *testing.T, id string) string {
+		result, err := toolGetEntity(ctx, map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		var entity struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &entity))
+		return entity.ID
+	}
+
+	t.Run("UniqueCode", func(t *testing.T) {
+		assert.Equal(t, "organization:0001", getID(t, "0001"))
+	})
+
+	t.Run("UniqueNMR", func(t *testing.T) {
+		assert.Equal(t, "ministry:01", getID(t, "90000038578"))
+	})
+
+	t.Run("AmbiguousCode", func(t *testing.T) {
+		ctx.Index.Entities["organization:0002"] = &Entity{
+			ID:         "organization:0002",
+			Type:       "organization",
+			Name:       "Other",
+			Attributes: map[string]string{"code": "01"},
+		}
+		defer delete(ctx.Index.Entities, "organization:0002")
+
+		result, err := toolGetEntity(ctx, map[string]interface{}{"id": "01"})
+		require.NoError(t, err)
+		toolErr := requireToolError(t, result)
+		assert.Equal(t, ToolErrorAmbiguous, toolErr.Code)
+		assert.Contains(t, toolErr.Message, "'01' matches 2 entities by code or NMR.")
+		assert.Contains(t, toolErr.Message, "Did you mean: 'ministry:01' (Valsts kanceleja), 'organization:0002' (Other)?")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		result, err := toolGetEntity(ctx, map[string]interface{}{"id": "nothing-like-this"})
+		require.NoError(t, err)
+		assert.Equal(t, ToolError{Code: ToolErrorNotFound, Message: "Entity 'nothing-like-this' not found."}, requireToolError(t, result))
+	})
+}
+
+type entityTreeNode struct {
+	ID       string            `json:"id"`
+	Children []*entityTreeNode `json:"children"`
+}
+
+func getEntityTree(t *testing.T, ctx *ToolContext, args map[string]interface{}) *entityTreeNode {
+	result, err := toolGetEntity(ctx, args)
+	require.NoError(t, err)
+	var node entityTreeNode
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &node))
+	return &node
+}
+
+func TestToolGetEntity_Depth(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index = &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+	require.NoError(t, parseXMLEntities([]byte(namespacedClassificationXML), ctx.Index, DefaultIDAttribute))
+
+	// default depth only includes direct children
+	node := getEntityTree(t, ctx, map[string]interface{}{"id": "domain:P"})
+	require.Len(t, node.Children, 1)
+	assert.Equal(t, "group:P-1", node.Children[0].ID)
+	assert.Empty(t, node.Children[0].Children)
+
+	node = getEntityTree(t, ctx, map[string]interface{}{"id": "domain:P", "depth": float64(3)})
+	require.Len(t, node.Children, 1)
+	require.Len(t, node.Children[0].Children, 1)
+	assert.Equal(t, "category:P-1-1", node.Children[0].Children[0].ID)
+	assert.Empty(t, node.Children[0].Children[0].Children)
+}
+
+func TestToolGetEntity_DepthCycle(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index = &EntityIndex{
+		Entities: map[string]*Entity{
+			"node:a": {ID: "node:a", Type: "node", ParentID: "node:b"},
+			"node:b": {ID: "node:b", Type: "node", ParentID: "node:a"},
+		},
+		ByParent: map[string][]string{"node:a": {"node:b"}, "node:b": {"node:a"}},
+	}
+
+	node := getEntityTree(t, ctx, map[string]interface{}{"id": "node:a", "depth": float64(10)})
+	require.Len(t, node.Children, 1)
+	assert.Equal(t, "node:b", node.Children[0].ID)
+	assert.Empty(t, node.Children[0].Children)
+}
+
+func TestToolGetEntity_CodeWithColons(t *testing.T) {
+	xmlData := []byte(`<?xml version="1.0"?>
+<register>
+  <group code="P:1">
+    <item code="P:1:13" name="Thirteen"/>
+    <item code="50%:1" name="Percent"/>
+  </group>
+</register>`)
+	ctx := newTestToolContext()
+	ctx.Index = &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+	require.NoError(t, parseXMLEntities(xmlData, ctx.Index, "code"))
+
+	item := ctx.Index.Entities["item:P%3A1%3A13"]
+	require.NotNil(t, item)
+	assert.Equal(t, "group:P%3A1", item.ParentID)
+	assert.Equal(t, []string{"item:P%3A1%3A13", "item:50%25%3A1"}, ctx.Index.ByParent["group:P%3A1"])
+
+	entityType, code, ok := ParseEntityID(item.ID)
+	assert.True(t, ok)
+	assert.Equal(t, "item", entityType)
+	assert.Equal(t, "P:1:13", code)
+	entityType, code, ok = ParseEntityID("item:50%25%3A1")
+	assert.True(t, ok)
+	assert.Equal(t, "item", entityType)
+	assert.Equal(t, "50%:1", code)
+
+	getID := func(t *testing.T, id string) string {
+		result, err := toolGetEntity(ctx, map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var entity struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &entity))
+		return entity.ID
+	}
+
+	// escaped IDs, unescaped codes and bare codes all find the entity
+	assert.Equal(t, "item:P%3A1%3A13", getID(t, "item:P%3A1%3A13"))
+	assert.Equal(t, "item:P%3A1%3A13", getID(t, "item:P%3a1%3a13"))
+	assert.Equal(t, "item:P%3A1%3A13", getID(t, "item:P:1:13"))
+	assert.Equal(t, "item:P%3A1%3A13", getID(t, "P:1:13"))
+	assert.Equal(t, "item:50%25%3A1", getID(t, "item:50%:1"))
+	assert.Equal(t, "group:P%3A1", getID(t, "group:P:1"))
+
+	result, err := toolGetEntity(ctx, map[string]interface{}{"id": "group:P:1:13"})
+	require.NoError(t, err)
+	assert.Equal(t, ToolErrorNotFound, requireToolError(t, result).Code)
+}
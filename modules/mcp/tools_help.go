@@ -3,7 +3,11 @@
 
 package mcp
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
 
 func toolHelp(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	help := fmt.Sprintf(`# %s — MCP Server
@@ -18,12 +22,17 @@ This is a read-only MCP server for a ProcessGit repository. It exposes structure
 
 1. **help** — You are here. Describes the server and its tools.
 2. **identify** — Server identity and metadata.
-3. **describe_model** — Data model overview: what entity types exist, their attributes, hierarchy, and counts. Call this to understand the data structure.
-4. **search** — Full-text search across all entities. Search by name, code, registration number, or any attribute. Example: search(query="kanceleja") or search(query="90000038578").
-5. **get_entity** — Get full details for one entity by ID. IDs are formatted as "type:code", e.g., "ministry:01" or "organization:0001".
-6. **list_entities** — List all entities, filter by type or parent. Example: list_entities(type="ministry") or list_entities(type="organization", parent="ministry:13").
-7. **validate** — Check data validity and get statistics.
-8. **generate_document** — Generate a formatted Markdown table of the register. Can generate the full register or a filtered subset.
+3. **describe_model** — Data model overview: what entity types exist, their attributes, hierarchy (with the top-level types to start from), and counts. Call this to understand the data structure.
+4. **search** — Full-text search across all entities. Search by name, code, registration number, or any attribute. Prefix the query with a field to scope it, e.g. name:, code:, nmr: or id:. Each result has a "path" of its ancestors from the root down, unless include_path=false. Example: search(query="kanceleja") or search(query="nmr:90000038578").
+5. **get_entity** — Get full details for one entity by ID. IDs are formatted as "type:code", e.g., "ministry:01" or "organization:0001"; a colon in the code is escaped as %%3A and a percent sign as %%25, e.g. "item:P%%3A1%%3A13" for code "P:1:13". A bare code or registration number also works when it identifies a single entity. Pass depth>1 to embed the whole subtree, e.g. get_entity(id="ministry:13", depth=2).
+6. **list_entities** — List entities sorted by ID, filter by type or parent. Results are paged (limit/offset, default 100). Each entity has a children_count of its direct children. Example: list_entities(type="ministry") or list_entities(type="organization", parent="ministry:13").
+7. **count** — Count entities by type and/or parent without fetching them, plus the grand total. Example: count(type="organization", parent="ministry:13").
+8. **find_by_attribute** — Find the entities whose attribute exactly equals a value, optionally of one type. Unlike search, there are no partial matches. Example: find_by_attribute(attribute="nmr", value="90000038578").
+9. **list_sources** — List the data sources with their declared schemas and whether each schema file exists.
+10. **read_source** — Raw content of a source file listed by list_sources, e.g. to quote an exact element. Example: read_source(path="data/register.xml").
+11. **validate** — Check data validity and get statistics. Pass source to check only one source, e.g. validate(source="data/register.xml").
+12. **generate_document** — Generate a formatted Markdown table of the register. Can generate the full register or a filtered subset.
+13. **diff** — Compare the register between two git refs. Example: diff(base_ref="main~1", head_ref="main").
 
 ## Recommended workflow
 
@@ -48,8 +57,19 @@ This server exposes %d declared source(s):
 		help += "\n"
 	}
 
-	if ctx.Config.Server.Instructions != "" {
-		help += "\n## Additional instructions\n\n" + ctx.Config.Server.Instructions + "\n"
+	var disabled []string
+	for name := range toolRegistry {
+		if !ctx.Config.ToolEnabled(name) {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(disabled) > 0 {
+		slices.Sort(disabled)
+		help += fmt.Sprintf("\n## Disabled tools\n\nThe operator has disabled %s on this server; they are not available.\n", strings.Join(disabled, ", "))
+	}
+
+	if instructions := joinInstructions(ctx.Config.Server.Instructions, readInstructionsFile(ctx.Commit, ctx.Config)); instructions != "" {
+		help += "\n## Additional instructions\n\n" + instructions + "\n"
 	}
 
 	return textResult(help), nil
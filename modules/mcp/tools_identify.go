@@ -22,5 +22,5 @@ func toolIdentify(ctx *ToolContext, args map[string]interface{}) (*ToolCallResul
 		},
 		"sources": ctx.Config.Sources,
 	}
-	return jsonTextResult(result)
+	return jsonTextResult(ctx, result)
 }
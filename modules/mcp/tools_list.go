@@ -3,12 +3,26 @@
 
 package mcp
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
+
+const listEntitiesDefaultLimit = 100
+
+// listedEntity is an entity listed by list_entities with the number of its direct children,
+// so agents can tell which entities are worth drilling into.
+type listedEntity struct {
+	*Entity
+	ChildrenCount int `json:"children_count"`
+}
 
 func toolListEntities(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	typeFilter, _ := args["type"].(string)
 	parentFilter, _ := args["parent"].(string)
 
+	limit := resultLimit(args, listEntitiesDefaultLimit)
+
 	var results []*Entity
 
 	if parentFilter != "" {
@@ -33,7 +47,8 @@ func toolListEntities(ctx *ToolContext, args map[string]interface{}) (*ToolCallR
 			for t := range ctx.Index.ByType {
 				types = append(types, t)
 			}
-			return textResult(fmt.Sprintf("Unknown type '%s'. Available types: %v", typeFilter, types)), nil
+			sort.Strings(types)
+			return toolError(ToolErrorUnknownType, fmt.Sprintf("Unknown type '%s'. Available types: %v", typeFilter, types)), nil
 		}
 		for _, id := range ids {
 			if entity, ok := ctx.Index.Entities[id]; ok {
@@ -47,9 +62,28 @@ func toolListEntities(ctx *ToolContext, args map[string]interface{}) (*ToolCallR
 		}
 	}
 
-	return jsonTextResult(map[string]interface{}{
-		"count":    len(results),
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	total := len(results)
+	// clamped to total while still a float64, a huge offset would overflow int
+	offset := 0
+	if o, ok := args["offset"].(float64); ok && o > 0 {
+		offset = total
+		if o < float64(total) {
+			offset = int(o)
+		}
+	}
+	page := make([]listedEntity, 0, min(limit, max(total-offset, 0)))
+	for _, entity := range results[min(offset, total):min(offset+limit, total)] {
+		page = append(page, listedEntity{Entity: entity, ChildrenCount: len(ctx.Index.ByParent[entity.ID])})
+	}
+
+	return jsonTextResult(ctx, map[string]interface{}{
+		"count":    len(page),
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
 		"filters":  map[string]interface{}{"type": typeFilter, "parent": parentFilter},
-		"entities": results,
+		"entities": page,
 	})
 }
@@ -0,0 +1,149 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listEntitiesResult struct {
+	Count    int       `json:"count"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	Entities []*Entity `json:"entities"`
+}
+
+func newListTestToolContext(n int) *ToolContext {
+	ctx := newTestToolContext()
+	ctx.Index.Entities = make(map[string]*Entity)
+	ctx.Index.ByType = make(map[string][]string)
+	// insert in reverse order so sorting is actually exercised
+	for i := n - 1; i >= 0; i-- {
+		id := fmt.Sprintf("item:%03d", i)
+		ctx.Index.Entities[id] = &Entity{ID: id, Type: "item"}
+		ctx.Index.ByType["item"] = append(ctx.Index.ByType["item"], id)
+	}
+	return ctx
+}
+
+func callListEntities(t *testing.T, ctx *ToolContext, args map[string]interface{}) listEntitiesResult {
+	result, err := toolListEntities(ctx, args)
+	require.NoError(t, err)
+	var out listEntitiesResult
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+	return out
+}
+
+func TestToolListEntities_SortedAndPaged(t *testing.T) {
+	ctx := newListTestToolContext(250)
+
+	out := callListEntities(t, ctx, map[string]interface{}{})
+	assert.Equal(t, 250, out.Total)
+	assert.Equal(t, listEntitiesDefaultLimit, out.Count)
+	assert.Equal(t, "item:000", out.Entities[0].ID)
+	assert.Equal(t, "item:099", out.Entities[99].ID)
+
+	out = callListEntities(t, ctx, map[string]interface{}{"type": "item", "limit": float64(10), "offset": float64(245)})
+	assert.Equal(t, 250, out.Total)
+	assert.Equal(t, 5, out.Count)
+	assert.Equal(t, 245, out.Offset)
+	assert.Equal(t, "item:245", out.Entities[0].ID)
+	assert.Equal(t, "item:249", out.Entities[4].ID)
+
+	out = callListEntities(t, ctx, map[string]interface{}{"offset": float64(1000)})
+	assert.Equal(t, 250, out.Total)
+	assert.Zero(t, out.Count)
+
+	out = callListEntities(t, ctx, map[string]interface{}{"limit": float64(10000)})
+	assert.Equal(t, setting.MCP.MaxResultLimit, out.Limit)
+
+	// values beyond the range of int must not overflow
+	out = callListEntities(t, ctx, map[string]interface{}{"offset": float64(1e19), "limit": float64(1e19)})
+	assert.Equal(t, 250, out.Total)
+	assert.Zero(t, out.Count)
+	assert.Equal(t, setting.MCP.MaxResultLimit, out.Limit)
+
+	out = callListEntities(t, ctx, map[string]interface{}{"offset": float64(240), "limit": float64(1e19)})
+	assert.Equal(t, 10, out.Count)
+}
+
+func TestResultLimit_ClampedToMax(t *testing.T) {
+	defer test.MockVariableValue(&setting.MCP.MaxResultLimit, 20)()
+	ctx := newListTestToolContext(50)
+
+	out := callListEntities(t, ctx, map[string]interface{}{"limit": float64(30)})
+	assert.Equal(t, 20, out.Limit)
+	assert.Equal(t, 20, out.Count)
+	assert.Equal(t, 50, out.Total)
+
+	result, err := toolSearch(ctx, map[string]interface{}{"query": "item", "limit": float64(30)})
+	require.NoError(t, err)
+	var search struct {
+		Count int `json:"count"`
+		Limit int `json:"limit"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &search))
+	assert.Equal(t, 20, search.Limit)
+	assert.Equal(t, 20, search.Count)
+
+	// limits within the cap and the defaults are kept
+	assert.Equal(t, 10, resultLimit(map[string]interface{}{"limit": float64(10)}, 5))
+	assert.Equal(t, 5, resultLimit(map[string]interface{}{"limit": float64(-1)}, 5))
+	assert.Equal(t, 20, resultLimit(map[string]interface{}{}, 25))
+
+	// values too large for an int are clamped, not overflowed
+	assert.Equal(t, 20, resultLimit(map[string]interface{}{"limit": float64(1e19)}, 5))
+	assert.Equal(t, 1, resultLimit(map[string]interface{}{"limit": 0.5}, 5))
+}
+
+func TestToolListEntities_HelperMessages(t *testing.T) {
+	ctx := newTestToolContext()
+
+	result, err := toolListEntities(ctx, map[string]interface{}{"type": "nope"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].Text, "Unknown type 'nope'. Available types: [item]")
+
+	result, err = toolListEntities(ctx, map[string]interface{}{"parent": "item:99"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].Text, "No children found for parent 'item:99'.")
+}
+
+func TestToolListEntities_ChildrenCount(t *testing.T) {
+	ctx := newCountTestToolContext()
+
+	result, err := toolListEntities(ctx, map[string]interface{}{"type": "ministry"})
+	require.NoError(t, err)
+	var out struct {
+		Entities []struct {
+			ID            string `json:"id"`
+			Type          string `json:"type"`
+			ChildrenCount int    `json:"children_count"`
+		} `json:"entities"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+	require.Len(t, out.Entities, 2)
+	assert.Equal(t, "ministry:13", out.Entities[0].ID)
+	assert.Equal(t, "ministry", out.Entities[0].Type)
+	assert.Equal(t, 3, out.Entities[0].ChildrenCount)
+	assert.Equal(t, 1, out.Entities[1].ChildrenCount)
+
+	// leaves report zero instead of omitting the count
+	result, err = toolListEntities(ctx, map[string]interface{}{"parent": "ministry:14"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].Text, `"children_count":0`)
+
+	// search keeps its shape
+	result, err = toolSearch(ctx, map[string]interface{}{"query": "ministry"})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content[0].Text, "children_count")
+}
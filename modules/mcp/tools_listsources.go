@@ -0,0 +1,35 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+// SourceInfo describes a configured data source in the list_sources result.
+type SourceInfo struct {
+	Path          string `json:"path"`
+	Type          string `json:"type"`
+	Description   string `json:"description,omitempty"`
+	Schema        string `json:"schema,omitempty"`
+	SchemaPresent bool   `json:"schema_present"`
+}
+
+func toolListSources(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+	sources := make([]SourceInfo, 0, len(ctx.Config.Sources))
+	for _, source := range ctx.Config.Sources {
+		info := SourceInfo{
+			Path:        source.Path,
+			Type:        source.Type,
+			Description: source.Description,
+			Schema:      source.Schema,
+		}
+		if source.Schema != "" && ctx.Commit != nil {
+			_, err := ctx.Commit.GetTreeEntryByPath(source.Schema)
+			info.SchemaPresent = err == nil
+		}
+		sources = append(sources, info)
+	}
+
+	return jsonTextResult(ctx, map[string]interface{}{
+		"count":   len(sources),
+		"sources": sources,
+	})
+}
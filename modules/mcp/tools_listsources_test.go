@@ -0,0 +1,46 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolListSources(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Commit = newTestCommit(t, map[string]string{
+		"data/orgs.xml":     "<orgs/>",
+		"schemas/orgs.xsd":  `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`,
+		"data/people.json":  "[]",
+		"data/budgets.json": "[]",
+	})
+	ctx.Config.Sources = []MCPSource{
+		{Path: "data/orgs.xml", Type: "xml", Description: "Organizations", Schema: "schemas/orgs.xsd"},
+		{Path: "data/people.json", Type: "json", Schema: "schemas/people.schema.json"},
+		{Path: "data/budgets.json", Type: "json"},
+	}
+	// list_sources describes the config and works without indexed data
+	ctx.Index = nil
+
+	result, err := ExecuteTool(ctx, "list_sources", nil)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var body struct {
+		Count   int          `json:"count"`
+		Sources []SourceInfo `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &body))
+	assert.Equal(t, 3, body.Count)
+	assert.Equal(t, []SourceInfo{
+		{Path: "data/orgs.xml", Type: "xml", Description: "Organizations", Schema: "schemas/orgs.xsd", SchemaPresent: true},
+		{Path: "data/people.json", Type: "json", Schema: "schemas/people.schema.json", SchemaPresent: false},
+		{Path: "data/budgets.json", Type: "json", SchemaPresent: false},
+	}, body.Sources)
+}
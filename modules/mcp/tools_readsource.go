@@ -0,0 +1,42 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"slices"
+)
+
+// toolReadSource returns the raw content of a configured source file. Only the paths declared
+// as sources can be read, so the tool can't be used to read arbitrary repository files.
+func toolReadSource(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return toolError(ToolErrorMissingArgument, "The path argument is required."), nil
+	}
+	if !slices.ContainsFunc(ctx.Config.Sources, func(source MCPSource) bool { return source.Path == path }) {
+		paths := make([]string, 0, len(ctx.Config.Sources))
+		for _, source := range ctx.Config.Sources {
+			paths = append(paths, source.Path)
+		}
+		return toolError(ToolErrorUnknownSource, fmt.Sprintf("'%s' is not a configured source. Configured sources: %v", path, paths)), nil
+	}
+	if ctx.Commit == nil {
+		return toolError(ToolErrorRepositoryUnavailable, "The repository is not available for reading sources."), nil
+	}
+
+	entry, err := ctx.Commit.GetTreeEntryByPath(path)
+	if err != nil {
+		return toolError(ToolErrorSourceUnreadable, fmt.Sprintf("Cannot read %s: %s", path, err.Error())), nil
+	}
+	// checked before reading so a large source isn't loaded only to be rejected
+	if size := entry.Blob().Size(); size > MaxInlineDocumentSize {
+		return toolError(ToolErrorDocumentTooLarge, fmt.Sprintf("%s is %d bytes, more than the %d bytes returned inline. Use get_entity or search for the parts you need.", path, size, MaxInlineDocumentSize)), nil
+	}
+	content, err := ReadFileContent(ctx.Commit, path)
+	if err != nil {
+		return toolError(ToolErrorSourceUnreadable, fmt.Sprintf("Cannot read %s: %s", path, err.Error())), nil
+	}
+	return textResult(string(content)), nil
+}
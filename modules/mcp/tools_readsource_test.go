@@ -0,0 +1,54 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolReadSource(t *testing.T) {
+	const register = `<register><ministry code="01" name="One"/></register>`
+	ctx := newTestToolContext()
+	ctx.Commit = newTestCommit(t, map[string]string{
+		"data/register.xml": register,
+		"data/large.xml":    "<register>" + strings.Repeat(" ", MaxInlineDocumentSize) + "</register>",
+		"secrets.env":       "API_KEY=secret",
+	})
+	ctx.Config.Sources = []MCPSource{
+		{Path: "data/register.xml", Type: "xml"},
+		{Path: "data/large.xml", Type: "xml"},
+		{Path: "data/missing.xml", Type: "xml"},
+	}
+
+	result, err := ExecuteTool(ctx, "read_source", map[string]interface{}{"path": "data/register.xml"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, register, result.Content[0].Text)
+
+	t.Run("UndeclaredPath", func(t *testing.T) {
+		for _, path := range []string{"secrets.env", "./data/register.xml", "data/../secrets.env"} {
+			result, err := ExecuteTool(ctx, "read_source", map[string]interface{}{"path": path})
+			require.NoError(t, err)
+			toolErr := requireToolError(t, result)
+			assert.Equal(t, ToolErrorUnknownSource, toolErr.Code, path)
+			assert.NotContains(t, toolErr.Message, "API_KEY")
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		result, err := ExecuteTool(ctx, "read_source", map[string]interface{}{"path": "data/large.xml"})
+		require.NoError(t, err)
+		assert.Equal(t, ToolErrorDocumentTooLarge, requireToolError(t, result).Code)
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		result, err := ExecuteTool(ctx, "read_source", map[string]interface{}{"path": "data/missing.xml"})
+		require.NoError(t, err)
+		assert.Equal(t, ToolErrorSourceUnreadable, requireToolError(t, result).Code)
+	})
+}
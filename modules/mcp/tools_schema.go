@@ -0,0 +1,83 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// toolInputSchema returns the InputSchema declared for the named tool, or nil if there is none.
+func toolInputSchema(cfg *MCPConfig, name string) map[string]interface{} {
+	if cfg == nil {
+		return nil
+	}
+	for _, def := range GetToolDefinitions(cfg) {
+		if def.Name == name {
+			schema, _ := def.InputSchema.(map[string]interface{})
+			return schema
+		}
+	}
+	return nil
+}
+
+// validateToolArguments checks args against the required properties and property types of an
+// object schema. Properties the schema doesn't declare are allowed.
+func validateToolArguments(schema, args map[string]interface{}) error {
+	if schema == nil {
+		return nil
+	}
+
+	required, _ := schema["required"].([]string)
+	for _, name := range required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("'%s' is required", name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	// check in a stable order so the reported error doesn't vary between calls
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		property, _ := properties[name].(map[string]interface{})
+		expected, _ := property["type"].(string)
+		if expected != "" && !matchesJSONType(args[name], expected) {
+			return fmt.Errorf("'%s' must be of type %s", name, expected)
+		}
+	}
+	return nil
+}
+
+// matchesJSONType reports whether a decoded JSON value has the given JSON Schema type.
+func matchesJSONType(value interface{}, jsonType string) bool {
+	switch jsonType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateToolArguments(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"id"},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "string"},
+			"depth": map[string]interface{}{"type": "integer"},
+			"tags":  map[string]interface{}{"type": "array"},
+			"flag":  map[string]interface{}{"type": "boolean"},
+		},
+	}
+
+	assert.NoError(t, validateToolArguments(schema, map[string]interface{}{"id": "a:1", "depth": float64(2), "tags": []interface{}{"x"}, "flag": false}))
+	assert.NoError(t, validateToolArguments(nil, nil))
+	assert.EqualError(t, validateToolArguments(schema, nil), "'id' is required")
+	assert.EqualError(t, validateToolArguments(schema, map[string]interface{}{"id": float64(1)}), "'id' must be of type string")
+	assert.EqualError(t, validateToolArguments(schema, map[string]interface{}{"id": "a:1", "depth": 1.5}), "'depth' must be of type integer")
+	assert.EqualError(t, validateToolArguments(schema, map[string]interface{}{"id": "a:1", "tags": "x"}), "'tags' must be of type array")
+}
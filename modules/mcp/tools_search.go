@@ -5,21 +5,31 @@ package mcp
 
 import "fmt"
 
+const searchDefaultLimit = 25
+
+// searchResult is an entity found by search with the chain of its ancestors.
+type searchResult struct {
+	*Entity
+	// Path lists the ancestors from the root down to the parent, absent for top-level entities.
+	Path []entityRef `json:"path,omitempty"`
+}
+
+// entityRef is the compact form of an entity used in search result paths.
+type entityRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
 func toolSearch(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
 	query, _ := args["query"].(string)
 	if query == "" {
-		return &ToolCallResult{
-			Content: []ToolContent{{Type: "text", Text: "Error: 'query' parameter is required"}},
-			IsError: true,
-		}, nil
+		return toolError(ToolErrorMissingArgument, "'query' parameter is required"), nil
 	}
 
-	limit := 25
-	if l, ok := args["limit"].(float64); ok && l > 0 {
-		limit = int(l)
-		if limit > 100 {
-			limit = 100
-		}
+	limit := resultLimit(args, searchDefaultLimit)
+	includePath := true
+	if v, ok := args["include_path"].(bool); ok {
+		includePath = v
 	}
 
 	results := ctx.Index.SearchEntities(query, limit)
@@ -28,9 +38,23 @@ func toolSearch(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult,
 		return textResult(fmt.Sprintf("No entities found matching '%s'.", query)), nil
 	}
 
-	return jsonTextResult(map[string]interface{}{
+	var data interface{} = results
+	if includePath {
+		withPaths := make([]searchResult, 0, len(results))
+		for _, entity := range results {
+			result := searchResult{Entity: entity}
+			for _, ancestor := range ctx.Index.ancestors(entity.ID) {
+				result.Path = append(result.Path, entityRef{ID: ancestor.ID, Name: ancestor.Name})
+			}
+			withPaths = append(withPaths, result)
+		}
+		data = withPaths
+	}
+
+	return jsonTextResult(ctx, map[string]interface{}{
 		"query":   query,
 		"count":   len(results),
-		"results": results,
+		"limit":   limit,
+		"results": data,
 	})
 }
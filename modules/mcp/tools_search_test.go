@@ -0,0 +1,84 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolSearch_Path(t *testing.T) {
+	xmlData := []byte(`<?xml version="1.0"?>
+<register>
+  <domain code="P" name="Public administration">
+    <group code="P-1" name="Governance">
+      <category code="P-1-13" name="Records retention"/>
+    </group>
+  </domain>
+</register>`)
+	ctx := newTestToolContext()
+	ctx.Index = &EntityIndex{
+		Entities: make(map[string]*Entity),
+		ByType:   make(map[string][]string),
+		ByParent: make(map[string][]string),
+		Stats:    IndexStats{TypeCounts: make(map[string]int)},
+	}
+	require.NoError(t, parseXMLEntities(xmlData, ctx.Index, "code"))
+
+	type result struct {
+		ID   string            `json:"id"`
+		Name string            `json:"name"`
+		Path []entityRef       `json:"path"`
+		Raw  map[string]string `json:"attributes"`
+	}
+	search := func(t *testing.T, args map[string]interface{}) []result {
+		res, err := toolSearch(ctx, args)
+		require.NoError(t, err)
+		require.False(t, res.IsError, res.Content[0].Text)
+		var out struct {
+			Results []result `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].Text), &out))
+		return out.Results
+	}
+
+	results := search(t, map[string]interface{}{"query": "code:p-1-13"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "category:P-1-13", results[0].ID)
+	assert.Equal(t, "Records retention", results[0].Name)
+	assert.Equal(t, "P-1-13", results[0].Raw["code"])
+	assert.Equal(t, []entityRef{
+		{ID: "domain:P", Name: "Public administration"},
+		{ID: "group:P-1", Name: "Governance"},
+	}, results[0].Path)
+
+	// top-level entities have no path
+	results = search(t, map[string]interface{}{"query": "id:domain:p"})
+	require.Len(t, results, 1)
+	assert.Nil(t, results[0].Path)
+
+	results = search(t, map[string]interface{}{"query": "code:p-1-13", "include_path": false})
+	require.Len(t, results, 1)
+	assert.Equal(t, "category:P-1-13", results[0].ID)
+	assert.Nil(t, results[0].Path)
+}
+
+func TestEntityIndexAncestors_Cycle(t *testing.T) {
+	idx := &EntityIndex{Entities: map[string]*Entity{
+		"a:1": {ID: "a:1", ParentID: "a:2"},
+		"a:2": {ID: "a:2", ParentID: "a:3"},
+		"a:3": {ID: "a:3", ParentID: "a:1"},
+		"b:1": {ID: "b:1", ParentID: "missing:1"},
+	}}
+	var ids []string
+	for _, ancestor := range idx.ancestors("a:1") {
+		ids = append(ids, ancestor.ID)
+	}
+	assert.Equal(t, []string{"a:3", "a:2"}, ids)
+	assert.Empty(t, idx.ancestors("b:1"))
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bytes"
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityToolsWithoutIndexedData(t *testing.T) {
+	tools := map[string]map[string]interface{}{
+		"describe_model":    {},
+		"search":            {"query": "test"},
+		"get_entity":        {"id": "item:01"},
+		"list_entities":     {},
+		"find_by_attribute": {"attribute": "code", "value": "01"},
+		"generate_document": {},
+	}
+
+	indexes := map[string]*EntityIndex{
+		"nil":   nil,
+		"empty": {Entities: map[string]*Entity{}, ByType: map[string][]string{}, ByParent: map[string][]string{}},
+	}
+
+	for indexName, index := range indexes {
+		for name, args := range tools {
+			t.Run(indexName+"/"+name, func(t *testing.T) {
+				ctx := newTestToolContext()
+				ctx.Index = index
+
+				result, err := ExecuteTool(ctx, name, args)
+				require.NoError(t, err)
+				assert.Equal(t, ToolError{Code: ToolErrorNoIndexedData, Message: "no data indexed for this repository"}, requireToolError(t, result))
+			})
+		}
+	}
+}
+
+// requireToolError asserts that result is a failed tool call and returns its error.
+func requireToolError(t *testing.T, result *ToolCallResult) ToolError {
+	t.Helper()
+	require.True(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	var body struct {
+		Error ToolError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &body))
+	return body.Error
+}
+
+func TestToolErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		code string
+	}{
+		{"UnknownFormat", "generate_document", map[string]interface{}{"format": "pdf"}, ToolErrorUnknownFormat},
+		{"MissingID", "get_entity", map[string]interface{}{}, ToolErrorMissingArgument},
+		{"UnknownID", "get_entity", map[string]interface{}{"id": "nothing-like-this"}, ToolErrorNotFound},
+		{"MissingQuery", "search", map[string]interface{}{}, ToolErrorMissingArgument},
+		{"UnknownType", "list_entities", map[string]interface{}{"type": "planet"}, ToolErrorUnknownType},
+		{"MissingValue", "find_by_attribute", map[string]interface{}{"attribute": "nmr"}, ToolErrorMissingArgument},
+		{"MissingRefs", "diff", map[string]interface{}{"base_ref": "main"}, ToolErrorMissingArgument},
+		{"UnknownTool", "nonexistent", map[string]interface{}{}, ToolErrorUnknownTool},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExecuteTool(newTestToolContext(), tt.tool, tt.args)
+			require.NoError(t, err)
+			toolErr := requireToolError(t, result)
+			assert.Equal(t, tt.code, toolErr.Code)
+			assert.NotEmpty(t, toolErr.Message)
+		})
+	}
+
+	result, err := ExecuteTool(newTestToolContext(), "generate_document", map[string]interface{}{"format": "pdf"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"error": {"code": "unknown_format", "message": "Unknown format 'pdf'. Use 'markdown' or 'csv'."}}`, result.Content[0].Text)
+}
+
+func TestSearchEntities_NilIndex(t *testing.T) {
+	var index *EntityIndex
+	assert.Empty(t, index.SearchEntities("test", 10))
+}
+
+func TestWriteDocument_NilIndex(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Index = nil
+
+	var buf bytes.Buffer
+	assert.ErrorIs(t, WriteDocument(&buf, ctx, "markdown", "", ""), ErrNoIndexedData)
+	assert.Empty(t, buf.String())
+}
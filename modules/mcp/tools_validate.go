@@ -3,21 +3,49 @@
 
 package mcp
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
 
 func toolValidate(ctx *ToolContext, args map[string]interface{}) (*ToolCallResult, error) {
-	var allErrors []string
+	var allErrors []ValidationError
 	var allStats IndexStats
 	allStats.TypeCounts = make(map[string]int)
 	allValid := true
 
-	for _, source := range ctx.Config.Sources {
-		valid, errors, stats, err := ValidateXMLAgainstXSD(ctx.Commit, source)
+	// validation still reports parse errors when no entities could be indexed
+	index := ctx.Index
+	if index == nil {
+		index = &EntityIndex{}
+	}
+
+	// an optional source restricts schema validation, statistics and reference checks to that
+	// one source; uniqueness and completeness are still checked across the whole index
+	sources := ctx.Config.Sources
+	target, _ := args["source"].(string)
+	if target != "" {
+		idx := slices.IndexFunc(sources, func(source MCPSource) bool { return source.Path == target })
+		if idx < 0 {
+			paths := make([]string, 0, len(sources))
+			for _, source := range sources {
+				paths = append(paths, source.Path)
+			}
+			return toolError(ToolErrorUnknownSource, fmt.Sprintf("Unknown source '%s'. Configured sources: %s", target, strings.Join(paths, ", "))), nil
+		}
+		sources = sources[idx : idx+1]
+	}
+
+	for _, source := range sources {
+		validateSource := ValidateXMLAgainstXSD
+		if source.Type == "json" {
+			validateSource = ValidateJSONSource
+		}
+		valid, errors, stats, err := validateSource(ctx.Commit, source)
 		if err != nil {
-			return &ToolCallResult{
-				Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("Validation error for %s: %s", source.Path, err.Error())}},
-				IsError: true,
-			}, nil
+			return toolError(ToolErrorSourceUnreadable, fmt.Sprintf("Validation error for %s: %s", source.Path, err.Error())), nil
 		}
 		if !valid {
 			allValid = false
@@ -30,13 +58,13 @@ func toolValidate(ctx *ToolContext, args map[string]interface{}) (*ToolCallResul
 	}
 
 	// Check for unique constraint violations
-	nmrSeen := make(map[string]string)        // nmr -> entityID
+	nmrSeen := make(map[string]string)           // nmr -> entityID
 	codeSeen := make(map[string]map[string]bool) // type -> set of codes
-	for _, entity := range ctx.Index.Entities {
+	for _, entity := range index.Entities {
 		// Check NMR uniqueness
 		if nmr, ok := entity.Attributes["nmr"]; ok && nmr != "" {
 			if existing, dup := nmrSeen[nmr]; dup {
-				allErrors = append(allErrors, fmt.Sprintf("Duplicate NMR %s: %s and %s", nmr, existing, entity.ID))
+				allErrors = append(allErrors, ValidationError{Message: fmt.Sprintf("Duplicate NMR %s: %s and %s", nmr, existing, entity.ID)})
 				allValid = false
 			}
 			nmrSeen[nmr] = entity.ID
@@ -48,27 +76,125 @@ func toolValidate(ctx *ToolContext, args map[string]interface{}) (*ToolCallResul
 		code := entity.Attributes["code"]
 		if code != "" {
 			if codeSeen[entity.Type][code] {
-				allErrors = append(allErrors, fmt.Sprintf("Duplicate %s code: %s", entity.Type, code))
+				allErrors = append(allErrors, ValidationError{Message: fmt.Sprintf("Duplicate %s code: %s", entity.Type, code)})
 				allValid = false
 			}
 			codeSeen[entity.Type][code] = true
 		}
 	}
 
+	// Check that declared reference attributes point at existing entities
+	for _, source := range sources {
+		if refErrors := checkReferences(index, source); len(refErrors) > 0 {
+			allErrors = append(allErrors, refErrors...)
+			allValid = false
+		}
+	}
+
 	result := map[string]interface{}{
 		"valid":  allValid,
 		"errors": allErrors,
 		"statistics": map[string]interface{}{
 			"total_entities": allStats.TotalEntities,
 			"by_type":        allStats.TypeCounts,
+			"completeness":   attributeCompleteness(index),
 		},
 	}
 
-	if len(ctx.Config.Sources) > 0 {
-		if schema := ctx.Config.Sources[0].Schema; schema != "" {
+	if target != "" {
+		result["source"] = target
+	}
+	if len(sources) > 0 {
+		if schema := sources[0].Schema; schema != "" {
 			result["schema"] = schema
 		}
 	}
 
-	return jsonTextResult(result)
+	return jsonTextResult(ctx, result)
+}
+
+// AttributeCompleteness counts the entities of a type that have a non-empty value for an attribute.
+type AttributeCompleteness struct {
+	Present int `json:"present"`
+	Total   int `json:"total"`
+}
+
+// attributeCompleteness returns, per entity type and attribute key seen on any entity of that
+// type, how many entities have a non-empty value, so incomplete fields (e.g. organizations
+// without an nmr) can be spotted.
+func attributeCompleteness(index *EntityIndex) map[string]map[string]AttributeCompleteness {
+	typeTotals := make(map[string]int)
+	present := make(map[string]map[string]int)
+	for _, entity := range index.Entities {
+		typeTotals[entity.Type]++
+		counts, ok := present[entity.Type]
+		if !ok {
+			counts = make(map[string]int)
+			present[entity.Type] = counts
+		}
+		for key, value := range entity.Attributes {
+			// keys with only empty values are still reported, with a zero count
+			n := counts[key]
+			if strings.TrimSpace(value) != "" {
+				n++
+			}
+			counts[key] = n
+		}
+	}
+
+	completeness := make(map[string]map[string]AttributeCompleteness, len(present))
+	for entityType, counts := range present {
+		completeness[entityType] = make(map[string]AttributeCompleteness, len(counts))
+		for key, count := range counts {
+			completeness[entityType][key] = AttributeCompleteness{Present: count, Total: typeTotals[entityType]}
+		}
+	}
+	return completeness
+}
+
+// checkReferences reports every value of the source's reference attributes, on the entities
+// that source defines, that does not resolve to an entity of the expected type. Repeated
+// attributes are checked value by value from MultiAttributes; any other value is one reference,
+// even if it contains a comma.
+func checkReferences(index *EntityIndex, source MCPSource) []ValidationError {
+	if len(source.References) == 0 {
+		return nil
+	}
+
+	// other sources may use the same attribute names for something else
+	var ids []string
+	for id := range index.Entities {
+		if index.Origins[id] == source.Path {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var errors []ValidationError
+	for _, id := range ids {
+		entity := index.Entities[id]
+		for _, ref := range source.References {
+			value, ok := entity.Attributes[ref.Attribute]
+			if !ok {
+				continue
+			}
+			codes, ok := entity.MultiAttributes[ref.Attribute]
+			if !ok {
+				codes = []string{value}
+			}
+			for _, code := range codes {
+				code = strings.TrimSpace(code)
+				if code == "" {
+					continue
+				}
+				if _, ok := index.Entities[EntityID(ref.Type, code)]; !ok {
+					errors = append(errors, ValidationError{
+						Source:  source.Path,
+						Message: fmt.Sprintf("Dangling reference: %s %s=%q does not resolve to a %s", entity.ID, ref.Attribute, code, ref.Type),
+					})
+				}
+			}
+		}
+	}
+	return errors
 }
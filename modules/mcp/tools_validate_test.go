@@ -0,0 +1,181 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReferences(t *testing.T) {
+	index := &EntityIndex{
+		Entities: map[string]*Entity{
+			"department:10": {ID: "department:10", Type: "department", Attributes: map[string]string{"code": "10"}},
+			"category:A": {
+				ID:              "category:A",
+				Type:            "category",
+				Attributes:      map[string]string{"code": "A", "departmentRef": "10, 11"},
+				MultiAttributes: map[string][]string{"departmentRef": {"10", "11"}},
+			},
+			"category:B": {
+				ID:         "category:B",
+				Type:       "category",
+				Attributes: map[string]string{"code": "B", "departmentRef": "10"},
+			},
+			// defined by another source, whose departmentRef means something else
+			"budget:1": {ID: "budget:1", Type: "budget", Attributes: map[string]string{"code": "1", "departmentRef": "99"}},
+		},
+		Origins: map[string]string{
+			"department:10": "data.xml",
+			"category:A":    "data.xml",
+			"category:B":    "data.xml",
+			"budget:1":      "budgets.xml",
+		},
+	}
+	source := MCPSource{
+		Path:       "data.xml",
+		Type:       "xml",
+		References: []MCPReference{{Attribute: "departmentRef", Type: "department"}},
+	}
+
+	errs := checkReferences(index, source)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "data.xml", errs[0].Source)
+	assert.Contains(t, errs[0].Message, "Dangling reference: category:A departmentRef=\"11\"")
+
+	assert.Empty(t, checkReferences(index, MCPSource{Path: "data.xml", Type: "xml"}))
+}
+
+func TestCheckReferences_MultiAttributes(t *testing.T) {
+	index := &EntityIndex{
+		Entities: map[string]*Entity{
+			"department:10, 11": {ID: "department:10, 11", Type: "department", Attributes: map[string]string{"code": "10, 11"}},
+			"category:A": {
+				ID:              "category:A",
+				Type:            "category",
+				Attributes:      map[string]string{"code": "A", "departmentRef": "10, 11, 12"},
+				MultiAttributes: map[string][]string{"departmentRef": {"10, 11", "12"}},
+			},
+		},
+		Origins: map[string]string{"department:10, 11": "data.xml", "category:A": "data.xml"},
+	}
+	source := MCPSource{
+		Path:       "data.xml",
+		Type:       "xml",
+		References: []MCPReference{{Attribute: "departmentRef", Type: "department"}},
+	}
+
+	// individual values are used as-is instead of splitting the joined string
+	errs := checkReferences(index, source)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "Dangling reference: category:A departmentRef=\"12\"")
+}
+
+func TestCheckReferences_CommaInValue(t *testing.T) {
+	index := &EntityIndex{
+		Entities: map[string]*Entity{
+			"department:A, B": {ID: "department:A, B", Type: "department", Attributes: map[string]string{"code": "A, B"}},
+			"category:1": {
+				ID:         "category:1",
+				Type:       "category",
+				Attributes: map[string]string{"code": "1", "departmentRef": "A, B"},
+			},
+			"category:2": {
+				ID:         "category:2",
+				Type:       "category",
+				Attributes: map[string]string{"code": "2", "departmentRef": "A, C"},
+			},
+		},
+		Origins: map[string]string{"department:A, B": "data.xml", "category:1": "data.xml", "category:2": "data.xml"},
+	}
+	source := MCPSource{
+		Path:       "data.xml",
+		Type:       "xml",
+		References: []MCPReference{{Attribute: "departmentRef", Type: "department"}},
+	}
+
+	// a single value is one reference, as for the parser and search
+	errs := checkReferences(index, source)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "Dangling reference: category:2 departmentRef=\"A, C\"")
+}
+
+func TestAttributeCompleteness(t *testing.T) {
+	index := &EntityIndex{
+		Entities: map[string]*Entity{
+			"organization:0001": {ID: "organization:0001", Type: "organization", Attributes: map[string]string{"code": "0001", "nmr": "90000038578"}},
+			"organization:0002": {ID: "organization:0002", Type: "organization", Attributes: map[string]string{"code": "0002", "nmr": " "}},
+			"organization:0003": {ID: "organization:0003", Type: "organization", Attributes: map[string]string{"code": "0003"}},
+			"ministry:01":       {ID: "ministry:01", Type: "ministry", Attributes: map[string]string{"code": "01", "name": ""}},
+		},
+	}
+
+	completeness := attributeCompleteness(index)
+	assert.Equal(t, map[string]map[string]AttributeCompleteness{
+		"organization": {
+			"code": {Present: 3, Total: 3},
+			"nmr":  {Present: 1, Total: 3},
+		},
+		"ministry": {
+			"code": {Present: 1, Total: 1},
+			"name": {Present: 0, Total: 1},
+		},
+	}, completeness)
+}
+
+func TestToolValidate_Source(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Commit = newTestCommit(t, map[string]string{
+		"data/ministries.xml": `<register><ministry code="01" name="One"/><ministry code="02" name="Two"/></register>`,
+		"data/broken.xml":     `<register><ministry code="03"`,
+	})
+	ctx.Config.Sources = []MCPSource{
+		{Path: "data/ministries.xml", Type: "xml"},
+		{Path: "data/broken.xml", Type: "xml"},
+	}
+
+	var body struct {
+		Valid      bool              `json:"valid"`
+		Source     string            `json:"source"`
+		Errors     []ValidationError `json:"errors"`
+		Statistics struct {
+			TotalEntities int `json:"total_entities"`
+		} `json:"statistics"`
+	}
+
+	result, err := ExecuteTool(ctx, "validate", map[string]interface{}{"source": "data/ministries.xml"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &body))
+	assert.True(t, body.Valid)
+	assert.Equal(t, "data/ministries.xml", body.Source)
+	assert.Empty(t, body.Errors)
+	assert.Equal(t, 2, body.Statistics.TotalEntities)
+
+	// without a source every configured source is validated
+	body.Source, body.Errors = "", nil
+	result, err = ExecuteTool(ctx, "validate", nil)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &body))
+	assert.False(t, body.Valid)
+	assert.Empty(t, body.Source)
+	require.NotEmpty(t, body.Errors)
+	assert.Equal(t, "data/broken.xml", body.Errors[0].Source)
+}
+
+func TestToolValidate_UnknownSource(t *testing.T) {
+	ctx := newTestToolContext()
+	ctx.Config.Sources = []MCPSource{{Path: "data/ministries.xml", Type: "xml"}}
+
+	result, err := ExecuteTool(ctx, "validate", map[string]interface{}{"source": "data/missing.xml"})
+	require.NoError(t, err)
+	toolErr := requireToolError(t, result)
+	assert.Equal(t, ToolErrorUnknownSource, toolErr.Code)
+	assert.Contains(t, toolErr.Message, "data/ministries.xml")
+}
@@ -4,25 +4,35 @@
 package mcp
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 )
 
-// MaxRequestBodySize limits the size of incoming MCP requests.
+// MaxRequestBodySize limits the size of incoming MCP requests, after decompression.
 const MaxRequestBodySize = 1024 * 1024 // 1 MB
 
+// gzipMinResponseSize is the smallest response compressed for clients that accept gzip.
+const gzipMinResponseSize = 1024
+
+var errRequestBodyTooLarge = fmt.Errorf("request body exceeds %d bytes", MaxRequestBodySize)
+
 // ServeHTTP handles an MCP HTTP request.
 // Supports both POST (single JSON-RPC request) and GET (SSE streaming).
 func ServeHTTP(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	// Handle CORS preflight
 	if r.Method == http.MethodOptions {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -30,6 +40,10 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	switch r.Method {
 	case http.MethodGet:
 		serveSSE(w, r, toolCtx)
+	case http.MethodHead:
+		// clients probe availability before connecting; advertise the stream without opening a session
+		setSSEHeaders(w)
+		w.WriteHeader(http.StatusOK)
 	case http.MethodPost:
 		handlePost(w, r, toolCtx)
 	default:
@@ -42,7 +56,7 @@ func handlePost(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	// Set CORS headers for browser clients
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 	// Handle preflight OPTIONS request
 	if r.Method == http.MethodOptions {
@@ -72,21 +86,20 @@ func handlePost(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 	}
 
 	// Read body
-	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodySize))
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+	body, ok := readRequestBody(w, r)
+	if !ok {
 		return
 	}
 
 	// Parse as a single JSON-RPC request (batch not supported for MVP)
 	var req JSONRPCRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSONResponse(w, jsonRPCError(nil, -32700, "Parse error: "+err.Error()))
+		writeJSONResponse(w, r, jsonRPCError(nil, -32700, "Parse error: "+err.Error()))
 		return
 	}
 
 	if req.JSONRPC != "2.0" {
-		writeJSONResponse(w, jsonRPCError(req.ID, -32600, "Invalid JSON-RPC version"))
+		writeJSONResponse(w, r, jsonRPCError(req.ID, -32600, "Invalid JSON-RPC version"))
 		return
 	}
 
@@ -98,7 +111,7 @@ func handlePost(w http.ResponseWriter, r *http.Request, toolCtx *ToolContext) {
 		return
 	}
 
-	writeJSONResponse(w, resp)
+	writeJSONResponse(w, r, resp)
 }
 
 // handleSessionMessage routes a POST with Mcp-Session-Id to the correct SSE session.
@@ -117,21 +130,20 @@ func handleSessionMessage(w http.ResponseWriter, r *http.Request, sessionID stri
 	}
 
 	// Read body
-	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodySize))
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+	body, ok := readRequestBody(w, r)
+	if !ok {
 		return
 	}
 
 	// Parse JSON-RPC request
 	var req JSONRPCRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSONResponse(w, jsonRPCError(nil, -32700, "Parse error: "+err.Error()))
+		writeJSONResponse(w, r, jsonRPCError(nil, -32700, "Parse error: "+err.Error()))
 		return
 	}
 
 	if req.JSONRPC != "2.0" {
-		writeJSONResponse(w, jsonRPCError(req.ID, -32600, "Invalid JSON-RPC version"))
+		writeJSONResponse(w, r, jsonRPCError(req.ID, -32600, "Invalid JSON-RPC version"))
 		return
 	}
 
@@ -144,7 +156,66 @@ func handleSessionMessage(w http.ResponseWriter, r *http.Request, sessionID stri
 	w.WriteHeader(http.StatusAccepted)
 }
 
-func writeJSONResponse(w http.ResponseWriter, resp *JSONRPCResponse) {
+// readRequestBody reads the request body, decoding it if it is gzip-compressed. It writes an
+// error response and returns false if the body can't be read or is too large.
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	reader := r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid gzip request body", http.StatusBadRequest)
+			return nil, false
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	default:
+		http.Error(w, "Unsupported Content-Encoding: "+encoding, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	body, err := readLimited(reader, MaxRequestBodySize)
+	if errors.Is(err, errRequestBodyTooLarge) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// readLimited reads r up to limit bytes and fails with errRequestBodyTooLarge if there is more.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, errRequestBodyTooLarge
+	}
+	return body, nil
+}
+
+// acceptsGzip reports whether the client accepts gzip-compressed responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, resp *JSONRPCResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	data, err := json.Marshal(resp)
 	if err != nil {
@@ -152,6 +223,17 @@ func writeJSONResponse(w http.ResponseWriter, resp *JSONRPCResponse) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if len(data) >= gzipMinResponseSize && acceptsGzip(r) {
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		if _, err := gzipWriter.Write(data); err == nil && gzipWriter.Close() == nil {
+			w.Header().Set("Content-Encoding", "gzip")
+			data = buf.Bytes()
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(data)
 }
@@ -6,8 +6,10 @@ package mcp
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -15,6 +17,8 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -67,6 +71,26 @@ func TestServeHTTP_PostPing(t *testing.T) {
 	assert.Nil(t, resp.Error)
 }
 
+func TestServeHTTP_PostIntegerID(t *testing.T) {
+	ctx := newTestToolContext()
+
+	for body, id := range map[string]string{
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`:                `"id":7`,
+		`{"jsonrpc":"2.0","id":9007199254740993,"method":"ping"}`: `"id":9007199254740993`,
+		`{"jsonrpc":"2.0","id":1.5,"method":"ping"}`:              `"id":1.5`,
+		`{"jsonrpc":"2.0","id":"abc","method":"ping"}`:            `"id":"abc"`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		ServeHTTP(w, req, ctx)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), id)
+	}
+}
+
 func TestServeHTTP_MethodNotAllowed(t *testing.T) {
 	ctx := newTestToolContext()
 
@@ -78,6 +102,28 @@ func TestServeHTTP_MethodNotAllowed(t *testing.T) {
 	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
 }
 
+func TestServeHTTP_Head(t *testing.T) {
+	ctx := newTestToolContext()
+	sessionManager.mu.RLock()
+	before := len(sessionManager.sessions)
+	sessionManager.mu.RUnlock()
+
+	req := httptest.NewRequest(http.MethodHead, "/test/repo/mcp", nil)
+	w := httptest.NewRecorder()
+
+	ServeHTTP(w, req, ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "HEAD")
+	assert.Empty(t, w.Header().Get("Mcp-Session-Id"))
+	assert.Empty(t, w.Body.String())
+	sessionManager.mu.RLock()
+	assert.Len(t, sessionManager.sessions, before)
+	sessionManager.mu.RUnlock()
+}
+
 func TestServeHTTP_PostBadContentType(t *testing.T) {
 	ctx := newTestToolContext()
 
@@ -123,6 +169,107 @@ func TestServeHTTP_PostNotification(t *testing.T) {
 
 // --- SSE tests ---
 
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestServeHTTP_PostGzip(t *testing.T) {
+	ctx := newTestToolContext()
+
+	body := gzipBytes(t, []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`))
+	req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	w := httptest.NewRecorder()
+
+	ServeHTTP(w, req, ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	gzipReader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gzipReader)
+	require.NoError(t, err)
+
+	var resp JSONRPCResponse
+	require.NoError(t, json.Unmarshal(data, &resp))
+	assert.Nil(t, resp.Error)
+	assert.EqualValues(t, 3, resp.ID)
+	assert.Contains(t, string(data), "generate_document")
+}
+
+func TestServeHTTP_PostGzipSmallResponse(t *testing.T) {
+	ctx := newTestToolContext()
+
+	req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	ServeHTTP(w, req, ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	var resp JSONRPCResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+}
+
+func TestServeHTTP_PostGzipTooLarge(t *testing.T) {
+	ctx := newTestToolContext()
+
+	// compresses to a few KB but exceeds the limit once decompressed
+	padding := strings.Repeat(" ", MaxRequestBodySize)
+	body := gzipBytes(t, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`+padding))
+	require.Less(t, len(body), MaxRequestBodySize/100)
+
+	req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	ServeHTTP(w, req, ctx)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestServeHTTP_PostUnsupportedEncoding(t *testing.T) {
+	ctx := newTestToolContext()
+
+	req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "br")
+	w := httptest.NewRecorder()
+
+	ServeHTTP(w, req, ctx)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, GZIP":     true,
+		"br, gzip;q=0.5":    true,
+		"gzip;q=0":          false,
+		"gzip; q=0.0, br":   false,
+		"identity, deflate": false,
+	}
+	for header, expected := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", nil)
+		req.Header.Set("Accept-Encoding", header)
+		assert.Equal(t, expected, acceptsGzip(req), "Accept-Encoding: %q", header)
+	}
+}
+
 func TestServeHTTP_SSEConnection(t *testing.T) {
 	toolCtx := newTestToolContext()
 
@@ -321,7 +468,7 @@ func TestSessionManager_MaxSessions(t *testing.T) {
 	mgr := &SSESessionManager{sessions: make(map[string]*SSESession)}
 
 	// Fill to capacity
-	for i := 0; i < maxSessions; i++ {
+	for i := 0; i < setting.MCP.MaxSSESessions; i++ {
 		s := &SSESession{
 			ID:    fmt.Sprintf("session-%d", i),
 			reqCh: make(chan *JSONRPCRequest, 1),
@@ -341,6 +488,20 @@ func TestSessionManager_MaxSessions(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestSessionManager_ConfiguredMaxSessions(t *testing.T) {
+	defer test.MockVariableValue(&setting.MCP.MaxSSESessions, 2)()
+	mgr := &SSESessionManager{sessions: make(map[string]*SSESession)}
+
+	for i := range 3 {
+		s := &SSESession{
+			ID:    fmt.Sprintf("session-%d", i),
+			reqCh: make(chan *JSONRPCRequest, 1),
+			done:  make(chan struct{}),
+		}
+		assert.Equal(t, i < 2, mgr.Register(s), "session %d", i)
+	}
+}
+
 func TestSSESession_SendRequest(t *testing.T) {
 	session := &SSESession{
 		ID:    "test-send",
@@ -444,3 +605,185 @@ func TestServeHTTP_PostToSSESession(t *testing.T) {
 	cancel()
 	<-sseDone
 }
+
+func TestSessionManager_ReapIdle(t *testing.T) {
+	mgr := &SSESessionManager{sessions: make(map[string]*SSESession)}
+
+	idle := &SSESession{
+		ID:    "idle",
+		reqCh: make(chan *JSONRPCRequest, 1),
+		done:  make(chan struct{}),
+	}
+	idle.lastActivity.Store(time.Now().Add(-10 * time.Minute).UnixNano())
+	active := &SSESession{
+		ID:    "active",
+		reqCh: make(chan *JSONRPCRequest, 1),
+		done:  make(chan struct{}),
+	}
+	active.touch()
+
+	mgr.Register(idle)
+	mgr.Register(active)
+
+	assert.Equal(t, 1, mgr.ReapIdle(5*time.Minute))
+	assert.Nil(t, mgr.Get("idle"))
+	assert.NotNil(t, mgr.Get("active"))
+
+	// the reaped session is closed and its event loop released
+	select {
+	case <-idle.done:
+	default:
+		assert.Fail(t, "idle session should be closed")
+	}
+	assert.False(t, idle.SendRequest(&JSONRPCRequest{JSONRPC: "2.0", Method: "ping"}))
+}
+
+func TestServeHTTP_SSEReapedSessionEnds(t *testing.T) {
+	toolCtx := newTestToolContext()
+
+	req, cancel := newCancellableSSERequest("/test/repo/mcp")
+	defer cancel()
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ServeHTTP(w, req, toolCtx)
+	}()
+
+	var session *SSESession
+	require.Eventually(t, func() bool {
+		sessionManager.mu.RLock()
+		defer sessionManager.mu.RUnlock()
+		for _, s := range sessionManager.sessions {
+			if s.ToolCtx.Config == toolCtx.Config {
+				session = s
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	session.lastActivity.Store(time.Now().Add(-time.Hour).UnixNano())
+	sessionManager.ReapIdle(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "SSE handler should return once its session is reaped")
+	}
+}
+
+func TestServeHTTP_SSELogNotification(t *testing.T) {
+	toolCtx := newTestToolContext()
+
+	sseReq, cancel := newCancellableSSERequest("/test/repo/mcp")
+	defer cancel()
+	sseReq.Header.Set("Accept", "text/event-stream")
+	sseW := httptest.NewRecorder()
+
+	sseDone := make(chan struct{})
+	go func() {
+		defer close(sseDone)
+		ServeHTTP(sseW, sseReq, toolCtx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	sessionID := sseW.Header().Get("Mcp-Session-Id")
+	require.NotEmpty(t, sessionID)
+
+	post := func(body string) {
+		postReq := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", strings.NewReader(body))
+		postReq.Header.Set("Content-Type", "application/json")
+		postReq.Header.Set("Mcp-Session-Id", sessionID)
+		postW := httptest.NewRecorder()
+		ServeHTTP(postW, postReq, toolCtx)
+		assert.Equal(t, http.StatusAccepted, postW.Code)
+	}
+	post(`{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	post(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"nonexistent_tool","arguments":{}}}`)
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-sseDone
+
+	var notification JSONRPCNotification
+	scanner := bufio.NewScanner(bytes.NewReader(sseW.Body.Bytes()))
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok || !strings.Contains(data, "notifications/message") {
+			continue
+		}
+		require.NoError(t, json.Unmarshal([]byte(data), &notification))
+	}
+	assert.Equal(t, "notifications/message", notification.Method)
+	params, ok := notification.Params.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "error", params["level"])
+	assert.Equal(t, "tools", params["logger"])
+}
+
+func TestServeHTTP_SSEProgressNotification(t *testing.T) {
+	toolCtx := newCountTestToolContext()
+
+	sseReq, cancel := newCancellableSSERequest("/test/repo/mcp")
+	defer cancel()
+	sseReq.Header.Set("Accept", "text/event-stream")
+	sseW := httptest.NewRecorder()
+
+	sseDone := make(chan struct{})
+	go func() {
+		defer close(sseDone)
+		ServeHTTP(sseW, sseReq, toolCtx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	sessionID := sseW.Header().Get("Mcp-Session-Id")
+	require.NotEmpty(t, sessionID)
+
+	post := func(body string) {
+		postReq := httptest.NewRequest(http.MethodPost, "/test/repo/mcp", strings.NewReader(body))
+		postReq.Header.Set("Content-Type", "application/json")
+		postReq.Header.Set("Mcp-Session-Id", sessionID)
+		postW := httptest.NewRecorder()
+		ServeHTTP(postW, postReq, toolCtx)
+		assert.Equal(t, http.StatusAccepted, postW.Code)
+	}
+	// only the call carrying a progress token gets notifications
+	post(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"generate_document","arguments":{}}}`)
+	post(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"generate_document","arguments":{},"_meta":{"progressToken":"doc-1"}}}`)
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-sseDone
+
+	var progress []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(sseW.Body.Bytes()))
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok || !strings.Contains(data, "notifications/progress") {
+			continue
+		}
+		var notification JSONRPCNotification
+		require.NoError(t, json.Unmarshal([]byte(data), &notification))
+		params, ok := notification.Params.(map[string]any)
+		require.True(t, ok)
+		progress = append(progress, params)
+	}
+	// one notification per ministry section
+	require.Len(t, progress, 2)
+	for i, params := range progress {
+		assert.Equal(t, "doc-1", params["progressToken"])
+		assert.EqualValues(t, i+1, params["progress"])
+		assert.EqualValues(t, 2, params["total"])
+	}
+	assert.Equal(t, "ministry:14", progress[1]["message"])
+}
+
+func TestReportProgress_NoSession(t *testing.T) {
+	// plain POST requests have no session to send notifications on
+	ctx := newCountTestToolContext()
+	ctx.progressToken = "doc-1"
+	assert.NotPanics(t, func() { ctx.reportProgress(1, 1, "ministry:13") })
+}
@@ -3,26 +3,75 @@
 
 package mcp
 
+import "code.gitea.io/gitea/modules/json"
+
 // MCPConfig represents the parsed processgit.mcp.yaml file.
 type MCPConfig struct {
 	Version int             `yaml:"version"`
 	Server  MCPServerConfig `yaml:"server"`
 	Sources []MCPSource     `yaml:"sources"`
+	// DuplicateIDs decides what happens when several sources define an entity with the same ID:
+	// "error" (the default) fails the index build, "first" or "last" keeps that source's entity.
+	DuplicateIDs string `yaml:"duplicate_ids"`
+	// DocumentRootTypes lists, in order, the entity types whose entities become the sections
+	// of a generated Markdown document. When empty, every type with a parentless entity is
+	// used, sorted by name.
+	DocumentRootTypes []string `yaml:"document_root_types"`
+	// EnabledTools, when set, lists the only tools the server offers. DisabledTools lists tools
+	// it doesn't offer, and wins over EnabledTools.
+	EnabledTools  []string `yaml:"enabled_tools"`
+	DisabledTools []string `yaml:"disabled_tools"`
 }
 
+// Values of MCPConfig.DuplicateIDs.
+const (
+	DuplicateIDsError = "error"
+	DuplicateIDsFirst = "first"
+	DuplicateIDsLast  = "last"
+)
+
 // MCPServerConfig holds server metadata from the config file.
 type MCPServerConfig struct {
 	Name         string `yaml:"name"`
 	Description  string `yaml:"description"`
 	Instructions string `yaml:"instructions"`
+	// InstructionsFile is a Markdown file in the repository whose content is appended to
+	// Instructions, DefaultInstructionsFile when empty.
+	InstructionsFile string `yaml:"instructions_file"`
 }
 
 // MCPSource declares a data source file in the repository.
 type MCPSource struct {
-	Path        string `yaml:"path"`
-	Type        string `yaml:"type"`        // "xml", "json", etc.
-	Schema      string `yaml:"schema"`      // optional XSD/JSON Schema path
-	Description string `yaml:"description"`
+	Path        string         `yaml:"path"`
+	Type        string         `yaml:"type"`   // "xml" or "json"
+	Schema      string         `yaml:"schema"` // optional XSD/JSON Schema path
+	Description string         `yaml:"description"`
+	References  []MCPReference `yaml:"references"`   // attributes holding codes of other entities
+	IDAttribute string         `yaml:"id_attribute"` // attribute that marks and keys entities, default "code"
+
+	// JSON sources are flat arrays of records, so the entity type and the parent
+	// relationship can't be derived from the document structure.
+	EntityType      string `yaml:"entity_type"`      // entity type of every record in a "json" source
+	ParentAttribute string `yaml:"parent_attribute"` // optional record field holding the id of the parent record
+}
+
+// DefaultIDAttribute is the XML attribute that identifies entities when a source doesn't set id_attribute.
+const DefaultIDAttribute = "code"
+
+// EntityIDAttribute returns the attribute that identifies entities in this source.
+func (s MCPSource) EntityIDAttribute() string {
+	if s.IDAttribute == "" {
+		return DefaultIDAttribute
+	}
+	return s.IDAttribute
+}
+
+// MCPReference declares that an entity attribute holds the code of another entity,
+// e.g. {attribute: departmentRef, type: department}. The validate tool reports
+// values that don't resolve to an entity of the given type.
+type MCPReference struct {
+	Attribute string `yaml:"attribute"`
+	Type      string `yaml:"type"`
 }
 
 // --- JSON-RPC 2.0 types ---
@@ -35,6 +84,24 @@ type JSONRPCRequest struct {
 	Params  interface{} `json:"params,omitempty"`
 }
 
+// UnmarshalJSON decodes the request, keeping an integer ID as an int64: decoded as a float64 it
+// could lose precision and be echoed in exponent form, which strict clients reject.
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type request JSONRPCRequest // without this method
+	if err := json.Unmarshal(data, (*request)(r)); err != nil {
+		return err
+	}
+	if _, ok := r.ID.(float64); ok {
+		var intID struct {
+			ID int64 `json:"id"`
+		}
+		if json.Unmarshal(data, &intID) == nil {
+			r.ID = intID.ID
+		}
+	}
+	return nil
+}
+
 // JSONRPCResponse represents an outgoing JSON-RPC 2.0 response.
 type JSONRPCResponse struct {
 	JSONRPC string        `json:"jsonrpc"`
@@ -43,6 +110,13 @@ type JSONRPCResponse struct {
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
 
+// JSONRPCNotification represents an outgoing JSON-RPC 2.0 notification (a request without an ID).
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 // JSONRPCError represents a JSON-RPC 2.0 error object.
 type JSONRPCError struct {
 	Code    int         `json:"code"`
@@ -54,9 +128,15 @@ type JSONRPCError struct {
 
 // InitializeParams is sent by the client during the initialize handshake.
 type InitializeParams struct {
-	ProtocolVersion string     `json:"protocolVersion"`
-	Capabilities    struct{}   `json:"capabilities"`
-	ClientInfo      ClientInfo `json:"clientInfo"`
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ClientCapabilities `json:"capabilities"`
+	ClientInfo      ClientInfo         `json:"clientInfo"`
+}
+
+// ClientCapabilities declares what the client supports. Only the experimental capabilities
+// are read, to find out whether the client accepts JSON content blocks.
+type ClientCapabilities struct {
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 // ClientInfo identifies the MCP client.
@@ -75,12 +155,33 @@ type InitializeResult struct {
 
 // ServerCapabilities declares what the server supports.
 type ServerCapabilities struct {
-	Tools *ToolCapability `json:"tools"`
+	Tools        *ToolCapability        `json:"tools"`
+	Logging      *LoggingCapability     `json:"logging,omitempty"`
+	Prompts      *PromptCapability      `json:"prompts,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 // ToolCapability declares tool support.
 type ToolCapability struct{}
 
+// PromptCapability declares prompt support.
+type PromptCapability struct{}
+
+// LoggingCapability declares support for logging/setLevel and notifications/message.
+type LoggingCapability struct{}
+
+// SetLevelParams is sent by the client with logging/setLevel.
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+// LoggingMessageParams is the payload of a notifications/message notification.
+type LoggingMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
 // ServerInfo identifies the MCP server.
 type ServerInfo struct {
 	Name    string `json:"name"`
@@ -105,6 +206,21 @@ type ToolListResult struct {
 type ToolCallParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta field of a request. A client that sets ProgressToken, a string or
+// a number, asks for notifications/progress while the request is being processed.
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressNotificationParams is the payload of a notifications/progress notification.
+type ProgressNotificationParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      int         `json:"progress"`
+	Total         int         `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
 }
 
 // ToolCallResult is returned from a tool execution.
@@ -113,10 +229,80 @@ type ToolCallResult struct {
 	IsError bool          `json:"isError,omitempty"`
 }
 
-// ToolContent represents a content block in a tool result.
+// ToolError is the machine-readable error of a failed tool call. It is sent as the JSON text
+// content {"error": {"code": ..., "message": ...}} of a ToolCallResult with IsError set.
+type ToolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Content block types of a ToolContent.
+const (
+	ContentTypeText = "text"
+	ContentTypeJSON = "json"
+)
+
+// ToolContent represents a content block in a tool result. A "text" block carries Text,
+// a "json" block carries the structured value in JSON.
 type ToolContent struct {
-	Type string `json:"type"` // "text"
-	Text string `json:"text"`
+	Type string      `json:"type"` // "text" or "json"
+	Text string      `json:"text"`
+	JSON interface{} `json:"json,omitempty"`
+}
+
+// MarshalJSON writes only the field that belongs to the block type, so text blocks always
+// have "text" and json blocks don't carry an empty one.
+func (c ToolContent) MarshalJSON() ([]byte, error) {
+	if c.Type == ContentTypeJSON {
+		return json.Marshal(struct {
+			Type string      `json:"type"`
+			JSON interface{} `json:"json"`
+		}{c.Type, c.JSON})
+	}
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}{c.Type, c.Text})
+}
+
+// --- Prompt types ---
+
+// PromptArgument describes an argument accepted by a prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptDefinition describes an available prompt for prompts/list.
+type PromptDefinition struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptListResult is returned for prompts/list requests.
+type PromptListResult struct {
+	Prompts    []PromptDefinition `json:"prompts"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// PromptGetParams is sent by the client when requesting a prompt.
+type PromptGetParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// PromptMessage is a single message of a rendered prompt.
+type PromptMessage struct {
+	Role    string      `json:"role"` // "user" or "assistant"
+	Content ToolContent `json:"content"`
+}
+
+// PromptGetResult is returned for prompts/get requests.
+type PromptGetResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
 }
 
 // --- Entity types (parsed from XML) ---
@@ -128,7 +314,10 @@ type Entity struct {
 	Name       string            `json:"name"`
 	ParentID   string            `json:"parent_id,omitempty"`
 	Attributes map[string]string `json:"attributes"`
-	Children   []string          `json:"children,omitempty"`
+	// MultiAttributes holds every value, in document order, of child elements that occur
+	// more than once. Attributes keeps the comma-joined form of the same values.
+	MultiAttributes map[string][]string `json:"multi_attributes,omitempty"`
+	Children        []string            `json:"children,omitempty"`
 }
 
 // EntityIndex holds all parsed entities with lookup indices.
@@ -136,9 +325,14 @@ type EntityIndex struct {
 	Entities   map[string]*Entity  // keyed by ID
 	ByType     map[string][]string // type -> list of IDs
 	ByParent   map[string][]string // parentID -> list of child IDs
+	Origins    map[string]string   // entity ID -> path of the source defining it
 	SourceFile string
 	CommitSHA  string
 	Stats      IndexStats
+
+	// searchTokens maps each lower-cased token of the entity names, IDs and attribute values
+	// to the sorted IDs of the entities containing it. Nil until buildSearchTokens is called.
+	searchTokens map[string][]string
 }
 
 // IndexStats holds summary statistics about the index.
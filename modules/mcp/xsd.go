@@ -0,0 +1,616 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file implements a small, pure-Go validator for the commonly used subset of
+// XML Schema 1.0: global and local element declarations (name, type, ref, minOccurs,
+// maxOccurs), named and anonymous complex types (sequence, choice, all, any,
+// anyAttribute, mixed, simpleContent and complexContent extensions), attributes
+// (type, use="required") and simple types restricted by enumeration, pattern,
+// length and numeric bounds. Unsupported constructs (imports, substitution groups,
+// identity constraints, ...) are ignored, which makes the validator lenient rather
+// than wrong.
+
+// ValidationError describes a single validation problem. Line is 0 when the problem
+// is not tied to a position in the source file.
+type ValidationError struct {
+	Source  string `json:"source,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Source, e.Line, e.Message)
+	}
+	if e.Source != "" {
+		return e.Source + ": " + e.Message
+	}
+	return e.Message
+}
+
+// xsdNode is a generic XSD document node.
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n *xsdNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+type xsdSchema struct {
+	elements     map[string]*xsdElement
+	complexTypes map[string]*xsdComplexType
+	simpleTypes  map[string]*xsdSimpleType
+}
+
+type xsdElement struct {
+	name      string
+	typeName  string
+	ref       string
+	minOccurs int
+	maxOccurs int // -1 means unbounded
+	complex   *xsdComplexType
+	simple    *xsdSimpleType
+}
+
+type xsdAttribute struct {
+	name     string
+	typeName string
+	required bool
+	simple   *xsdSimpleType
+}
+
+type xsdComplexType struct {
+	base         string // complexContent/simpleContent extension base
+	simpleBase   bool   // true when base comes from simpleContent
+	mixed        bool
+	anyChildren  bool
+	anyAttribute bool
+	attributes   []*xsdAttribute
+	children     []*xsdElement
+	resolved     bool
+}
+
+type xsdSimpleType struct {
+	base         string
+	enumerations []string
+	patterns     []*regexp.Regexp
+	minLength    int
+	maxLength    int // -1 means no limit
+	minInclusive *float64
+	maxInclusive *float64
+	union        bool
+}
+
+// parseXSD parses an XSD document into a schema model.
+func parseXSD(data []byte) (*xsdSchema, error) {
+	var root xsdNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid XSD: %w", err)
+	}
+	if root.XMLName.Local != "schema" {
+		return nil, fmt.Errorf("invalid XSD: root element is <%s>, expected <schema>", root.XMLName.Local)
+	}
+
+	schema := &xsdSchema{
+		elements:     make(map[string]*xsdElement),
+		complexTypes: make(map[string]*xsdComplexType),
+		simpleTypes:  make(map[string]*xsdSimpleType),
+	}
+	for i := range root.Children {
+		child := &root.Children[i]
+		switch child.XMLName.Local {
+		case "element":
+			el := parseXSDElement(child)
+			schema.elements[el.name] = el
+		case "complexType":
+			schema.complexTypes[child.attr("name")] = parseXSDComplexType(child)
+		case "simpleType":
+			schema.simpleTypes[child.attr("name")] = parseXSDSimpleType(child)
+		}
+	}
+	return schema, nil
+}
+
+func parseXSDOccurs(value string, def int) int {
+	if value == "" {
+		return def
+	}
+	if value == "unbounded" {
+		return -1
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+// mulXSDOccurs multiplies two maxOccurs values, where -1 is unbounded.
+func mulXSDOccurs(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	if a != 0 && a*b/a != b {
+		return -1 // too large to count anyway
+	}
+	return a * b
+}
+
+func parseXSDElement(n *xsdNode) *xsdElement {
+	el := &xsdElement{
+		name:      n.attr("name"),
+		typeName:  localXSDName(n.attr("type")),
+		ref:       localXSDName(n.attr("ref")),
+		minOccurs: parseXSDOccurs(n.attr("minOccurs"), 1),
+		maxOccurs: parseXSDOccurs(n.attr("maxOccurs"), 1),
+	}
+	if el.name == "" {
+		el.name = el.ref
+	}
+	for i := range n.Children {
+		child := &n.Children[i]
+		switch child.XMLName.Local {
+		case "complexType":
+			el.complex = parseXSDComplexType(child)
+		case "simpleType":
+			el.simple = parseXSDSimpleType(child)
+		}
+	}
+	return el
+}
+
+func parseXSDAttribute(n *xsdNode) *xsdAttribute {
+	attr := &xsdAttribute{
+		name:     n.attr("name"),
+		typeName: localXSDName(n.attr("type")),
+		required: n.attr("use") == "required",
+	}
+	if attr.name == "" {
+		attr.name = localXSDName(n.attr("ref"))
+	}
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == "simpleType" {
+			attr.simple = parseXSDSimpleType(&n.Children[i])
+		}
+	}
+	return attr
+}
+
+func parseXSDComplexType(n *xsdNode) *xsdComplexType {
+	ct := &xsdComplexType{mixed: n.attr("mixed") == "true"}
+	collectXSDContent(n, ct, false, 1)
+	return ct
+}
+
+// collectXSDContent flattens model groups and attribute declarations into ct.
+// Elements inside a choice or an optional group are made optional since they don't have to
+// occur, and maxOccurs, the product of the maxOccurs of the enclosing groups (-1 for
+// unbounded), multiplies how often each element may occur.
+func collectXSDContent(n *xsdNode, ct *xsdComplexType, optional bool, maxOccurs int) {
+	for i := range n.Children {
+		child := &n.Children[i]
+		switch child.XMLName.Local {
+		case "sequence", "all":
+			collectXSDContent(child, ct, optional || parseXSDOccurs(child.attr("minOccurs"), 1) == 0,
+				mulXSDOccurs(maxOccurs, parseXSDOccurs(child.attr("maxOccurs"), 1)))
+		case "choice":
+			collectXSDContent(child, ct, true, mulXSDOccurs(maxOccurs, parseXSDOccurs(child.attr("maxOccurs"), 1)))
+		case "element":
+			el := parseXSDElement(child)
+			if optional {
+				el.minOccurs = 0
+			}
+			el.maxOccurs = mulXSDOccurs(el.maxOccurs, maxOccurs)
+			ct.children = append(ct.children, el)
+		case "any":
+			ct.anyChildren = true
+		case "attribute":
+			ct.attributes = append(ct.attributes, parseXSDAttribute(child))
+		case "anyAttribute":
+			ct.anyAttribute = true
+		case "simpleContent", "complexContent":
+			if child.XMLName.Local == "complexContent" && child.attr("mixed") == "true" {
+				ct.mixed = true
+			}
+			for j := range child.Children {
+				derivation := &child.Children[j]
+				if derivation.XMLName.Local != "extension" && derivation.XMLName.Local != "restriction" {
+					continue
+				}
+				// a complexContent restriction redeclares its content model, so only
+				// extensions and simpleContent derivations inherit from the base type
+				if derivation.XMLName.Local == "extension" || child.XMLName.Local == "simpleContent" {
+					ct.base = localXSDName(derivation.attr("base"))
+					ct.simpleBase = child.XMLName.Local == "simpleContent"
+				}
+				collectXSDContent(derivation, ct, optional, maxOccurs)
+			}
+		}
+	}
+}
+
+func parseXSDSimpleType(n *xsdNode) *xsdSimpleType {
+	st := &xsdSimpleType{maxLength: -1}
+	for i := range n.Children {
+		child := &n.Children[i]
+		switch child.XMLName.Local {
+		case "restriction":
+			st.base = localXSDName(child.attr("base"))
+			for j := range child.Children {
+				facet := &child.Children[j]
+				value := facet.attr("value")
+				switch facet.XMLName.Local {
+				case "enumeration":
+					st.enumerations = append(st.enumerations, value)
+				case "pattern":
+					// XSD patterns are implicitly anchored; skip the ones Go's regexp can't handle.
+					if re, err := regexp.Compile("^(?:" + value + ")$"); err == nil {
+						st.patterns = append(st.patterns, re)
+					}
+				case "length":
+					st.minLength = parseXSDOccurs(value, 0)
+					st.maxLength = parseXSDOccurs(value, -1)
+				case "minLength":
+					st.minLength = parseXSDOccurs(value, 0)
+				case "maxLength":
+					st.maxLength = parseXSDOccurs(value, -1)
+				case "minInclusive":
+					if f, err := strconv.ParseFloat(value, 64); err == nil {
+						st.minInclusive = &f
+					}
+				case "maxInclusive":
+					if f, err := strconv.ParseFloat(value, 64); err == nil {
+						st.maxInclusive = &f
+					}
+				}
+			}
+		case "union", "list":
+			st.union = true
+		}
+	}
+	return st
+}
+
+// localXSDName strips a namespace prefix from a QName like "xs:string".
+func localXSDName(qname string) string {
+	if i := strings.LastIndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+// resolveElement follows element references to their global declaration.
+func (s *xsdSchema) resolveElement(el *xsdElement) *xsdElement {
+	if el.ref == "" {
+		return el
+	}
+	if global, ok := s.elements[el.ref]; ok {
+		return global
+	}
+	return el
+}
+
+// complexTypeOf returns the complex type of an element, or nil if the element is simple.
+func (s *xsdSchema) complexTypeOf(el *xsdElement) *xsdComplexType {
+	ct := el.complex
+	if ct == nil && el.typeName != "" {
+		ct = s.complexTypes[el.typeName]
+	}
+	if ct == nil {
+		return nil
+	}
+	s.resolveComplexType(ct, 0)
+	return ct
+}
+
+// resolveComplexType merges attributes and children inherited from the base type.
+func (s *xsdSchema) resolveComplexType(ct *xsdComplexType, depth int) {
+	if ct.resolved || depth > 32 {
+		return
+	}
+	ct.resolved = true
+	if ct.base == "" {
+		return
+	}
+	base, ok := s.complexTypes[ct.base]
+	if !ok {
+		return
+	}
+	s.resolveComplexType(base, depth+1)
+	ct.attributes = append(append([]*xsdAttribute{}, base.attributes...), ct.attributes...)
+	ct.children = append(append([]*xsdElement{}, base.children...), ct.children...)
+	ct.anyChildren = ct.anyChildren || base.anyChildren
+	ct.anyAttribute = ct.anyAttribute || base.anyAttribute
+	ct.mixed = ct.mixed || base.mixed
+	if base.simpleBase {
+		ct.simpleBase = true
+		ct.base = base.base
+	}
+}
+
+// validateSimpleValue checks a text value against a named or anonymous simple type.
+func (s *xsdSchema) validateSimpleValue(value, typeName string, st *xsdSimpleType, depth int) error {
+	if depth > 32 {
+		return nil
+	}
+	if st == nil {
+		if typeName == "" {
+			return nil
+		}
+		if named, ok := s.simpleTypes[typeName]; ok {
+			st = named
+		} else {
+			return validateXSDBuiltin(value, typeName)
+		}
+	}
+	if st.union {
+		return nil
+	}
+	if err := s.validateSimpleValue(value, st.base, nil, depth+1); err != nil {
+		return err
+	}
+	if len(st.enumerations) > 0 {
+		found := false
+		for _, e := range st.enumerations {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value %q is not one of [%s]", value, strings.Join(st.enumerations, ", "))
+		}
+	}
+	if len(st.patterns) > 0 {
+		matched := false
+		for _, re := range st.patterns {
+			if re.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("value %q does not match the required pattern", value)
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if length < st.minLength {
+		return fmt.Errorf("value %q is shorter than %d characters", value, st.minLength)
+	}
+	if st.maxLength >= 0 && length > st.maxLength {
+		return fmt.Errorf("value %q is longer than %d characters", value, st.maxLength)
+	}
+	if st.minInclusive != nil || st.maxInclusive != nil {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("value %q is not numeric", value)
+		}
+		if st.minInclusive != nil && f < *st.minInclusive {
+			return fmt.Errorf("value %q is less than %v", value, *st.minInclusive)
+		}
+		if st.maxInclusive != nil && f > *st.maxInclusive {
+			return fmt.Errorf("value %q is greater than %v", value, *st.maxInclusive)
+		}
+	}
+	return nil
+}
+
+// validateXSDBuiltin checks a value against the built-in XSD types that have a lexical constraint.
+func validateXSDBuiltin(value, typeName string) error {
+	v := strings.TrimSpace(value)
+	switch typeName {
+	case "integer", "int", "long", "short", "byte":
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return fmt.Errorf("value %q is not a valid %s", value, typeName)
+		}
+	case "nonNegativeInteger", "positiveInteger", "unsignedInt", "unsignedLong", "unsignedShort", "unsignedByte":
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 || (typeName == "positiveInteger" && n == 0) {
+			return fmt.Errorf("value %q is not a valid %s", value, typeName)
+		}
+	case "decimal", "float", "double":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("value %q is not a valid %s", value, typeName)
+		}
+	case "boolean":
+		switch v {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Errorf("value %q is not a valid boolean", value)
+		}
+	case "date":
+		if len(v) < 10 {
+			return fmt.Errorf("value %q is not a valid date", value)
+		}
+		if _, err := time.Parse("2006-01-02", v[:10]); err != nil {
+			return fmt.Errorf("value %q is not a valid date", value)
+		}
+	case "dateTime":
+		if len(v) < 19 {
+			return fmt.Errorf("value %q is not a valid dateTime", value)
+		}
+		if _, err := time.Parse("2006-01-02T15:04:05", v[:19]); err != nil {
+			return fmt.Errorf("value %q is not a valid dateTime", value)
+		}
+	}
+	return nil
+}
+
+type xsdFrame struct {
+	name   string
+	line   int
+	decl   *xsdElement
+	ctype  *xsdComplexType
+	counts map[string]int
+	text   strings.Builder
+}
+
+// validate checks an XML document against the schema and returns all problems found.
+// The document must be well-formed; a syntax error ends validation.
+func (s *xsdSchema) validate(data []byte) []ValidationError {
+	var errs []ValidationError
+	addErr := func(line int, format string, args ...any) {
+		errs = append(errs, ValidationError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xsdFrame
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				addErr(syntaxErr.Line, "XML error: %s", syntaxErr.Msg)
+			} else {
+				line, _ := decoder.InputPos()
+				addErr(line, "XML error: %s", err.Error())
+			}
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			frame := &xsdFrame{name: t.Name.Local, line: line}
+
+			if len(stack) == 0 {
+				decl, ok := s.elements[t.Name.Local]
+				if !ok {
+					addErr(line, "root element <%s> is not declared in the schema", t.Name.Local)
+				}
+				frame.decl = decl
+			} else if parent := stack[len(stack)-1]; parent.ctype != nil {
+				parent.counts[t.Name.Local]++
+				var decl *xsdElement
+				for _, child := range parent.ctype.children {
+					if child.name == t.Name.Local {
+						decl = child
+						break
+					}
+				}
+				if decl != nil {
+					frame.decl = s.resolveElement(decl)
+				} else if !parent.ctype.anyChildren {
+					addErr(line, "element <%s> is not allowed in <%s>", t.Name.Local, parent.name)
+				}
+			}
+
+			if frame.decl != nil {
+				frame.ctype = s.complexTypeOf(frame.decl)
+				frame.counts = make(map[string]int)
+				s.validateAttributes(frame, t.Attr, addErr)
+			}
+			stack = append(stack, frame)
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if frame.decl == nil {
+				continue
+			}
+			s.validateContent(frame, addErr)
+		}
+	}
+	return errs
+}
+
+func (s *xsdSchema) validateAttributes(frame *xsdFrame, attrs []xml.Attr, addErr func(int, string, ...any)) {
+	ct := frame.ctype
+	present := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		// namespace declarations and xsi:/xml: attributes are never declared in the schema
+		if a.Name.Space != "" || a.Name.Local == "xmlns" {
+			continue
+		}
+		present[a.Name.Local] = a.Value
+	}
+
+	var declared []*xsdAttribute
+	anyAttribute := false
+	if ct != nil {
+		declared = ct.attributes
+		anyAttribute = ct.anyAttribute
+	}
+	for _, decl := range declared {
+		value, ok := present[decl.name]
+		if !ok {
+			if decl.required {
+				addErr(frame.line, "element <%s> is missing required attribute %q", frame.name, decl.name)
+			}
+			continue
+		}
+		if err := s.validateSimpleValue(value, decl.typeName, decl.simple, 0); err != nil {
+			addErr(frame.line, "attribute %q of <%s>: %s", decl.name, frame.name, err.Error())
+		}
+		delete(present, decl.name)
+	}
+	if anyAttribute {
+		return
+	}
+	for name := range present {
+		addErr(frame.line, "attribute %q is not allowed on <%s>", name, frame.name)
+	}
+}
+
+func (s *xsdSchema) validateContent(frame *xsdFrame, addErr func(int, string, ...any)) {
+	ct := frame.ctype
+	text := strings.TrimSpace(frame.text.String())
+	if ct == nil {
+		if err := s.validateSimpleValue(text, frame.decl.typeName, frame.decl.simple, 0); err != nil {
+			addErr(frame.line, "element <%s>: %s", frame.name, err.Error())
+		}
+		return
+	}
+
+	if ct.simpleBase {
+		if err := s.validateSimpleValue(text, ct.base, nil, 0); err != nil {
+			addErr(frame.line, "element <%s>: %s", frame.name, err.Error())
+		}
+	} else if text != "" && !ct.mixed {
+		addErr(frame.line, "element <%s> must not contain text", frame.name)
+	}
+
+	for _, child := range ct.children {
+		n := frame.counts[child.name]
+		if n < child.minOccurs {
+			addErr(frame.line, "element <%s> must contain at least %d <%s> element(s), found %d", frame.name, child.minOccurs, child.name, n)
+		}
+		if child.maxOccurs >= 0 && n > child.maxOccurs {
+			addErr(frame.line, "element <%s> may contain at most %d <%s> element(s), found %d", frame.name, child.maxOccurs, child.name, n)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegisterXSD = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+           xmlns:tns="http://example.com/register"
+           targetNamespace="http://example.com/register"
+           elementFormDefault="qualified">
+  <xs:simpleType name="CodeType">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[0-9]{2}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="StatusType">
+    <xs:restriction base="xs:string">
+      <xs:enumeration value="active"/>
+      <xs:enumeration value="closed"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="NamedType">
+    <xs:sequence>
+      <xs:element name="name" type="xs:string"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="MinistryType">
+    <xs:complexContent>
+      <xs:extension base="tns:NamedType">
+        <xs:sequence>
+          <xs:element name="budget" type="xs:decimal" minOccurs="0"/>
+          <xs:element ref="tns:note" minOccurs="0" maxOccurs="unbounded"/>
+        </xs:sequence>
+        <xs:attribute name="code" type="tns:CodeType" use="required"/>
+        <xs:attribute name="status" type="tns:StatusType"/>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="note" type="xs:string"/>
+  <xs:element name="register">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="ministry" type="tns:MinistryType" maxOccurs="unbounded"/>
+      </xs:sequence>
+      <xs:attribute name="version" type="xs:string"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+func TestXSDValidate_Valid(t *testing.T) {
+	schema, err := parseXSD([]byte(testRegisterXSD))
+	require.NoError(t, err)
+
+	errs := schema.validate([]byte(`<?xml version="1.0"?>
+<register xmlns="http://example.com/register" version="1">
+  <ministry code="01" status="active">
+    <name>First</name>
+    <budget>12.5</budget>
+    <note>a</note>
+    <note>b</note>
+  </ministry>
+</register>`))
+	assert.Empty(t, errs)
+}
+
+func TestXSDValidate_Errors(t *testing.T) {
+	schema, err := parseXSD([]byte(testRegisterXSD))
+	require.NoError(t, err)
+
+	errs := schema.validate([]byte(`<?xml version="1.0"?>
+<register xmlns="http://example.com/register">
+  <ministry code="1" status="unknown" extra="x">
+    <budget>lots</budget>
+  </ministry>
+  <ministry>
+    <name>Second</name>
+    <unexpected/>
+  </ministry>
+</register>`))
+
+	messages := make(map[int][]string)
+	for _, e := range errs {
+		messages[e.Line] = append(messages[e.Line], e.Message)
+	}
+	assert.Contains(t, messages[3], `attribute "code" of <ministry>: value "1" does not match the required pattern`)
+	assert.Contains(t, messages[3], `attribute "status" of <ministry>: value "unknown" is not one of [active, closed]`)
+	assert.Contains(t, messages[3], `attribute "extra" is not allowed on <ministry>`)
+	assert.Contains(t, messages[3], `element <ministry> must contain at least 1 <name> element(s), found 0`)
+	assert.Contains(t, messages[4], `element <budget>: value "lots" is not a valid decimal`)
+	assert.Contains(t, messages[6], `element <ministry> is missing required attribute "code"`)
+	assert.Contains(t, messages[8], `element <unexpected> is not allowed in <ministry>`)
+	assert.Len(t, errs, 7)
+}
+
+func TestXSDValidate_RepeatedGroups(t *testing.T) {
+	schema, err := parseXSD([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:choice maxOccurs="unbounded">
+          <xs:element name="a" type="xs:string"/>
+          <xs:element name="b" type="xs:string"/>
+        </xs:choice>
+        <xs:sequence minOccurs="0" maxOccurs="2">
+          <xs:element name="c" type="xs:string"/>
+        </xs:sequence>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`))
+	require.NoError(t, err)
+
+	assert.Empty(t, schema.validate([]byte(`<root><a>1</a><b>2</b><a>3</a><c>4</c><c>5</c></root>`)))
+	// the optional group may be left out
+	assert.Empty(t, schema.validate([]byte(`<root><a>1</a></root>`)))
+
+	errs := schema.validate([]byte(`<root><a>1</a><c>2</c><c>3</c><c>4</c></root>`))
+	require.Len(t, errs, 1)
+	assert.Equal(t, "element <root> may contain at most 2 <c> element(s), found 3", errs[0].Message)
+}
+
+func TestXSDValidate_UndeclaredRoot(t *testing.T) {
+	schema, err := parseXSD([]byte(testRegisterXSD))
+	require.NoError(t, err)
+
+	errs := schema.validate([]byte(`<other/>`))
+	require.Len(t, errs, 1)
+	assert.Equal(t, 1, errs[0].Line)
+	assert.Contains(t, errs[0].Message, "root element <other>")
+}
+
+func TestValidateXMLData_WellFormedness(t *testing.T) {
+	assert.Empty(t, validateXMLData([]byte(`<root><a/></root>`), nil))
+
+	errs := validateXMLData([]byte("<root>\n<a>\n</root>"), nil)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 3, errs[0].Line)
+}
+
+func TestParseXSD_Invalid(t *testing.T) {
+	_, err := parseXSD([]byte(`<notaschema/>`))
+	assert.Error(t, err)
+}
@@ -6,9 +6,20 @@ package processgitviewer
 import (
 	"fmt"
 	"path"
+	"slices"
 	"strings"
 )
 
+// Viewer types a binding may declare.
+const (
+	ViewerTypeHTML   = "html"   // HTML page rendered in a sandboxed iframe (default)
+	ViewerTypeSVG    = "svg"    // SVG document
+	ViewerTypeCanvas = "canvas" // script that draws onto a canvas element
+)
+
+// AllowedViewerTypes lists the accepted values of ViewerBinding.Type.
+var AllowedViewerTypes = []string{ViewerTypeHTML, ViewerTypeSVG, ViewerTypeCanvas}
+
 type Manifest struct {
 	Version int             `json:"version"`
 	Viewers []ViewerBinding `json:"viewers"`
@@ -25,14 +36,14 @@ type ViewerBinding struct {
 	//   "registers/*.xml"
 	PrimaryPattern string `json:"primary_pattern"`
 
-	// Viewer type - v1 supports only "html"
+	// Viewer type, one of AllowedViewerTypes. Defaults to "html" when empty.
 	Type string `json:"type"`
 
 	// GUI entry file name/path relative to directory of the manifest
 	Entry string `json:"entry"`
 
-	// Exactly which repo-relative file(s) are allowed to be edited by this viewer.
-	// In your requirement: only the primary file.
+	// Exactly which file(s) are allowed to be edited by this viewer, relative to the manifest
+	// directory. Must include the primary file and may add related files, e.g. its XSD.
 	EditAllow []string `json:"edit_allow"`
 
 	// Optional mapping of “related” files the GUI may need (xsd, examples, etc.)
@@ -40,6 +51,7 @@ type ViewerBinding struct {
 	Targets map[string]string `json:"targets,omitempty"`
 }
 
+// Validate checks the manifest and fills in defaults, such as the "html" viewer type.
 func (m *Manifest) Validate() error {
 	if m.Version < 1 {
 		return fmt.Errorf("manifest version must be >= 1")
@@ -47,15 +59,19 @@ func (m *Manifest) Validate() error {
 	if len(m.Viewers) == 0 {
 		return fmt.Errorf("manifest must include at least one viewer binding")
 	}
-	for i, viewer := range m.Viewers {
+	for i := range m.Viewers {
+		viewer := &m.Viewers[i]
 		if strings.TrimSpace(viewer.ID) == "" {
 			return fmt.Errorf("viewer %d: id is required", i)
 		}
 		if strings.TrimSpace(viewer.PrimaryPattern) == "" {
 			return fmt.Errorf("viewer %d: primary_pattern is required", i)
 		}
-		if viewer.Type != "html" {
-			return fmt.Errorf("viewer %d: type must be html", i)
+		if viewer.Type == "" {
+			viewer.Type = ViewerTypeHTML
+		}
+		if !slices.Contains(AllowedViewerTypes, viewer.Type) {
+			return fmt.Errorf("viewer %d: type %q is not supported, must be one of: %s", i, viewer.Type, strings.Join(AllowedViewerTypes, ", "))
 		}
 		if strings.TrimSpace(viewer.Entry) == "" {
 			return fmt.Errorf("viewer %d: entry is required", i)
@@ -63,6 +79,11 @@ func (m *Manifest) Validate() error {
 		if len(viewer.EditAllow) == 0 {
 			return fmt.Errorf("viewer %d: edit_allow must not be empty", i)
 		}
+		for _, editPath := range viewer.EditAllow {
+			if !isLocalPath(editPath) {
+				return fmt.Errorf("viewer %d: edit_allow path %q must be inside the manifest directory", i, editPath)
+			}
+		}
 	}
 	return nil
 }
@@ -74,3 +95,18 @@ func MatchBinding(binding ViewerBinding, repoTreePath string) (bool, error) {
 	}
 	return path.Match(pattern, path.Base(repoTreePath))
 }
+
+// EditAllowPaths returns the repo-relative paths of the files the viewer may edit, given the
+// directory of the manifest.
+func (b *ViewerBinding) EditAllowPaths(dir string) []string {
+	paths := make([]string, 0, len(b.EditAllow))
+	for _, editPath := range b.EditAllow {
+		paths = append(paths, joinFromDir(dir, editPath))
+	}
+	return paths
+}
+
+// CanEdit reports whether the viewer may write repoTreePath, i.e. whether it is listed in EditAllow.
+func (b *ViewerBinding) CanEdit(dir, repoTreePath string) bool {
+	return slices.Contains(b.EditAllowPaths(dir), path.Clean(repoTreePath))
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package processgitviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManifest(viewerType string) *Manifest {
+	return &Manifest{
+		Version: 1,
+		Viewers: []ViewerBinding{{
+			ID:             "register",
+			PrimaryPattern: "*-register.xml",
+			Type:           viewerType,
+			Entry:          "viewer.html",
+			EditAllow:      []string{"vdvc-register.xml"},
+		}},
+	}
+}
+
+func TestManifestValidate_ViewerType(t *testing.T) {
+	for _, viewerType := range AllowedViewerTypes {
+		m := newTestManifest(viewerType)
+		require.NoError(t, m.Validate(), viewerType)
+		assert.Equal(t, viewerType, m.Viewers[0].Type)
+	}
+
+	m := newTestManifest("")
+	require.NoError(t, m.Validate())
+	assert.Equal(t, ViewerTypeHTML, m.Viewers[0].Type, "empty type defaults to html")
+
+	for _, viewerType := range []string{"pdf", "HTML", "webgl"} {
+		err := newTestManifest(viewerType).Validate()
+		assert.ErrorContains(t, err, "is not supported, must be one of: html, svg, canvas", viewerType)
+	}
+}
+
+func TestManifestValidate_EditAllowOutsideDir(t *testing.T) {
+	for _, editPath := range []string{"../secret.xml", "/etc/passwd", "a/../../b.xml"} {
+		m := newTestManifest("html")
+		m.Viewers[0].EditAllow = append(m.Viewers[0].EditAllow, editPath)
+		assert.ErrorContains(t, m.Validate(), "must be inside the manifest directory", editPath)
+	}
+}
+
+func TestViewerBindingCanEdit(t *testing.T) {
+	binding := &ViewerBinding{EditAllow: []string{"vdvc-register.xml", "schema/vdvc-register.xsd"}}
+
+	assert.Equal(t, []string{"registers/vdvc-register.xml", "registers/schema/vdvc-register.xsd"}, binding.EditAllowPaths("registers"))
+	assert.True(t, binding.CanEdit("registers", "registers/vdvc-register.xml"))
+	assert.True(t, binding.CanEdit("registers", "registers/schema/vdvc-register.xsd"))
+	assert.True(t, binding.CanEdit("registers", "registers/./schema/vdvc-register.xsd"))
+	assert.False(t, binding.CanEdit("registers", "registers/other.xml"))
+	assert.False(t, binding.CanEdit("registers", "vdvc-register.xml"))
+
+	// manifest in the repository root
+	assert.True(t, binding.CanEdit("", "schema/vdvc-register.xsd"))
+}
@@ -3,7 +3,10 @@
 
 package processgitviewer
 
-import "path"
+import (
+	"path"
+	"strings"
+)
 
 func joinFromDir(dir, name string) string {
 	if dir == "" {
@@ -11,3 +14,12 @@ func joinFromDir(dir, name string) string {
 	}
 	return path.Join(dir, name)
 }
+
+// isLocalPath reports whether name is a relative path that stays within its base directory.
+func isLocalPath(name string) bool {
+	if name == "" || path.IsAbs(name) {
+		return false
+	}
+	cleaned := path.Clean(name)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
@@ -25,6 +25,7 @@ func ResolveBinding(commit *git.Commit, dir string, repoTreePath string, manifes
 			continue
 		}
 
+		// every viewer type loads its entry file, so it must exist regardless of type
 		entryPath := joinFromDir(dir, binding.Entry)
 		entry, err := commit.GetTreeEntryByPath(entryPath)
 		if err != nil {
@@ -45,9 +46,8 @@ func ResolveBinding(commit *git.Commit, dir string, repoTreePath string, manifes
 			}
 		}
 
-		editAllowed := false
-		for _, editPath := range binding.EditAllow {
-			fullPath := joinFromDir(dir, editPath)
+		// edit_allow may list files beyond the primary one; all of them must exist
+		for _, fullPath := range binding.EditAllowPaths(dir) {
 			editEntry, err := commit.GetTreeEntryByPath(fullPath)
 			if err != nil {
 				return nil, fmt.Errorf("edit_allow path %s missing: %w", fullPath, err)
@@ -55,12 +55,9 @@ func ResolveBinding(commit *git.Commit, dir string, repoTreePath string, manifes
 			if editEntry.IsDir() {
 				return nil, fmt.Errorf("edit_allow path %s is a directory", fullPath)
 			}
-			if fullPath == repoTreePath {
-				editAllowed = true
-			}
 		}
 
-		if !editAllowed {
+		if !binding.CanEdit(dir, repoTreePath) {
 			return nil, fmt.Errorf("edit_allow does not include primary file %s", repoTreePath)
 		}
 
@@ -3,7 +3,11 @@
 
 package setting
 
-import "strconv"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Chat agent settings
 var Chat = struct {
@@ -12,12 +16,44 @@ var Chat = struct {
 	RateLimitPerMinute int
 	MaxMonthlyBudget   float64
 	DefaultProvider    string
+	MaxTokensLimit     int // ceiling of llm.max_tokens, 0 for none
+	MaxMessageLength   int // longest user message in characters, 0 for no limit
+	HistorySearchLimit int // conversations read by a history content search
+	AnthropicBaseURL   string
+	OpenAIBaseURL      string
+	OllamaBaseURL      string
+
+	// MCPAllowedHosts lists the host patterns that additional MCP servers of a chat agent may use, empty for any.
+	MCPAllowedHosts string
+	// MCPAllowLocalNetworks permits additional MCP servers on loopback and private addresses.
+	MCPAllowLocalNetworks bool
+
+	// HistoryShardThreshold is the number of conversation files in a day directory of the history
+	// branch after which new ones go into sub-directories by conversation ID, 0 to never shard.
+	HistoryShardThreshold int
+
+	// NewConversationOnAgentChange starts a new conversation when a message continues one
+	// that was held with another agent file, instead of rejecting it with 409 Conflict.
+	NewConversationOnAgentChange bool
+
+	// SSEKeepaliveInterval is how often a keepalive comment is sent on a chat stream while
+	// waiting for the model, so clients and proxies don't close an idle connection.
+	SSEKeepaliveInterval time.Duration
 }{
 	Enabled:            true,
 	MaxAgentsPerRepo:   10,
 	RateLimitPerMinute: 10,
 	MaxMonthlyBudget:   100.0,
 	DefaultProvider:    "anthropic",
+	MaxTokensLimit:     8192,
+	MaxMessageLength:   20000,
+	HistorySearchLimit: 100,
+	AnthropicBaseURL:   "https://api.anthropic.com",
+	OpenAIBaseURL:      "https://api.openai.com",
+	OllamaBaseURL:      "http://localhost:11434",
+
+	HistoryShardThreshold: 1000,
+	SSEKeepaliveInterval:  15 * time.Second,
 }
 
 func loadChatFrom(rootCfg ConfigProvider) {
@@ -30,4 +66,18 @@ func loadChatFrom(rootCfg ConfigProvider) {
 		Chat.MaxMonthlyBudget = maxBudget
 	}
 	Chat.DefaultProvider = sec.Key("DEFAULT_PROVIDER").MustString("anthropic")
+	Chat.MaxTokensLimit = sec.Key("MAX_TOKENS_LIMIT").MustInt(8192)
+	Chat.MaxMessageLength = max(sec.Key("MAX_MESSAGE_LENGTH").MustInt(20000), 0)
+	Chat.HistorySearchLimit = sec.Key("HISTORY_SEARCH_LIMIT").MustInt(100)
+	Chat.HistoryShardThreshold = max(sec.Key("HISTORY_SHARD_THRESHOLD").MustInt(1000), 0)
+	Chat.AnthropicBaseURL = strings.TrimSuffix(sec.Key("ANTHROPIC_BASE_URL").MustString("https://api.anthropic.com"), "/")
+	Chat.OpenAIBaseURL = strings.TrimSuffix(sec.Key("OPENAI_BASE_URL").MustString("https://api.openai.com"), "/")
+	Chat.OllamaBaseURL = strings.TrimSuffix(sec.Key("OLLAMA_BASE_URL").MustString("http://localhost:11434"), "/")
+	Chat.MCPAllowedHosts = sec.Key("MCP_ALLOWED_HOSTS").MustString("")
+	Chat.MCPAllowLocalNetworks = sec.Key("MCP_ALLOW_LOCAL_NETWORKS").MustBool(false)
+	Chat.NewConversationOnAgentChange = sec.Key("NEW_CONVERSATION_ON_AGENT_CHANGE").MustBool(false)
+	Chat.SSEKeepaliveInterval = sec.Key("SSE_KEEPALIVE_INTERVAL").MustDuration(15 * time.Second)
+	if Chat.SSEKeepaliveInterval <= 0 {
+		Chat.SSEKeepaliveInterval = 15 * time.Second
+	}
 }
@@ -3,26 +3,62 @@
 
 package setting
 
+import "time"
+
 // MCP server settings
 var MCP = struct {
 	Enabled            bool
+	AllowAnonymous     bool
 	MaxServersPerUser  int
 	RateLimitPerMinute int
 	SessionTimeoutSec  int
+	SessionIdleTimeout time.Duration
 	MaxResponseSizeMB  int
+	MaxSourceSizeMB    int // largest repository file read as a source, schema or instructions
+
+	// SSEKeepaliveInterval is how often keepalive comments are sent on SSE streams,
+	// shorten it when a proxy closes idle connections sooner.
+	SSEKeepaliveInterval time.Duration
+	MaxSSESessions       int
+
+	// MaxResultLimit caps the limit argument of tools returning lists of entities.
+	MaxResultLimit int
 }{
 	Enabled:            true,
+	AllowAnonymous:     true,
 	MaxServersPerUser:  50,
 	RateLimitPerMinute: 120,
 	SessionTimeoutSec:  3600,
+	SessionIdleTimeout: 5 * time.Minute,
 	MaxResponseSizeMB:  5,
+	MaxSourceSizeMB:    50,
+
+	SSEKeepaliveInterval: 30 * time.Second,
+	MaxSSESessions:       100,
+
+	MaxResultLimit: 500,
 }
 
 func loadMCPFrom(rootCfg ConfigProvider) {
 	sec := rootCfg.Section("mcp")
 	MCP.Enabled = sec.Key("ENABLED").MustBool(true)
+	MCP.AllowAnonymous = sec.Key("ALLOW_ANONYMOUS").MustBool(true)
 	MCP.MaxServersPerUser = sec.Key("MAX_SERVERS_PER_USER").MustInt(50)
 	MCP.RateLimitPerMinute = sec.Key("RATE_LIMIT_PER_MINUTE").MustInt(120)
 	MCP.SessionTimeoutSec = sec.Key("SESSION_TIMEOUT").MustInt(3600)
+	MCP.SessionIdleTimeout = sec.Key("SESSION_IDLE_TIMEOUT").MustDuration(5 * time.Minute)
 	MCP.MaxResponseSizeMB = sec.Key("MAX_RESPONSE_SIZE_MB").MustInt(5)
+	MCP.MaxSourceSizeMB = sec.Key("MAX_SOURCE_SIZE_MB").MustInt(50)
+	MCP.SSEKeepaliveInterval = sec.Key("SSE_KEEPALIVE_INTERVAL").MustDuration(30 * time.Second)
+	if MCP.SSEKeepaliveInterval <= 0 {
+		MCP.SSEKeepaliveInterval = 30 * time.Second
+	}
+	MCP.MaxSSESessions = sec.Key("MAX_SSE_SESSIONS").MustInt(100)
+	if MCP.MaxSSESessions <= 0 {
+		MCP.MaxSSESessions = 100
+	}
+	MCP.MaxResultLimit = sec.Key("MAX_RESULT_LIMIT").MustInt(500)
+	if MCP.MaxResultLimit <= 0 {
+		MCP.MaxResultLimit = 500
+	}
 }
@@ -150,6 +150,7 @@ func loadCommonSettingsFrom(cfg ConfigProvider) error {
 	loadGlobalLockFrom(cfg)
 	loadMCPFrom(cfg)
 	loadChatFrom(cfg)
+	loadUAPFFrom(cfg)
 	loadOtherFrom(cfg)
 	return nil
 }
@@ -0,0 +1,21 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+// UAPF package import settings
+var UAPF = struct {
+	// MaxExtractedSizeMB caps the total size of the files extracted from an imported package, 0 for no cap.
+	MaxExtractedSizeMB int64
+	// MaxCompressionRatio caps the extracted size as a multiple of the archive size, 0 for no cap.
+	MaxCompressionRatio int64
+}{
+	MaxExtractedSizeMB:  1024,
+	MaxCompressionRatio: 100,
+}
+
+func loadUAPFFrom(rootCfg ConfigProvider) {
+	sec := rootCfg.Section("uapf")
+	UAPF.MaxExtractedSizeMB = sec.Key("MAX_EXTRACTED_SIZE_MB").MustInt64(1024)
+	UAPF.MaxCompressionRatio = sec.Key("MAX_COMPRESSION_RATIO").MustInt64(100)
+}
@@ -7,16 +7,31 @@ import (
 	"bytes"
 	"encoding/xml"
 	"io"
+	"strings"
 )
 
 // DVSXMLSniffLimit caps how many bytes are inspected when trying to detect a typed DVS XML.
 const DVSXMLSniffLimit = 32 * 1024
 
+// dvsXMLRoot identifies a typed DVS XML document by its namespace and root element local name.
+type dvsXMLRoot struct {
+	namespace string
+	localName string
+}
+
+// dvsXMLTypes maps known DVS document roots to the detected type.
+var dvsXMLTypes = map[dvsXMLRoot]string{
+	{"https://vdvc.gov.lv/schema/dvs/classification-scheme/v1", "KlasifikacijasShema"}:    "dvs.classification-scheme",
+	{"https://vdvc.gov.lv/schema/dvs/document-metadata/v1", "DvsDokumenti"}:               "dvs.document-metadata",
+	{"https://vdvc.gov.lv/schema/dvs/organization-register/v1", "OrganizacijuRegistrs"}:   "dvs.organization-register",
+	{"https://vdvc.gov.lv/schema/dvs/retention-schedule/v1", "GlabasanasTerminuSaraksts"}: "dvs.retention-schedule",
+}
+
 // DetectDVSXMLType tries to detect ProcessGit "typed XML" documents used by DVS registries.
 // It only looks at the first start element and inspects:
 //   - the root element local name
 //   - the default namespace
-//   - optional xsi:schemaLocation
+//   - optional xsi:schemaLocation, whose namespaces are tried when the element namespace is not a known DVS one
 func DetectDVSXMLType(contentPrefix []byte) (typ string, meta map[string]string, ok bool) {
 	if len(contentPrefix) == 0 {
 		return "", nil, false
@@ -59,16 +74,28 @@ func DetectDVSXMLType(contentPrefix []byte) (typ string, meta map[string]string,
 			}
 		}
 
-		ns := meta["namespace"]
-		switch {
-		case ns == "https://vdvc.gov.lv/schema/dvs/classification-scheme/v1" && start.Name.Local == "KlasifikacijasShema":
-			return "dvs.classification-scheme", meta, true
-		case ns == "https://vdvc.gov.lv/schema/dvs/document-metadata/v1" && start.Name.Local == "DvsDokumenti":
-			return "dvs.document-metadata", meta, true
-		default:
-			return "", meta, false
+		if typ, ok = dvsXMLTypes[dvsXMLRoot{namespace: meta["namespace"], localName: start.Name.Local}]; ok {
+			return typ, meta, true
 		}
+		for _, ns := range schemaLocationNamespaces(meta["schemaLocation"]) {
+			if typ, ok = dvsXMLTypes[dvsXMLRoot{namespace: ns, localName: start.Name.Local}]; ok {
+				meta["schemaNamespace"] = ns
+				return typ, meta, true
+			}
+		}
+		return "", meta, false
 	}
 
 	return "", meta, false
 }
+
+// schemaLocationNamespaces returns the namespace URIs of an xsi:schemaLocation value,
+// which is a whitespace separated list of "namespace location" pairs.
+func schemaLocationNamespaces(schemaLocation string) []string {
+	fields := strings.Fields(schemaLocation)
+	namespaces := make([]string, 0, (len(fields)+1)/2)
+	for i := 0; i < len(fields); i += 2 {
+		namespaces = append(namespaces, fields[i])
+	}
+	return namespaces
+}
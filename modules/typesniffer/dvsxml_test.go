@@ -15,7 +15,9 @@ func TestDetectDVSXMLType(t *testing.T) {
 		wantType   string
 		wantNS     string
 		wantSchema string
-		wantOK     bool
+		// wantSchemaNS is the schemaLocation namespace used when the element namespace did not match
+		wantSchemaNS string
+		wantOK       bool
 	}{
 		{
 			name: "classification scheme",
@@ -34,6 +36,45 @@ func TestDetectDVSXMLType(t *testing.T) {
 			wantNS:   "https://vdvc.gov.lv/schema/dvs/document-metadata/v1",
 			wantOK:   true,
 		},
+		{
+			name:     "organization register",
+			data:     `<OrganizacijuRegistrs xmlns="https://vdvc.gov.lv/schema/dvs/organization-register/v1"></OrganizacijuRegistrs>`,
+			wantType: "dvs.organization-register",
+			wantNS:   "https://vdvc.gov.lv/schema/dvs/organization-register/v1",
+			wantOK:   true,
+		},
+		{
+			name:     "retention schedule",
+			data:     `<GlabasanasTerminuSaraksts xmlns="https://vdvc.gov.lv/schema/dvs/retention-schedule/v1"></GlabasanasTerminuSaraksts>`,
+			wantType: "dvs.retention-schedule",
+			wantNS:   "https://vdvc.gov.lv/schema/dvs/retention-schedule/v1",
+			wantOK:   true,
+		},
+		{
+			name: "prefixed root with schemaLocation only",
+			data: `<?xml version="1.0"?>
+<k:KlasifikacijasShema xmlns:k="urn:vdvc:klasifikacija" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.w3.org/1999/xhtml xhtml.xsd https://vdvc.gov.lv/schema/dvs/classification-scheme/v1 schema.xsd">
+</k:KlasifikacijasShema>`,
+			wantType:     "dvs.classification-scheme",
+			wantNS:       "urn:vdvc:klasifikacija",
+			wantSchema:   "http://www.w3.org/1999/xhtml xhtml.xsd https://vdvc.gov.lv/schema/dvs/classification-scheme/v1 schema.xsd",
+			wantSchemaNS: "https://vdvc.gov.lv/schema/dvs/classification-scheme/v1",
+			wantOK:       true,
+		},
+		{
+			name:     "schemaLocation with another root element",
+			data:     `<x:Other xmlns:x="urn:other" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="https://vdvc.gov.lv/schema/dvs/classification-scheme/v1 schema.xsd"></x:Other>`,
+			wantType: "",
+			wantNS:   "urn:other",
+			wantOK:   false,
+		},
+		{
+			name:     "known namespace with another root element",
+			data:     `<DvsDokumenti xmlns="https://vdvc.gov.lv/schema/dvs/retention-schedule/v1"></DvsDokumenti>`,
+			wantType: "",
+			wantNS:   "https://vdvc.gov.lv/schema/dvs/retention-schedule/v1",
+			wantOK:   false,
+		},
 		{
 			name:     "unknown xml",
 			data:     `<root xmlns="https://example.com/schema/v1"></root>`,
@@ -61,6 +102,9 @@ func TestDetectDVSXMLType(t *testing.T) {
 			if tc.wantSchema != "" && meta["schemaLocation"] != tc.wantSchema {
 				t.Fatalf("schemaLocation=%q, want %q", meta["schemaLocation"], tc.wantSchema)
 			}
+			if ns := meta["schemaNamespace"]; ns != tc.wantSchemaNS {
+				t.Fatalf("schemaNamespace=%q, want %q", ns, tc.wantSchemaNS)
+			}
 			if name := strings.TrimSpace(meta["localName"]); name == "" {
 				t.Fatalf("localName should be captured")
 			}
@@ -5,7 +5,10 @@ package uapf
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -89,17 +92,20 @@ func ExportUAPF(ctx context.Context, repo *repo_model.Repository, ref string) (i
 	pr, pw := io.Pipe()
 	go func() {
 		zw := zip.NewWriter(pw)
-		if err := writeBytesEntry(zw, "manifest.json", manifestData); err != nil {
+		var checksums bytes.Buffer
+		sum, err := writeBytesEntry(zw, "manifest.json", manifestData)
+		if err != nil {
 			_ = pw.CloseWithError(err)
 			return
 		}
+		writeChecksumLine(&checksums, sum, "manifest.json")
 
 		for _, entry := range entries {
 			if entry.IsDir() {
 				continue
 			}
 			name := entry.Name()
-			if name == "" || name == "manifest.json" {
+			if name == "" || name == "manifest.json" || name == ChecksumsFileName {
 				delete(requiredPaths, name)
 				continue
 			}
@@ -107,10 +113,12 @@ func ExportUAPF(ctx context.Context, repo *repo_model.Repository, ref string) (i
 				_ = pw.CloseWithError(fmt.Errorf("exporting submodules is not supported: %s", name))
 				return
 			}
-			if err := writeTreeEntry(zw, entry, name); err != nil {
+			sum, err := writeTreeEntry(zw, entry, name)
+			if err != nil {
 				_ = pw.CloseWithError(err)
 				return
 			}
+			writeChecksumLine(&checksums, sum, name)
 			delete(requiredPaths, name)
 		}
 
@@ -124,6 +132,11 @@ func ExportUAPF(ctx context.Context, repo *repo_model.Repository, ref string) (i
 			return
 		}
 
+		if _, err := writeBytesEntry(zw, ChecksumsFileName, checksums.Bytes()); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
 		if err := zw.Close(); err != nil {
 			_ = pw.CloseWithError(err)
 			return
@@ -161,21 +174,26 @@ func sanitizeFilename(s string) string {
 	return s
 }
 
-func writeBytesEntry(zw *zip.Writer, name string, data []byte) error {
+// writeBytesEntry adds data to the archive as name and returns its SHA-256 checksum.
+func writeBytesEntry(zw *zip.Writer, name string, data []byte) ([]byte, error) {
 	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
 	header.SetMode(0o644)
 	writer, err := zw.CreateHeader(header)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
 	}
-	_, err = writer.Write(data)
-	return err
+	sum := sha256.Sum256(data)
+	return sum[:], nil
 }
 
-func writeTreeEntry(zw *zip.Writer, entry *git.TreeEntry, name string) error {
+// writeTreeEntry adds the blob of entry to the archive as name and returns its SHA-256 checksum.
+func writeTreeEntry(zw *zip.Writer, entry *git.TreeEntry, name string) ([]byte, error) {
 	reader, err := entry.Blob().DataAsync()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer reader.Close()
 
@@ -187,11 +205,19 @@ func writeTreeEntry(zw *zip.Writer, entry *git.TreeEntry, name string) error {
 	header.SetMode(mode)
 	writer, err := zw.CreateHeader(header)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = io.Copy(writer, reader)
-	return err
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(writer, hash), reader); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// writeChecksumLine appends the checksum of name to buf in the "sha256sum" format.
+func writeChecksumLine(buf *bytes.Buffer, sum []byte, name string) {
+	fmt.Fprintf(buf, "%s  %s\n", hex.EncodeToString(sum), name)
 }
 
 func readTreeEntry(entry *git.TreeEntry) ([]byte, error) {
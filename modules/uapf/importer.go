@@ -9,9 +9,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	repo_model "code.gitea.io/gitea/models/repo"
@@ -24,11 +26,13 @@ import (
 	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
-// ImportUAPF extracts a .uapf archive and commits its contents into the repository.
-func ImportUAPF(ctx context.Context, repo *repo_model.Repository, doer *user_model.User, commitMsg string, zipData io.Reader, zipSize int64, targetPath string) error {
+// ImportUAPF extracts a .uapf archive and commits its contents into the repository under
+// targetPath, or if that is empty under the install_path of the manifest, and returns the
+// directory it was imported into ("" for the repository root).
+func ImportUAPF(ctx context.Context, repo *repo_model.Repository, doer *user_model.User, commitMsg string, zipData io.Reader, zipSize int64, targetPath string) (string, error) {
 	maxSize := setting.Repository.Upload.FileMaxSize << 20
 	if maxSize > 0 && zipSize > maxSize {
-		return fmt.Errorf("package exceeds maximum size: %d bytes > %d bytes", zipSize, maxSize)
+		return "", fmt.Errorf("package exceeds maximum size: %d bytes > %d bytes", zipSize, maxSize)
 	}
 
 	limitedReader := io.Reader(zipData)
@@ -38,74 +42,78 @@ func ImportUAPF(ctx context.Context, repo *repo_model.Repository, doer *user_mod
 
 	buffer, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return fmt.Errorf("read package: %w", err)
+		return "", fmt.Errorf("read package: %w", err)
 	}
 	if maxSize > 0 && int64(len(buffer)) > maxSize {
-		return fmt.Errorf("package exceeds maximum size: %d bytes > %d bytes", len(buffer), maxSize)
+		return "", fmt.Errorf("package exceeds maximum size: %d bytes > %d bytes", len(buffer), maxSize)
 	}
 
 	if err := ValidatePackage(buffer); err != nil {
-		return err
+		return "", err
 	}
 
 	tempDir, err := os.MkdirTemp("", "uapf-import-*")
 	if err != nil {
-		return fmt.Errorf("create temp dir: %w", err)
+		return "", fmt.Errorf("create temp dir: %w", err)
 	}
 	defer func() { _ = os.RemoveAll(tempDir) }()
 
 	readerAt := bytes.NewReader(buffer)
 	zipReader, err := zip.NewReader(readerAt, int64(len(buffer)))
 	if err != nil {
-		return fmt.Errorf("invalid .uapf archive: %w", err)
+		return "", fmt.Errorf("invalid .uapf archive: %w", err)
 	}
 
-	if err := extractZipSafe(zipReader, tempDir); err != nil {
-		return err
+	modes, err := extractZipSafe(zipReader, tempDir, maxExtractedSize(int64(len(buffer))))
+	if err != nil {
+		return "", err
 	}
 
 	packageRoot, err := determinePackageRoot(tempDir)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := checkSymlinkTargets(packageRoot, modes); err != nil {
+		return "", err
 	}
 
 	manifestPath := filepath.Join(packageRoot, "manifest.json")
 	manifestBytes, err := os.ReadFile(manifestPath)
 	if err != nil {
-		return fmt.Errorf("manifest.json is required in the UAPF package")
+		return "", fmt.Errorf("manifest.json is required in the UAPF package")
 	}
 
 	if err := ValidateManifest(manifestBytes); err != nil {
-		return err
+		return "", err
 	}
 
 	var manifest spec.Manifest
 	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		return fmt.Errorf("manifest.json is not valid JSON: %w", err)
+		return "", fmt.Errorf("manifest.json is not valid JSON: %w", err)
 	}
 
 	refPaths, err := spec.ValidateManifest(&manifest)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	for _, ref := range refPaths {
 		if ref == "" {
-			return fmt.Errorf("referenced path cannot be empty")
+			return "", fmt.Errorf("referenced path cannot be empty")
 		}
 		if _, err := os.Stat(filepath.Join(packageRoot, filepath.FromSlash(ref))); err != nil {
-			return fmt.Errorf("referenced path missing in package: %s", ref)
+			return "", fmt.Errorf("referenced path missing in package: %s", ref)
 		}
 	}
 
-	targetPath, err = normalizeTargetPath(targetPath)
+	targetPath, err = resolveTargetPath(targetPath, &manifest)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	operations, err := buildFileOperations(ctx, repo, packageRoot, targetPath)
+	operations, err := buildFileOperations(ctx, repo, packageRoot, targetPath, modes)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if commitMsg == "" {
@@ -145,61 +153,148 @@ func ImportUAPF(ctx context.Context, repo *repo_model.Repository, doer *user_mod
 		},
 	}
 
-	_, err = files_service.ChangeRepoFiles(ctx, repo, doer, changeOpts)
-	return err
+	if _, err := files_service.ChangeRepoFiles(ctx, repo, doer, changeOpts); err != nil {
+		return "", err
+	}
+	return targetPath, nil
+}
+
+// maxExtractedSize returns how many bytes an archive of the given size may extract to,
+// or 0 if there is no limit.
+func maxExtractedSize(archiveSize int64) int64 {
+	limit := setting.UAPF.MaxExtractedSizeMB << 20
+	if ratio := setting.UAPF.MaxCompressionRatio; ratio > 0 && (limit <= 0 || archiveSize*ratio < limit) {
+		limit = archiveSize * ratio
+	}
+	return limit
 }
 
-func extractZipSafe(zr *zip.Reader, dest string) error {
+// maxSymlinkTargetSize bounds the link target of a symlink entry.
+const maxSymlinkTargetSize = 4096
+
+// extractZipSafe extracts the archive into dest, rejecting entries outside of it and, if maxSize
+// is positive, archives whose files add up to more than maxSize bytes. Symlinks are extracted
+// as regular files holding the link target, so they can't be followed on disk. The returned
+// map holds the git mode of the extracted files that are executables or symlinks.
+func extractZipSafe(zr *zip.Reader, dest string, maxSize int64) (map[string]git.EntryMode, error) {
+	modes := make(map[string]git.EntryMode)
+	var extracted int64
 	for _, file := range zr.File {
 		cleanName := filepath.Clean(file.Name)
 		if cleanName == "." || cleanName == "" {
 			continue
 		}
 		if filepath.IsAbs(cleanName) || strings.HasPrefix(cleanName, "..") || filepath.VolumeName(cleanName) != "" {
-			return fmt.Errorf("invalid entry path in archive: %s", file.Name)
+			return nil, fmt.Errorf("invalid entry path in archive: %s", file.Name)
 		}
 
 		target := filepath.Join(dest, cleanName)
 		if !strings.HasPrefix(target, dest+string(os.PathSeparator)) && target != dest {
-			return fmt.Errorf("archive entry escapes destination: %s", file.Name)
+			return nil, fmt.Errorf("archive entry escapes destination: %s", file.Name)
 		}
 
 		if file.FileInfo().IsDir() {
 			if err := os.MkdirAll(target, 0o755); err != nil {
-				return fmt.Errorf("create directory %s: %w", cleanName, err)
+				return nil, fmt.Errorf("create directory %s: %w", cleanName, err)
 			}
 			continue
 		}
 
 		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-			return fmt.Errorf("create directory for %s: %w", cleanName, err)
+			return nil, fmt.Errorf("create directory for %s: %w", cleanName, err)
 		}
 
 		rc, err := file.Open()
 		if err != nil {
-			return fmt.Errorf("open %s: %w", cleanName, err)
+			return nil, fmt.Errorf("open %s: %w", cleanName, err)
 		}
 
-		if err := writeFile(target, rc, file.FileInfo().Mode()); err != nil {
-			rc.Close()
-			return err
+		// the declared sizes can't be trusted, so count what is actually written
+		var r io.Reader = rc
+		if maxSize > 0 {
+			r = io.LimitReader(rc, maxSize-extracted+1)
+		}
+		mode := file.FileInfo().Mode()
+		if mode&os.ModeSymlink != 0 {
+			linkTarget, err := readSymlinkTarget(r, cleanName)
+			if err != nil {
+				rc.Close()
+				return nil, err
+			}
+			r, mode = strings.NewReader(linkTarget), 0o644
+			modes[target] = git.EntryModeSymlink
+		} else if mode.Perm()&0o111 != 0 {
+			modes[target] = git.EntryModeExec
 		}
+		n, err := writeFile(target, r, mode.Perm())
 		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		extracted += n
+		if maxSize > 0 && extracted > maxSize {
+			return nil, fmt.Errorf("package expands to more than %d bytes when extracted", maxSize)
+		}
+	}
+	return modes, nil
+}
+
+// readSymlinkTarget reads the link target of the symlink entry name, which must be a relative
+// path that stays within the package.
+func readSymlinkTarget(r io.Reader, name string) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSymlinkTargetSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read symlink %s: %w", name, err)
+	}
+	linkTarget := string(data)
+	if len(data) > maxSymlinkTargetSize || !isLocalSymlink(name, linkTarget) {
+		return "", fmt.Errorf("symlink %s points outside of the package: %s", name, linkTarget)
+	}
+	return linkTarget, nil
+}
+
+// checkSymlinkTargets verifies that the extracted symlinks point within packageRoot, which
+// readSymlinkTarget can't do for a package in a top-level directory of the archive.
+func checkSymlinkTargets(packageRoot string, modes map[string]git.EntryMode) error {
+	for _, pathOnDisk := range slices.Sorted(maps.Keys(modes)) {
+		if modes[pathOnDisk] != git.EntryModeSymlink {
+			continue
+		}
+		name, err := filepath.Rel(packageRoot, pathOnDisk)
+		if err != nil {
+			return fmt.Errorf("locate symlink %s: %w", pathOnDisk, err)
+		}
+		name = filepath.ToSlash(name)
+		data, err := os.ReadFile(pathOnDisk)
+		if err != nil {
+			return fmt.Errorf("read symlink %s: %w", name, err)
+		}
+		if !isLocalSymlink(name, string(data)) {
+			return fmt.Errorf("symlink %s points outside of the package: %s", name, data)
+		}
 	}
 	return nil
 }
 
-func writeFile(dst string, r io.Reader, mode os.FileMode) error {
+// isLocalSymlink reports whether linkTarget, read from the symlink name, is a relative path
+// that stays within the directory name is relative to.
+func isLocalSymlink(name, linkTarget string) bool {
+	return linkTarget != "" && !path.IsAbs(linkTarget) &&
+		filepath.IsLocal(filepath.Join(filepath.Dir(name), filepath.FromSlash(linkTarget)))
+}
+
+func writeFile(dst string, r io.Reader, mode os.FileMode) (int64, error) {
 	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
-		return fmt.Errorf("create file %s: %w", dst, err)
+		return 0, fmt.Errorf("create file %s: %w", dst, err)
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(f, r); err != nil {
-		return fmt.Errorf("write file %s: %w", dst, err)
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return n, fmt.Errorf("write file %s: %w", dst, err)
 	}
-	return nil
+	return n, nil
 }
 
 func determinePackageRoot(tempDir string) (string, error) {
@@ -222,6 +317,15 @@ func determinePackageRoot(tempDir string) (string, error) {
 	return "", fmt.Errorf("manifest.json is required in the UAPF package")
 }
 
+// resolveTargetPath returns the directory to import into: the importer's target if set, else
+// the install_path declared by the manifest, else the repository root.
+func resolveTargetPath(target string, manifest *spec.Manifest) (string, error) {
+	if strings.TrimSpace(target) != "" || manifest.InstallPath == "" {
+		return normalizeTargetPath(target)
+	}
+	return spec.CleanInstallPath(manifest.InstallPath)
+}
+
 func normalizeTargetPath(target string) (string, error) {
 	target = strings.TrimSpace(target)
 	if target == "" {
@@ -238,7 +342,9 @@ func normalizeTargetPath(target string) (string, error) {
 	return clean, nil
 }
 
-func buildFileOperations(ctx context.Context, repo *repo_model.Repository, packageRoot, targetPath string) ([]*files_service.ChangeRepoFile, error) {
+// buildFileOperations returns the operations creating the extracted files of packageRoot under
+// targetPath, with the git modes of executables and symlinks from modes.
+func buildFileOperations(ctx context.Context, repo *repo_model.Repository, packageRoot, targetPath string, modes map[string]git.EntryMode) ([]*files_service.ChangeRepoFile, error) {
 	ops := make([]*files_service.ChangeRepoFile, 0)
 	root := packageRoot
 
@@ -269,6 +375,10 @@ func buildFileOperations(ctx context.Context, repo *repo_model.Repository, packa
 		if err != nil {
 			return err
 		}
+		if rel == ChecksumsFileName {
+			// verified by ValidatePackage and generated again on export
+			return nil
+		}
 		treePath := path.Join(targetPath, filepath.ToSlash(rel))
 		treePath = files_service.CleanGitTreePath(treePath)
 		if treePath == "" {
@@ -293,6 +403,7 @@ func buildFileOperations(ctx context.Context, repo *repo_model.Repository, packa
 			Operation:     "create",
 			TreePath:      treePath,
 			ContentReader: content,
+			EntryMode:     modes[pathOnDisk],
 		})
 		return nil
 	})
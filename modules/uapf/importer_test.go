@@ -0,0 +1,192 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package uapf
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/uapf/spec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxExtractedSize(t *testing.T) {
+	defer test.MockVariableValue(&setting.UAPF.MaxExtractedSizeMB, 1)()
+	defer test.MockVariableValue(&setting.UAPF.MaxCompressionRatio, 100)()
+	assert.EqualValues(t, 1000*100, maxExtractedSize(1000))
+	assert.EqualValues(t, 1<<20, maxExtractedSize(100_000))
+
+	setting.UAPF.MaxCompressionRatio = 0
+	assert.EqualValues(t, 1<<20, maxExtractedSize(1000))
+	setting.UAPF.MaxExtractedSizeMB = 0
+	assert.EqualValues(t, 0, maxExtractedSize(1000))
+}
+
+func TestExtractZipSafe_ZipBomb(t *testing.T) {
+	defer test.MockVariableValue(&setting.UAPF.MaxExtractedSizeMB, 1024)()
+	defer test.MockVariableValue(&setting.UAPF.MaxCompressionRatio, 100)()
+
+	// 8 MiB of zeros deflates to a few KiB
+	data := buildTestPackage(t, map[string]string{
+		"manifest.json": `{"name": "bomb", "version": "1.0.0"}`,
+		"zeros.bin":     strings.Repeat("\x00", 8<<20),
+	})
+	require.Less(t, len(data), 64<<10)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	_, err = extractZipSafe(zr, t.TempDir(), maxExtractedSize(int64(len(data))))
+	assert.ErrorContains(t, err, "package expands to more than")
+
+	// the same archive is fine without a ratio limit
+	setting.UAPF.MaxCompressionRatio = 0
+	_, err = extractZipSafe(zr, t.TempDir(), maxExtractedSize(int64(len(data))))
+	assert.NoError(t, err)
+}
+
+func buildModeTestPackage(t *testing.T, linkTarget string) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range []struct {
+		name, content string
+		mode          os.FileMode
+	}{
+		{"manifest.json", `{"name": "scripts", "version": "1.0.0"}`, 0o644},
+		{"bin/run.sh", "#!/bin/sh\necho run\n", 0o755},
+		{"bin/latest", linkTarget, os.ModeSymlink | 0o777},
+	} {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+		w, err := zw.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func TestImportExecutablesAndSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	modes, err := extractZipSafe(buildModeTestPackage(t, "run.sh"), dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]git.EntryMode{
+		filepath.Join(dir, "bin/run.sh"): git.EntryModeExec,
+		filepath.Join(dir, "bin/latest"): git.EntryModeSymlink,
+	}, modes)
+
+	// the symlink is a regular file on disk
+	info, err := os.Lstat(filepath.Join(dir, "bin/latest"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+
+	ops, err := buildFileOperations(t.Context(), &repo_model.Repository{IsEmpty: true}, dir, "pkg", modes)
+	require.NoError(t, err)
+	byPath := make(map[string]git.EntryMode)
+	for _, op := range ops {
+		byPath[op.TreePath] = op.EntryMode
+		if op.TreePath == "pkg/bin/latest" {
+			content, err := io.ReadAll(op.ContentReader)
+			require.NoError(t, err)
+			assert.Equal(t, "run.sh", string(content))
+		}
+	}
+	assert.Equal(t, map[string]git.EntryMode{
+		"pkg/manifest.json": git.EntryModeNoEntry,
+		"pkg/bin/run.sh":    git.EntryModeExec,
+		"pkg/bin/latest":    git.EntryModeSymlink,
+	}, byPath)
+
+	for _, linkTarget := range []string{"../../outside", "/etc/passwd", ""} {
+		_, err := extractZipSafe(buildModeTestPackage(t, linkTarget), t.TempDir(), 0)
+		assert.ErrorContains(t, err, "symlink bin/latest points outside of the package", linkTarget)
+	}
+}
+
+func TestImportSymlinksInPackageDirectory(t *testing.T) {
+	extract := func(t *testing.T, linkTarget string) (string, map[string]git.EntryMode) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, entry := range []struct {
+			name, content string
+			mode          os.FileMode
+		}{
+			{"pkg/manifest.json", `{"name": "scripts", "version": "1.0.0"}`, 0o644},
+			{"pkg/bin/run.sh", "#!/bin/sh\necho run\n", 0o755},
+			{"pkg/bin/latest", linkTarget, os.ModeSymlink | 0o777},
+		} {
+			header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+			header.SetMode(entry.mode)
+			w, err := zw.CreateHeader(header)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(entry.content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		modes, err := extractZipSafe(zr, dir, 0)
+		require.NoError(t, err)
+		packageRoot, err := determinePackageRoot(dir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "pkg"), packageRoot)
+		return packageRoot, modes
+	}
+
+	packageRoot, modes := extract(t, "run.sh")
+	assert.NoError(t, checkSymlinkTargets(packageRoot, modes))
+
+	// stays within the archive but leaves the package directory
+	packageRoot, modes = extract(t, "../../outside")
+	assert.ErrorContains(t, checkSymlinkTargets(packageRoot, modes), "symlink bin/latest points outside of the package: ../../outside")
+}
+
+func TestResolveTargetPath(t *testing.T) {
+	t.Run("ManifestPath", func(t *testing.T) {
+		target, err := resolveTargetPath("", &spec.Manifest{InstallPath: "reference/demo/"})
+		require.NoError(t, err)
+		assert.Equal(t, "reference/demo", target)
+
+		target, err = resolveTargetPath("", &spec.Manifest{})
+		require.NoError(t, err)
+		assert.Empty(t, target)
+	})
+
+	t.Run("ImporterOverride", func(t *testing.T) {
+		target, err := resolveTargetPath("vendor/pkg", &spec.Manifest{InstallPath: "reference/demo"})
+		require.NoError(t, err)
+		assert.Equal(t, "vendor/pkg", target)
+
+		// "/" picks the repository root explicitly
+		target, err = resolveTargetPath("/", &spec.Manifest{InstallPath: "reference/demo"})
+		require.NoError(t, err)
+		assert.Empty(t, target)
+	})
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		for _, installPath := range []string{"../outside", "reference/../../outside", "/etc", `reference\demo`, ".git/hooks", "./"} {
+			_, err := resolveTargetPath("", &spec.Manifest{InstallPath: installPath})
+			assert.Error(t, err, installPath)
+
+			_, err = spec.ValidateManifest(&spec.Manifest{Name: "demo", Version: "1.0.0", InstallPath: installPath})
+			assert.Error(t, err, installPath)
+		}
+	})
+}
@@ -12,6 +12,9 @@ type Manifest struct {
 	Workflows []ReferencedEntry `json:"workflows"`
 	Resources []ReferencedEntry `json:"resources"`
 	Metadata  map[string]any    `json:"metadata"`
+	// InstallPath is the repository directory the package asks to be imported into, used
+	// when the importer doesn't choose one.
+	InstallPath string `json:"install_path"`
 }
 
 // Package contains optional package metadata fields.
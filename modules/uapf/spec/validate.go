@@ -5,7 +5,9 @@ package spec
 
 import (
 	"errors"
+	"fmt"
 	"path"
+	"strings"
 )
 
 // ValidateManifest performs lightweight structural checks expected by the UAPF schema
@@ -35,9 +37,33 @@ func ValidateManifest(manifest *Manifest) ([]string, error) {
 		refPaths = append(refPaths, cleanRelativePath(res.Path))
 	}
 
+	if manifest.InstallPath != "" {
+		if _, err := CleanInstallPath(manifest.InstallPath); err != nil {
+			return nil, err
+		}
+	}
+
 	return refPaths, nil
 }
 
+// CleanInstallPath returns the cleaned install_path of a manifest. Unlike a path chosen by the
+// importer, it must be a relative slash-separated path that stays inside the repository.
+func CleanInstallPath(p string) (string, error) {
+	if strings.HasPrefix(p, "/") || strings.Contains(p, "\\") {
+		return "", fmt.Errorf("install_path %q must be a relative path using forward slashes", p)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." || strings.EqualFold(segment, ".git") {
+			return "", fmt.Errorf("install_path %q must stay inside the repository", p)
+		}
+	}
+	clean := cleanRelativePath(p)
+	if clean == "" {
+		return "", fmt.Errorf("install_path %q does not name a directory", p)
+	}
+	return clean, nil
+}
+
 func cleanRelativePath(p string) string {
 	clean := path.Clean("/" + p)
 	return clean[1:]
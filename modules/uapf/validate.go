@@ -6,18 +6,31 @@ package uapf
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/uapf/spec"
+	"code.gitea.io/gitea/modules/util"
 	uapfresources "code.gitea.io/gitea/resources/uapf"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
+// ChecksumsFileName is the optional file next to manifest.json that lists the SHA-256 checksum of
+// the package files, one "<hex>  <path>" line per file as written by sha256sum.
+const ChecksumsFileName = "checksums.txt"
+
+// maxChecksumsFileSize bounds the checksums file, which is read into memory.
+const maxChecksumsFileSize = 1 << 20
+
 var (
 	manifestSchema     *jsonschema.Schema
 	manifestSchemaOnce sync.Once
@@ -36,7 +49,8 @@ func loadManifestSchema() (*jsonschema.Schema, error) {
 	return manifestSchema, manifestSchemaErr
 }
 
-// ValidatePackage ensures a .uapf archive contains a manifest.json that conforms to the embedded schema.
+// ValidatePackage ensures a .uapf archive contains a manifest.json that conforms to the embedded schema
+// and, if the package has a checksums file, that every listed file matches its checksum.
 func ValidatePackage(data []byte) error {
 	readerAt := bytes.NewReader(data)
 
@@ -45,7 +59,7 @@ func ValidatePackage(data []byte) error {
 		return fmt.Errorf("invalid .uapf archive: %w", err)
 	}
 
-	manifestJSON, err := extractManifest(zipReader)
+	manifestJSON, root, err := extractManifest(zipReader)
 	if err != nil {
 		return err
 	}
@@ -67,10 +81,11 @@ func ValidatePackage(data []byte) error {
 		return fmt.Errorf("manifest validation failed: %w", err)
 	}
 
-	return nil
+	return verifyChecksums(zipReader, root, maxExtractedSize(int64(len(data))))
 }
 
-func extractManifest(zipReader *zip.Reader) ([]byte, error) {
+// extractManifest returns the contents of manifest.json and the directory of the archive it is in.
+func extractManifest(zipReader *zip.Reader) ([]byte, string, error) {
 	for _, file := range zipReader.File {
 		name := filepath.Clean(file.Name)
 		if filepath.Base(name) != "manifest.json" {
@@ -79,18 +94,197 @@ func extractManifest(zipReader *zip.Reader) ([]byte, error) {
 
 		manifestReader, err := file.Open()
 		if err != nil {
-			return nil, fmt.Errorf("open manifest.json: %w", err)
+			return nil, "", fmt.Errorf("open manifest.json: %w", err)
 		}
 		defer manifestReader.Close()
 
 		contents, err := io.ReadAll(manifestReader)
 		if err != nil {
-			return nil, fmt.Errorf("read manifest.json: %w", err)
+			return nil, "", fmt.Errorf("read manifest.json: %w", err)
+		}
+		return contents, filepath.Dir(name), nil
+	}
+
+	return nil, "", errors.New("manifest.json is required in the UAPF package")
+}
+
+// verifyChecksums checks the files listed in the checksums file of the package root against
+// their contents. Packages without a checksums file are accepted as they are. The files are
+// hashed as they are decompressed, and like extractZipSafe it fails once they add up to more
+// than maxSize bytes, if maxSize is positive.
+func verifyChecksums(zipReader *zip.Reader, root string, maxSize int64) error {
+	files := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		files[filepath.Clean(file.Name)] = file
+	}
+
+	checksumsFile, ok := files[filepath.Join(root, ChecksumsFileName)]
+	if !ok {
+		return nil
+	}
+	checksums, err := readZipFile(checksumsFile, maxChecksumsFileSize)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", ChecksumsFileName, err)
+	}
+
+	var hashed int64
+	for i, line := range strings.Split(string(checksums), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		expected, name, ok := strings.Cut(line, " ")
+		// sha256sum separates the name by " " in text mode and " *" in binary mode
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		if !ok || name == "" {
+			return fmt.Errorf("%s line %d is malformed", ChecksumsFileName, i+1)
+		}
+
+		file, ok := files[filepath.Join(root, name)]
+		if !ok {
+			return fmt.Errorf("file listed in %s is missing in package: %s", ChecksumsFileName, name)
+		}
+		limit := int64(-1)
+		if maxSize > 0 {
+			limit = maxSize - hashed
+		}
+		sum, n, err := hashZipFile(file, limit)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+		hashed += n
+		if maxSize > 0 && hashed > maxSize {
+			return fmt.Errorf("package expands to more than %d bytes when extracted", maxSize)
+		}
+		if !strings.EqualFold(sum, expected) {
+			return fmt.Errorf("checksum mismatch for %s", name)
 		}
-		return contents, nil
 	}
+	return nil
+}
 
-	return nil, errors.New("manifest.json is required in the UAPF package")
+// readZipFile returns the contents of file, failing if it is larger than maxSize bytes.
+func readZipFile(file *zip.File, maxSize int64) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// hashZipFile returns the hex SHA-256 checksum of file and the number of bytes hashed. Unless
+// limit is negative, at most limit+1 bytes are read, enough for the caller to notice the excess.
+func hashZipFile(file *zip.File, limit int64) (string, int64, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", 0, err
+	}
+	defer reader.Close()
+
+	var r io.Reader = reader
+	if limit >= 0 {
+		r = io.LimitReader(reader, limit+1)
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// ValidationIssue is one problem found in a package. Path is the JSON pointer of the offending
+// manifest value for schema violations, and the file name for problems with a file.
+type ValidationIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationReport lists every problem found in a package, so authors can fix them all at once.
+type ValidationReport struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationIssue `json:"errors"`
+}
+
+func (r *ValidationReport) add(path, message string) {
+	r.Errors = append(r.Errors, ValidationIssue{Path: path, Message: message})
+	r.Valid = false
+}
+
+// ValidatePackageReport checks a .uapf archive like ValidatePackage, but instead of stopping at
+// the first problem it reports every manifest schema violation along with any archive, manifest
+// or checksum problem. The error is only set if the validation itself could not run.
+func ValidatePackageReport(data []byte) (*ValidationReport, error) {
+	report := &ValidationReport{Valid: true, Errors: []ValidationIssue{}}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		report.add("", fmt.Sprintf("invalid .uapf archive: %v", err))
+		return report, nil
+	}
+
+	manifestJSON, root, err := extractManifest(zipReader)
+	if err != nil {
+		report.add("manifest.json", err.Error())
+		return report, nil
+	}
+
+	var manifest any
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		report.add("manifest.json", fmt.Sprintf("manifest.json is not valid JSON: %v", err))
+	} else {
+		schema, err := loadManifestSchema()
+		if err != nil {
+			return nil, fmt.Errorf("load manifest schema: %w", err)
+		}
+		if err := schema.Validate(manifest); err != nil {
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) {
+				return nil, fmt.Errorf("manifest validation failed: %w", err)
+			}
+			violations := schemaViolations(nil, validationErr)
+			// the validator visits properties in map order; sort to keep reports stable
+			slices.SortStableFunc(violations, func(a, b ValidationIssue) int { return strings.Compare(a.Path, b.Path) })
+			for _, violation := range violations {
+				report.add(violation.Path, violation.Message)
+			}
+		}
+		if fields, ok := manifest.(map[string]any); ok {
+			if installPath, ok := fields["install_path"].(string); ok && installPath != "" {
+				if _, err := spec.CleanInstallPath(installPath); err != nil {
+					report.add("/install_path", err.Error())
+				}
+			}
+		}
+	}
+
+	if err := verifyChecksums(zipReader, root, maxExtractedSize(int64(len(data)))); err != nil {
+		report.add(ChecksumsFileName, err.Error())
+	}
+	return report, nil
+}
+
+// schemaViolations appends the leaf causes of a schema validation error to violations. The
+// intermediate errors only say which keyword failed, e.g. "doesn't validate with ...".
+func schemaViolations(violations []ValidationIssue, validationErr *jsonschema.ValidationError) []ValidationIssue {
+	if len(validationErr.Causes) == 0 {
+		return append(violations, ValidationIssue{
+			Path:    util.IfZero(validationErr.InstanceLocation, "/"),
+			Message: validationErr.Message,
+		})
+	}
+	for _, cause := range validationErr.Causes {
+		violations = schemaViolations(violations, cause)
+	}
+	return violations
 }
 
 // ValidateManifest validates manifest.json contents against the embedded schema.
@@ -0,0 +1,168 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package uapf
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestPackage(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func checksumOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestValidatePackageChecksums(t *testing.T) {
+	const manifest = `{"name": "demo", "version": "1.0.0"}`
+	const workflow = "<definitions/>"
+
+	t.Run("NoChecksums", func(t *testing.T) {
+		assert.NoError(t, ValidatePackage(buildTestPackage(t, map[string]string{
+			"manifest.json":   manifest,
+			"flows/main.bpmn": workflow,
+		})))
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.NoError(t, ValidatePackage(buildTestPackage(t, map[string]string{
+			"demo/manifest.json":   manifest,
+			"demo/flows/main.bpmn": workflow,
+			"demo/checksums.txt": checksumOf(manifest) + "  manifest.json\n" +
+				checksumOf(workflow) + " *flows/main.bpmn\n",
+		})))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		err := ValidatePackage(buildTestPackage(t, map[string]string{
+			"manifest.json":   manifest,
+			"flows/main.bpmn": "<definitions>changed</definitions>",
+			"checksums.txt":   checksumOf(workflow) + "  flows/main.bpmn\n",
+		}))
+		assert.EqualError(t, err, "checksum mismatch for flows/main.bpmn")
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		err := ValidatePackage(buildTestPackage(t, map[string]string{
+			"manifest.json": manifest,
+			"checksums.txt": checksumOf(workflow) + "  flows/main.bpmn\n",
+		}))
+		assert.EqualError(t, err, "file listed in checksums.txt is missing in package: flows/main.bpmn")
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		err := ValidatePackage(buildTestPackage(t, map[string]string{
+			"manifest.json": manifest,
+			"checksums.txt": "\n" + checksumOf(manifest) + "\n",
+		}))
+		assert.EqualError(t, err, "checksums.txt line 2 is malformed")
+	})
+
+	t.Run("ZipBomb", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.UAPF.MaxExtractedSizeMB, 1024)()
+		defer test.MockVariableValue(&setting.UAPF.MaxCompressionRatio, 100)()
+
+		// the listed file is hashed as it is decompressed, so the limit stops it early
+		zeros := strings.Repeat("\x00", 8<<20)
+		data := buildTestPackage(t, map[string]string{
+			"manifest.json": manifest,
+			"zeros.bin":     zeros,
+			"checksums.txt": checksumOf(zeros) + "  zeros.bin\n",
+		})
+		assert.ErrorContains(t, ValidatePackage(data), "package expands to more than")
+
+		report, err := ValidatePackageReport(data)
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+
+		setting.UAPF.MaxCompressionRatio = 0
+		assert.NoError(t, ValidatePackage(data))
+	})
+
+	t.Run("LargeChecksumsFile", func(t *testing.T) {
+		err := ValidatePackage(buildTestPackage(t, map[string]string{
+			"manifest.json": manifest,
+			"checksums.txt": strings.Repeat(" ", maxChecksumsFileSize+1),
+		}))
+		assert.ErrorContains(t, err, "read checksums.txt: file is larger than")
+	})
+}
+
+func TestValidatePackageReport(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		report, err := ValidatePackageReport(buildTestPackage(t, map[string]string{
+			"manifest.json": `{"name": "demo", "version": "1.0.0"}`,
+		}))
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.Errors)
+	})
+
+	t.Run("AllSchemaViolations", func(t *testing.T) {
+		const manifest = `{"name": "demo", "version": "", "workflows": "main.bpmn", "metadata": []}`
+		data := buildTestPackage(t, map[string]string{
+			"manifest.json": manifest,
+			"checksums.txt": checksumOf("other") + "  manifest.json\n",
+		})
+		report, err := ValidatePackageReport(data)
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, []ValidationIssue{
+			{Path: "/metadata", Message: "expected object, but got array"},
+			{Path: "/version", Message: "length must be >= 1, but got 0"},
+			{Path: "/workflows", Message: "expected array, but got string"},
+			{Path: "checksums.txt", Message: "checksum mismatch for manifest.json"},
+		}, report.Errors)
+
+		// the single-error function still stops at the first problem
+		assert.ErrorContains(t, ValidatePackage(data), "manifest validation failed")
+	})
+
+	t.Run("PackageProblems", func(t *testing.T) {
+		report, err := ValidatePackageReport([]byte("not a zip"))
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		require.Len(t, report.Errors, 1)
+		assert.Empty(t, report.Errors[0].Path)
+
+		report, err = ValidatePackageReport(buildTestPackage(t, map[string]string{"flows/main.bpmn": "<definitions/>"}))
+		require.NoError(t, err)
+		assert.Equal(t, []ValidationIssue{{Path: "manifest.json", Message: "manifest.json is required in the UAPF package"}}, report.Errors)
+
+		report, err = ValidatePackageReport(buildTestPackage(t, map[string]string{"manifest.json": "{"}))
+		require.NoError(t, err)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, "manifest.json", report.Errors[0].Path)
+	})
+
+	t.Run("InstallPath", func(t *testing.T) {
+		report, err := ValidatePackageReport(buildTestPackage(t, map[string]string{
+			"manifest.json": `{"name": "demo", "version": "1.0.0", "install_path": "../outside"}`,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []ValidationIssue{{Path: "/install_path", Message: `install_path "../outside" must stay inside the repository`}}, report.Errors)
+	})
+}
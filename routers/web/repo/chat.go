@@ -4,28 +4,28 @@
 package repo
 
 import (
-	"bufio"
-	"bytes"
+	gocontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"code.gitea.io/gitea/modules/chat"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/mcp"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	chat_service "code.gitea.io/gitea/services/chat"
 	"code.gitea.io/gitea/services/context"
 )
 
-const (
-	anthropicMessagesURL = "https://api.anthropic.com/v1/messages"
-	anthropicAPIVersion  = "2023-06-01"
-	anthropicMCPBeta     = "mcp-client-2025-11-20"
-)
+// newChatProvider creates the LLM provider of a chat request; tests replace it with a fake.
+var newChatProvider = chat.NewProvider
 
 // rateLimitEntry tracks per-user rate limit state.
 type rateLimitEntry struct {
@@ -46,10 +46,14 @@ type monthlyCostTracker struct {
 	month    time.Month
 	year     int
 	totalUSD float64
+	requests int
 }
 
 // ChatEndpoint handles chat requests for a repository's agent.chat.yaml.
 func ChatEndpoint(ctx *context.Context) {
+	// the turn's duration is measured from here to the end of the reply
+	received := time.Now().UTC()
+
 	if !setting.Chat.Enabled {
 		ctx.JSON(http.StatusNotFound, map[string]string{"error": "Chat agents are disabled on this instance"})
 		return
@@ -66,6 +70,22 @@ func ChatEndpoint(ctx *context.Context) {
 		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "message is required"})
 		return
 	}
+	if maxLength := setting.Chat.MaxMessageLength; maxLength > 0 && utf8.RuneCountInString(req.Message) > maxLength {
+		ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("message is too long (max %d characters)", maxLength),
+		})
+		return
+	}
+	if req.Ephemeral && req.ConversationID != "" {
+		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "an ephemeral message can't continue a saved conversation"})
+		return
+	}
+	if len(req.Attachments) > chat.MaxAttachments {
+		ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("too many attachments (max %d)", chat.MaxAttachments),
+		})
+		return
+	}
 
 	// Get default branch commit
 	commit, err := ctx.Repo.GitRepo.GetBranchCommit(ctx.Repo.Repository.DefaultBranch)
@@ -98,7 +118,7 @@ func ChatEndpoint(ctx *context.Context) {
 	}
 
 	// Resolve API key
-	apiKey, err := chat.ResolveAPIKey(cfg.LLM.APIKeyRef)
+	apiKey, err := chat_service.ResolveAPIKey(ctx, ctx.Repo.Repository, cfg.LLM.APIKeyRef)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "failed to resolve API key: " + err.Error(),
@@ -143,19 +163,64 @@ func ChatEndpoint(ctx *context.Context) {
 			conv, _ = chat.LoadConversation(historyCommit, req.ConversationID)
 		}
 	}
+	// conversations saved before the agent was recorded have no AgentConfig and are continued as before
+	if conv != nil && conv.AgentConfig != "" && conv.AgentConfig != agentFile {
+		if !setting.Chat.NewConversationOnAgentChange {
+			ctx.JSON(http.StatusConflict, map[string]string{
+				"error": fmt.Sprintf("conversation %s belongs to agent %s, not %s", conv.ID, conv.AgentConfig, agentFile),
+			})
+			return
+		}
+		conv = nil
+	}
 	if conv == nil {
 		conv = chat.NewConversation(agentFile, cfg.LLM.Model, userID, userName)
+		if req.Ephemeral {
+			// without an ID the conversation can't be looked up, continued or saved later
+			conv.ID = ""
+		}
 	}
 
 	// Add user message
 	conv.AddMessage(chat.Message{
-		Role:      "user",
-		Content:   req.Message,
-		Timestamp: time.Now().UTC(),
+		Role:        "user",
+		Content:     req.Message,
+		Timestamp:   received,
+		Attachments: req.Attachments,
 	})
 
-	// Build Claude API request
-	claudeReq := buildClaudeRequest(cfg, conv, ctx.Repo.Repository.OwnerName, ctx.Repo.Repository.Name)
+	provider, err := newChatProvider(cfg.LLM.Provider, chat.ProviderOptions{
+		APIKey: apiKey,
+		Commit: commit,
+		RepoMCP: chat.MCPServerEntry{
+			Name: ctx.Repo.Repository.Name + "-mcp",
+			URL:  fmt.Sprintf("%s%s/%s/mcp", setting.AppURL, ctx.Repo.Repository.OwnerName, ctx.Repo.Repository.Name),
+		},
+		Prompt: chatPromptData(ctx, commit, cfg.LLM.SystemPrompt),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// canceled early when the agent exceeds mcp.max_tool_calls
+	streamCtx, cancelStream := gocontext.WithCancel(ctx)
+	defer cancelStream()
+	events, err := provider.Stream(streamCtx, cfg, conv)
+	if err != nil {
+		var upstreamErr *chat.UpstreamError
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		} else if errors.As(err, &upstreamErr) {
+			// the backend's response may reveal details of the API key or account
+			log.Error("Chat provider error: %v", err)
+			ctx.JSON(http.StatusBadGateway, map[string]string{"error": upstreamErr.UserMessage()})
+		} else {
+			log.Error("Chat provider error: %v", err)
+			ctx.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+		}
+		return
+	}
 
 	// Stream response via SSE
 	ctx.Resp.Header().Set("Content-Type", "text/event-stream")
@@ -163,42 +228,125 @@ func ChatEndpoint(ctx *context.Context) {
 	ctx.Resp.Header().Set("Connection", "keep-alive")
 	ctx.Resp.Header().Set("X-Accel-Buffering", "no")
 
-	assistantContent, toolCalls, usage, err := streamClaudeResponse(ctx.Resp, apiKey, claudeReq)
-	if err != nil {
-		log.Error("Chat streaming error: %v", err)
-		writeSSEEvent(ctx.Resp, "error", chat.SSEEvent{Type: "error", Text: err.Error()})
+	var assistantContent strings.Builder
+	var toolCalls []chat.ToolCall
+	var usage *chat.Usage
+	var canceled bool
+	// keepalive comments stop with the loop, once the provider has closed the channel
+	keepalive := time.NewTicker(setting.Chat.SSEKeepaliveInterval)
+	defer keepalive.Stop()
+stream:
+	for {
+		var event chat.SSEEvent
+		select {
+		case <-keepalive.C:
+			writeSSEComment(ctx.Resp, "keepalive")
+			continue
+		case e, ok := <-events:
+			if !ok {
+				break stream
+			}
+			event = e
+		}
+		if canceled && event.Type != "canceled" && event.Type != "done" {
+			// the tool-call limit was hit, only the usage of the aborted reply is left to read
+			continue
+		}
+		switch event.Type {
+		case "text":
+			assistantContent.WriteString(event.Text)
+			writeSSEEvent(ctx.Resp, "message_delta", event)
+		case "tool_call":
+			if maxToolCalls := cfg.MCP.MaxToolCalls; maxToolCalls > 0 && len(toolCalls) >= maxToolCalls {
+				cancelStream()
+				canceled = true
+				writeSSEEvent(ctx.Resp, "limit_reached", chat.SSEEvent{
+					Type: "limit_reached",
+					Text: fmt.Sprintf("the agent exceeded its limit of %d tool calls", maxToolCalls),
+				})
+				continue
+			}
+			toolCalls = append(toolCalls, chat.ToolCall{Tool: event.Tool, Server: event.Server})
+			writeSSEEvent(ctx.Resp, "tool_use", event)
+		case "error":
+			log.Error("Chat streaming error: %s", event.Text)
+			writeSSEEvent(ctx.Resp, "error", event)
+			return
+		case "done":
+			usage = event.Usage
+		case "canceled":
+			// the client went away before the reply was complete: the upstream request
+			// has been aborted, but what was consumed until then is still billed
+			usage = event.Usage
+			canceled = true
+		}
+	}
+	if usage == nil {
 		return
 	}
 
 	// Add assistant response to conversation
 	assistantMsg := chat.Message{
 		Role:      "assistant",
-		Content:   assistantContent,
+		Content:   assistantContent.String(),
 		Timestamp: time.Now().UTC(),
 		ToolCalls: toolCalls,
 		Usage:     usage,
 	}
 	conv.AddMessage(assistantMsg)
 
-	// Send completion event
-	writeSSEEvent(ctx.Resp, "message_complete", chat.SSEEvent{
-		Type:           "done",
-		ConversationID: conv.ID,
-		Usage:          usage,
-	})
-
 	// Track cost
-	if usage != nil {
-		trackCost(ctx.Repo.Repository.ID, usage.CostUSD)
+	trackCost(ctx.Repo.Repository.ID, usage.CostUSD)
+
+	if !canceled {
+		// Send completion event
+		writeSSEEvent(ctx.Resp, "message_complete", chat.SSEEvent{
+			Type:           "done",
+			ConversationID: conv.ID,
+			Usage:          usage,
+		})
+
+		// Let the client warn the user before a limit is hit
+		writeSSEEvent(ctx.Resp, "quota", chat.SSEEvent{
+			Type:  "quota",
+			Quota: chatQuota(ctx.Repo.Repository.ID, userID, cfg.Access),
+		})
 	}
 
 	// Buffer conversation for async persistence
-	if cfg.History.Enabled {
+	if cfg.History.Enabled && !req.Ephemeral {
 		buf := chat.GetBuffer(ctx.Repo.Repository.ID)
-		buf.BufferConversation(conv)
+		if titler, ok := provider.(chat.Titler); ok && cfg.History.LLMTitles {
+			buf.BufferConversationWithTitle(conv, titler.GenerateTitle)
+		} else {
+			buf.BufferConversation(conv)
+		}
 	}
 }
 
+// chatPromptData returns the template variables of the system prompt. The MCP index is only
+// built for prompts that use .Stats; if that fails the stats are left zero.
+func chatPromptData(ctx *context.Context, commit *git.Commit, systemPrompt string) chat.PromptData {
+	repo := ctx.Repo.Repository
+	data := chat.PromptData{
+		Repo: chat.PromptRepo{Name: repo.Name, Owner: repo.OwnerName, DefaultBranch: repo.DefaultBranch},
+	}
+	if !strings.Contains(systemPrompt, ".Stats") {
+		return data
+	}
+	mcpCfg, err := mcp.LoadRepoConfig(repo.ID, commit)
+	if err != nil || mcpCfg == nil {
+		return data
+	}
+	index, err := mcp.GetOrBuildIndex(repo.ID, commit, mcpCfg)
+	if err != nil {
+		log.Warn("Chat system prompt: cannot build the MCP index of %s: %v", repo.FullName(), err)
+		return data
+	}
+	data.Stats = chat.PromptStats{TotalEntities: index.Stats.TotalEntities, TypeCounts: index.Stats.TypeCounts}
+	return data
+}
+
 // ChatAgents returns a list of chat agents found in the repository.
 func ChatAgents(ctx *context.Context) {
 	if !setting.Chat.Enabled {
@@ -225,7 +373,8 @@ func ChatAgents(ctx *context.Context) {
 	ctx.JSON(http.StatusOK, agents)
 }
 
-// ChatHistory returns conversation list for the current user.
+// ChatHistory returns conversation list for the current user. The q parameter filters it by
+// title, and with content=true also by the messages of recent conversations.
 func ChatHistory(ctx *context.Context) {
 	if !setting.Chat.Enabled {
 		ctx.JSON(http.StatusNotFound, map[string]string{"error": "Chat agents are disabled"})
@@ -258,7 +407,12 @@ func ChatHistory(ctx *context.Context) {
 	}
 	offset := ctx.FormInt("offset")
 
-	conversations, err := chat.ListConversations(historyCommit, userID, limit, offset)
+	var conversations []chat.ConversationSummary
+	if q := ctx.FormTrim("q"); q != "" {
+		conversations, err = chat.SearchConversations(historyCommit, userID, q, ctx.FormBool("content"), limit, offset)
+	} else {
+		conversations, err = chat.ListConversations(historyCommit, userID, limit, offset)
+	}
 	if err != nil {
 		ctx.ServerError("ListConversations", err)
 		return
@@ -267,182 +421,144 @@ func ChatHistory(ctx *context.Context) {
 	ctx.JSON(http.StatusOK, conversations)
 }
 
-func buildClaudeRequest(cfg *chat.ChatConfig, conv *chat.Conversation, owner, repoName string) *chat.ClaudeRequest {
-	// Build messages from conversation history
-	messages := make([]chat.ClaudeMessage, 0, len(conv.Messages))
-	for _, msg := range conv.Messages {
-		if msg.Role == "user" || msg.Role == "assistant" {
-			messages = append(messages, chat.ClaudeMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
+// ChatExportConversation returns a conversation of the history branch as a Markdown transcript
+// or as JSON, selected by the format query parameter. Users can export their own conversations
+// and repository admins any conversation.
+func ChatExportConversation(ctx *context.Context) {
+	if !setting.Chat.Enabled {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": "Chat agents are disabled"})
+		return
 	}
 
-	req := &chat.ClaudeRequest{
-		Model:       cfg.LLM.Model,
-		MaxTokens:   cfg.LLM.MaxTokens,
-		System:      cfg.LLM.SystemPrompt,
-		Messages:    messages,
-		Stream:      true,
-		Temperature: cfg.LLM.Temperature,
+	format := ctx.FormString("format")
+	if format == "" {
+		format = "markdown"
 	}
-
-	// Build MCP server configurations
-	if cfg.MCP.UseRepoMCP {
-		mcpURL := fmt.Sprintf("%s%s/%s/mcp", setting.AppURL, owner, repoName)
-		req.MCPServers = append(req.MCPServers, chat.ClaudeMCPServer{
-			Type: "url",
-			URL:  mcpURL,
-			Name: repoName + "-mcp",
-		})
+	contentType, ok := chat.ExportContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "unknown format, use 'markdown' or 'json'"})
+		return
 	}
 
-	for _, server := range cfg.MCP.AdditionalServers {
-		req.MCPServers = append(req.MCPServers, chat.ClaudeMCPServer{
-			Type: "url",
-			URL:  server.URL,
-			Name: server.Name,
-		})
+	notFound := func() {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": "conversation not found"})
 	}
 
-	// Build tool configurations
-	for _, mcpServer := range req.MCPServers {
-		tool := chat.ClaudeTool{
-			Type:          "mcp_toolset",
-			MCPServerName: mcpServer.Name,
-		}
-
-		// Apply tool allow/deny lists
-		if len(cfg.MCP.AllowedTools) > 0 {
-			// Default all tools to disabled, enable only allowed ones
-			tool.DefaultConfig = &chat.ClaudeToolDefaultConfig{Enabled: false}
-			tool.Configs = make(map[string]chat.ClaudeToolOverride)
-			for _, toolName := range cfg.MCP.AllowedTools {
-				tool.Configs[toolName] = chat.ClaudeToolOverride{Enabled: true}
-			}
-		} else if len(cfg.MCP.DeniedTools) > 0 {
-			// Default all tools to enabled, disable denied ones
-			tool.DefaultConfig = &chat.ClaudeToolDefaultConfig{Enabled: true}
-			tool.Configs = make(map[string]chat.ClaudeToolOverride)
-			for _, toolName := range cfg.MCP.DeniedTools {
-				tool.Configs[toolName] = chat.ClaudeToolOverride{Enabled: false}
-			}
+	// read the history branch of the agent the same way the chat endpoint stores it
+	agentFile := ctx.FormString("agent_file")
+	if agentFile == "" {
+		agentFile = chat.DefaultConfigFileName
+	}
+	branch := "chat-history"
+	commit, err := ctx.Repo.GitRepo.GetBranchCommit(ctx.Repo.Repository.DefaultBranch)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			notFound()
+		} else {
+			ctx.ServerError("GetBranchCommit", err)
 		}
-
-		req.Tools = append(req.Tools, tool)
+		return
 	}
-
-	return req
-}
-
-func streamClaudeResponse(w http.ResponseWriter, apiKey string, req *chat.ClaudeRequest) (string, []chat.ToolCall, *chat.Usage, error) {
-	reqBody, err := json.Marshal(req)
+	cfg, err := chat.LoadChatConfig(commit, agentFile)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to load chat config: " + err.Error(),
+		})
+		return
+	}
+	if cfg != nil && cfg.History.Branch != "" {
+		branch = cfg.History.Branch
 	}
 
-	httpReq, err := http.NewRequest("POST", anthropicMessagesURL, bytes.NewReader(reqBody))
+	historyCommit, err := ctx.Repo.GitRepo.GetBranchCommit(branch)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("failed to create request: %w", err)
+		if git.IsErrNotExist(err) {
+			notFound()
+		} else {
+			ctx.ServerError("GetBranchCommit", err)
+		}
+		return
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", apiKey)
-	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
-	httpReq.Header.Set("anthropic-beta", anthropicMCPBeta)
-
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Do(httpReq)
+	conv, err := chat.LoadConversation(historyCommit, ctx.PathParam("id"))
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("API request failed: %w", err)
+		ctx.ServerError("LoadConversation", err)
+		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", nil, nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	// don't reveal whether conversations of other users exist
+	if conv == nil || ctx.Doer == nil || (conv.User.ID != fmt.Sprintf("%d", ctx.Doer.ID) && !ctx.Repo.IsAdmin()) {
+		notFound()
+		return
 	}
 
-	// Parse SSE stream from Claude
-	var fullContent strings.Builder
-	var toolCalls []chat.ToolCall
-	usage := &chat.Usage{}
-
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if !strings.HasPrefix(line, "data: ") {
-			continue
+	data, ext := []byte(nil), "md"
+	if format == "json" {
+		ext = "json"
+		if data, err = json.MarshalIndent(conv, "", "  "); err != nil {
+			ctx.ServerError("MarshalIndent", err)
+			return
 		}
+	} else {
+		data = []byte(chat.ConversationMarkdown(conv))
+	}
 
-		data := strings.TrimPrefix(line, "data: ")
-		if data == "[DONE]" {
-			break
-		}
+	ctx.Resp.Header().Set("Content-Type", contentType)
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, conv.ID, ext))
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(data)
+}
 
-		var event map[string]interface{}
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
-			continue
-		}
+// ChatStats returns the chat spend of the repository for repository admins: the cost and
+// requests of the current month tracked since the server started, and the lifetime totals
+// of the conversations on the history branch.
+func ChatStats(ctx *context.Context) {
+	if !setting.Chat.Enabled {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": "Chat agents are disabled"})
+		return
+	}
+	if !ctx.Repo.IsAdmin() {
+		ctx.JSON(http.StatusForbidden, map[string]string{"error": "repository admin access required"})
+		return
+	}
 
-		eventType, _ := event["type"].(string)
-		switch eventType {
-		case "content_block_delta":
-			delta, ok := event["delta"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			deltaType, _ := delta["type"].(string)
-			if deltaType == "text_delta" {
-				text, _ := delta["text"].(string)
-				fullContent.WriteString(text)
-				writeSSEEvent(w, "message_delta", chat.SSEEvent{Type: "text", Text: text})
-			}
+	branch := ctx.FormString("branch")
+	if branch == "" {
+		branch = "chat-history"
+	}
 
-		case "content_block_start":
-			block, ok := event["content_block"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			blockType, _ := block["type"].(string)
-			if blockType == "mcp_tool_use" {
-				toolName, _ := block["name"].(string)
-				serverName, _ := block["server_name"].(string)
-				toolCalls = append(toolCalls, chat.ToolCall{
-					Tool:   toolName,
-					Server: serverName,
-				})
-				writeSSEEvent(w, "tool_use", chat.SSEEvent{
-					Type:   "tool_call",
-					Tool:   toolName,
-					Server: serverName,
-				})
-			}
+	var index *chat.ConversationIndex
+	historyCommit, err := ctx.Repo.GitRepo.GetBranchCommit(branch)
+	if err == nil {
+		index, err = chat.LoadIndex(historyCommit)
+	}
+	if err != nil && !git.IsErrNotExist(err) {
+		ctx.ServerError("LoadIndex", err)
+		return
+	}
 
-		case "message_delta":
-			if u, ok := event["usage"].(map[string]interface{}); ok {
-				if v, ok := u["output_tokens"].(float64); ok {
-					usage.OutputTokens = int(v)
-				}
-			}
+	ctx.JSON(http.StatusOK, chatStats(ctx.Repo.Repository.ID, index, time.Now()))
+}
 
-		case "message_start":
-			if msg, ok := event["message"].(map[string]interface{}); ok {
-				if u, ok := msg["usage"].(map[string]interface{}); ok {
-					if v, ok := u["input_tokens"].(float64); ok {
-						usage.InputTokens = int(v)
-					}
-				}
-			}
-		}
+// chatStats combines the monthly tracker of the repository with the totals of the history index, which may be nil.
+func chatStats(repoID int64, index *chat.ConversationIndex, now time.Time) *chat.Stats {
+	stats := &chat.Stats{Month: now.Format("2006-01")}
+	if val, ok := monthlyCost.Load(repoID); ok {
+		stats.MonthCostUSD, stats.MonthRequests = val.(*monthlyCostTracker).current(now)
 	}
+	if index != nil {
+		stats.TotalCostUSD = index.TotalCostUSD
+		stats.TotalConversations = index.TotalConversations
+		stats.TotalMessages = index.TotalMessages
+	}
+	return stats
+}
 
-	// Calculate approximate cost (Claude Sonnet pricing as default)
-	usage.CostUSD = estimateCost(usage.InputTokens, usage.OutputTokens, req.Model)
-
-	return fullContent.String(), toolCalls, usage, nil
+// writeSSEComment writes an SSE comment line, which EventSource clients ignore.
+func writeSSEComment(w http.ResponseWriter, comment string) {
+	fmt.Fprintf(w, ": %s\n\n", comment)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
 }
 
 func writeSSEEvent(w http.ResponseWriter, event string, data interface{}) {
@@ -456,27 +572,6 @@ func writeSSEEvent(w http.ResponseWriter, event string, data interface{}) {
 	}
 }
 
-func estimateCost(inputTokens, outputTokens int, model string) float64 {
-	// Approximate pricing per million tokens
-	var inputRate, outputRate float64
-	switch {
-	case strings.Contains(model, "opus"):
-		inputRate = 5.0
-		outputRate = 25.0
-	case strings.Contains(model, "sonnet"):
-		inputRate = 3.0
-		outputRate = 15.0
-	case strings.Contains(model, "haiku"):
-		inputRate = 0.25
-		outputRate = 1.25
-	default:
-		inputRate = 3.0
-		outputRate = 15.0
-	}
-
-	return (float64(inputTokens)*inputRate + float64(outputTokens)*outputRate) / 1_000_000
-}
-
 func checkRateLimit(repoID int64, userID string, limits chat.RateLimitConfig) bool {
 	key := fmt.Sprintf("%d:%s", repoID, userID)
 	val, _ := rateLimits.LoadOrStore(key, &rateLimitEntry{
@@ -513,6 +608,76 @@ func checkRateLimit(repoID int64, userID string, limits chat.RateLimitConfig) bo
 	return true
 }
 
+// remaining returns the requests left in the current minute and day windows without
+// counting a request. A zero limit is unlimited and reported as -1.
+func (e *rateLimitEntry) remaining(limits chat.RateLimitConfig, now time.Time) (minute, day int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	minuteCount, dayCount := e.minuteCount, e.dayCount
+	if now.After(e.minuteReset) {
+		minuteCount = 0
+	}
+	if now.After(e.dayReset) {
+		dayCount = 0
+	}
+
+	minute, day = -1, -1
+	if limits.RequestsPerMinute > 0 {
+		minute = max(limits.RequestsPerMinute-minuteCount, 0)
+	}
+	if limits.RequestsPerDay > 0 {
+		day = max(limits.RequestsPerDay-dayCount, 0)
+	}
+	return minute, day
+}
+
+// spent returns the cost tracked in the month of now without resetting the tracker.
+func (t *monthlyCostTracker) spent(now time.Time) float64 {
+	totalUSD, _ := t.current(now)
+	return totalUSD
+}
+
+// current returns the cost and the number of requests tracked in the month of now without
+// resetting the tracker.
+func (t *monthlyCostTracker) current(now time.Time) (totalUSD float64, requests int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.month != now.Month() || t.year != now.Year() {
+		return 0, 0
+	}
+	return t.totalUSD, t.requests
+}
+
+// chatQuota reports the remaining requests of the user and the remaining monthly budget of the repository.
+func chatQuota(repoID int64, userID string, access chat.AccessConfig) *chat.Quota {
+	now := time.Now()
+	quota := &chat.Quota{}
+
+	limits := access.RateLimits
+	minute, day := limits.RequestsPerMinute, limits.RequestsPerDay
+	if val, ok := rateLimits.Load(fmt.Sprintf("%d:%s", repoID, userID)); ok {
+		minute, day = val.(*rateLimitEntry).remaining(limits, now)
+	}
+	if limits.RequestsPerMinute > 0 {
+		quota.RemainingRequestsMinute = &minute
+	}
+	if limits.RequestsPerDay > 0 {
+		quota.RemainingRequestsDay = &day
+	}
+
+	if maxUSD := access.Budget.MaxMonthlyUSD; maxUSD > 0 {
+		spentUSD := 0.0
+		if val, ok := monthlyCost.Load(repoID); ok {
+			spentUSD = val.(*monthlyCostTracker).spent(now)
+		}
+		remainingUSD := max(maxUSD-spentUSD, 0)
+		quota.RemainingBudgetUSD = &remainingUSD
+	}
+	return quota
+}
+
 func checkBudget(repoID int64, maxMonthlyUSD float64) bool {
 	val, _ := monthlyCost.LoadOrStore(repoID, &monthlyCostTracker{})
 	tracker := val.(*monthlyCostTracker)
@@ -525,6 +690,7 @@ func checkBudget(repoID int64, maxMonthlyUSD float64) bool {
 		tracker.month = now.Month()
 		tracker.year = now.Year()
 		tracker.totalUSD = 0
+		tracker.requests = 0
 	}
 
 	return tracker.totalUSD < maxMonthlyUSD
@@ -542,6 +708,8 @@ func trackCost(repoID int64, costUSD float64) {
 		tracker.month = now.Month()
 		tracker.year = now.Year()
 		tracker.totalUSD = 0
+		tracker.requests = 0
 	}
 	tracker.totalUSD += costUSD
+	tracker.requests++
 }
@@ -0,0 +1,665 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/chat"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/contexttest"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitEntryRemaining(t *testing.T) {
+	now := time.Now()
+	entry := &rateLimitEntry{
+		minuteCount: 3,
+		dayCount:    7,
+		minuteReset: now.Add(30 * time.Second),
+		dayReset:    now.Add(time.Hour),
+	}
+	limits := chat.RateLimitConfig{RequestsPerMinute: 5, RequestsPerDay: 10}
+
+	minute, day := entry.remaining(limits, now)
+	assert.Equal(t, 2, minute)
+	assert.Equal(t, 3, day)
+
+	// reading doesn't count a request
+	minute, day = entry.remaining(limits, now)
+	assert.Equal(t, 2, minute)
+	assert.Equal(t, 3, day)
+
+	// an expired window is reported as fresh without being reset
+	minute, day = entry.remaining(limits, now.Add(time.Minute))
+	assert.Equal(t, 5, minute)
+	assert.Equal(t, 3, day)
+	assert.Equal(t, 3, entry.minuteCount)
+
+	// unlimited and exhausted limits
+	minute, day = entry.remaining(chat.RateLimitConfig{RequestsPerDay: 5}, now)
+	assert.Equal(t, -1, minute)
+	assert.Equal(t, 0, day)
+}
+
+func TestMonthlyCostTrackerSpent(t *testing.T) {
+	now := time.Now()
+	tracker := &monthlyCostTracker{month: now.Month(), year: now.Year(), totalUSD: 4.5}
+
+	assert.InDelta(t, 4.5, tracker.spent(now), 1e-9)
+	assert.Zero(t, tracker.spent(now.AddDate(0, 1, 0)))
+	assert.InDelta(t, 4.5, tracker.totalUSD, 1e-9)
+}
+
+func TestChatQuota(t *testing.T) {
+	const repoID = -1082 // not shared with other tests using the global trackers
+	defer rateLimits.Delete("-1082:7")
+	defer monthlyCost.Delete(int64(repoID))
+
+	access := chat.AccessConfig{
+		RateLimits: chat.RateLimitConfig{RequestsPerMinute: 3, RequestsPerDay: 10},
+		Budget:     chat.BudgetConfig{MaxMonthlyUSD: 20},
+	}
+
+	quota := chatQuota(repoID, "7", access)
+	require.NotNil(t, quota.RemainingRequestsMinute)
+	require.NotNil(t, quota.RemainingRequestsDay)
+	require.NotNil(t, quota.RemainingBudgetUSD)
+	assert.Equal(t, 3, *quota.RemainingRequestsMinute)
+	assert.Equal(t, 10, *quota.RemainingRequestsDay)
+	assert.InDelta(t, 20.0, *quota.RemainingBudgetUSD, 1e-9)
+
+	require.True(t, checkRateLimit(repoID, "7", access.RateLimits))
+	require.True(t, checkRateLimit(repoID, "7", access.RateLimits))
+	trackCost(repoID, 12.5)
+
+	quota = chatQuota(repoID, "7", access)
+	assert.Equal(t, 1, *quota.RemainingRequestsMinute)
+	assert.Equal(t, 8, *quota.RemainingRequestsDay)
+	assert.InDelta(t, 7.5, *quota.RemainingBudgetUSD, 1e-9)
+
+	trackCost(repoID, 10)
+	quota = chatQuota(repoID, "7", access)
+	assert.Zero(t, *quota.RemainingBudgetUSD)
+
+	// limits that are not configured are not reported
+	quota = chatQuota(repoID, "7", chat.AccessConfig{})
+	assert.Nil(t, quota.RemainingRequestsMinute)
+	assert.Nil(t, quota.RemainingRequestsDay)
+	assert.Nil(t, quota.RemainingBudgetUSD)
+}
+
+func TestBuildClaudeRequestAttachments(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	ctx, _ := contexttest.MockContext(t, "user2/repo1/chat")
+	contexttest.LoadRepo(t, ctx, 1)
+	contexttest.LoadGitRepo(t, ctx)
+	defer ctx.Repo.GitRepo.Close()
+	commit, err := ctx.Repo.GitRepo.GetBranchCommit("master")
+	require.NoError(t, err)
+	opts := chat.ProviderOptions{Commit: commit}
+
+	cfg := &chat.ChatConfig{LLM: chat.LLMConfig{Model: "claude-sonnet-4-5", MaxTokens: 1000}}
+	conv := chat.NewConversation("agent.chat.yaml", cfg.LLM.Model, "2", "user2")
+	conv.AddMessage(chat.Message{Role: "user", Content: "Summarize", Attachments: []string{"README.md"}})
+
+	req, err := chat.BuildClaudeRequest(cfg, conv, opts)
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 1)
+	assert.Equal(t, "Summarize", req.Messages[0].Content)
+	require.Len(t, req.Messages[0].Blocks, 1)
+	assert.Equal(t, "document", req.Messages[0].Blocks[0].Type)
+	assert.Equal(t, "README.md", req.Messages[0].Blocks[0].Title)
+	assert.Contains(t, req.Messages[0].Blocks[0].Source.Data, "# repo1")
+
+	// a missing attachment on the latest message is rejected
+	conv.AddMessage(chat.Message{Role: "assistant", Content: "It is a test repository."})
+	conv.AddMessage(chat.Message{Role: "user", Content: "And this?", Attachments: []string{"missing.png"}})
+	_, err = chat.BuildClaudeRequest(cfg, conv, opts)
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	assert.ErrorContains(t, err, "not found")
+
+	// while one on an earlier message is replaced by a note
+	conv.AddMessage(chat.Message{Role: "assistant", Content: "I cannot see it."})
+	conv.AddMessage(chat.Message{Role: "user", Content: "Never mind"})
+	req, err = chat.BuildClaudeRequest(cfg, conv, opts)
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 5)
+	assert.Contains(t, req.Messages[2].Content, "[Attachments unavailable: attachment missing.png not found]")
+	assert.Empty(t, req.Messages[2].Blocks)
+}
+
+// fakeChatProvider replays a fixed reply instead of calling an LLM backend. With a delay, the
+// reply only starts after the stream has been idle for that long.
+type fakeChatProvider struct {
+	events []chat.SSEEvent
+	err    error
+	delay  time.Duration
+
+	cfg  *chat.ChatConfig
+	conv *chat.Conversation
+}
+
+func (p *fakeChatProvider) Stream(_ context.Context, cfg *chat.ChatConfig, conv *chat.Conversation) (<-chan chat.SSEEvent, error) {
+	p.cfg, p.conv = cfg, conv
+	if p.err != nil {
+		return nil, p.err
+	}
+	events := make(chan chat.SSEEvent, len(p.events))
+	replay := func() {
+		for _, event := range p.events {
+			events <- event
+		}
+		close(events)
+	}
+	if p.delay > 0 {
+		time.AfterFunc(p.delay, replay)
+	} else {
+		replay()
+	}
+	return events, nil
+}
+
+func TestChatEndpoint(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	t.Setenv("CHAT_TEST_API_KEY", "secret")
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	_, err := files_service.ChangeRepoFiles(t.Context(), repo, doer, &files_service.ChangeRepoFilesOptions{
+		Files: []*files_service.ChangeRepoFile{{
+			Operation: "create",
+			TreePath:  "agent.chat.yaml",
+			ContentReader: strings.NewReader(`version: "1.0"
+ui:
+  name: Test Assistant
+llm:
+  provider: openai
+  model: gpt-test
+  api_key_ref: CHAT_TEST_API_KEY
+`),
+		}},
+		OldBranch: repo.DefaultBranch,
+		NewBranch: repo.DefaultBranch,
+		Message:   "Add chat agent",
+	})
+	require.NoError(t, err)
+
+	var providerName string
+	var providerOpts chat.ProviderOptions
+	fake := &fakeChatProvider{}
+	defer test.MockVariableValue(&newChatProvider, func(name string, opts chat.ProviderOptions) (chat.Provider, error) {
+		providerName, providerOpts = name, opts
+		return fake, nil
+	})()
+
+	chatRequest := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		ctx, resp := contexttest.MockContext(t, "POST /user2/repo1/chat")
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		ctx.Req.Body = io.NopCloser(strings.NewReader(body))
+		ChatEndpoint(ctx)
+		return resp
+	}
+
+	t.Run("Stream", func(t *testing.T) {
+		fake.events = []chat.SSEEvent{
+			{Type: "tool_call", Tool: "search", Server: "repo1-mcp"},
+			{Type: "text", Text: "Hello "},
+			{Type: "text", Text: "there"},
+			{Type: "done", Usage: &chat.Usage{InputTokens: 10, OutputTokens: 2}},
+		}
+		resp := chatRequest(t, `{"message": "Hi"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+
+		assert.Equal(t, "openai", providerName)
+		assert.Equal(t, "secret", providerOpts.APIKey)
+		assert.Equal(t, "repo1-mcp", providerOpts.RepoMCP.Name)
+		assert.Equal(t, setting.AppURL+"user2/repo1/mcp", providerOpts.RepoMCP.URL)
+		assert.Equal(t, "gpt-test", fake.cfg.LLM.Model)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, "event: tool_use\ndata: {\"type\":\"tool_call\",\"tool\":\"search\",\"server\":\"repo1-mcp\"}")
+		assert.Contains(t, body, "event: message_delta\ndata: {\"type\":\"text\",\"text\":\"Hello \"}")
+		assert.Contains(t, body, "event: message_complete\ndata: {\"type\":\"done\",\"conversation_id\":\""+fake.conv.ID+"\"")
+		assert.Contains(t, body, "event: quota\n")
+
+		require.Len(t, fake.conv.Messages, 2)
+		assistant := fake.conv.Messages[1]
+		assert.Equal(t, "Hello there", assistant.Content)
+		assert.Equal(t, []chat.ToolCall{{Tool: "search", Server: "repo1-mcp"}}, assistant.ToolCalls)
+		assert.Equal(t, 10, assistant.Usage.InputTokens)
+	})
+
+	t.Run("StreamError", func(t *testing.T) {
+		fake.events = []chat.SSEEvent{
+			{Type: "text", Text: "Hel"},
+			{Type: "error", Text: "stream interrupted"},
+		}
+		resp := chatRequest(t, `{"message": "Hi"}`)
+		body := resp.Body.String()
+		assert.Contains(t, body, "event: error\ndata: {\"type\":\"error\",\"text\":\"stream interrupted\"}")
+		assert.NotContains(t, body, "event: message_complete")
+	})
+
+	t.Run("ToolCallLimit", func(t *testing.T) {
+		_, err := files_service.ChangeRepoFiles(t.Context(), repo, doer, &files_service.ChangeRepoFilesOptions{
+			Files: []*files_service.ChangeRepoFile{{
+				Operation: "create",
+				TreePath:  "capped.chat.yaml",
+				ContentReader: strings.NewReader(`version: "1.0"
+ui:
+  name: Capped Assistant
+llm:
+  provider: openai
+  model: gpt-test
+  api_key_ref: CHAT_TEST_API_KEY
+mcp:
+  max_tool_calls: 2
+`),
+			}},
+			OldBranch: repo.DefaultBranch,
+			NewBranch: repo.DefaultBranch,
+			Message:   "Add capped chat agent",
+		})
+		require.NoError(t, err)
+
+		fake.events = []chat.SSEEvent{
+			{Type: "tool_call", Tool: "search", Server: "repo1-mcp"},
+			{Type: "text", Text: "Looking "},
+			{Type: "tool_call", Tool: "get_entity", Server: "repo1-mcp"},
+			{Type: "tool_call", Tool: "search", Server: "repo1-mcp"},
+			{Type: "text", Text: "further"},
+			{Type: "canceled", Usage: &chat.Usage{InputTokens: 10, OutputTokens: 2}},
+		}
+		resp := chatRequest(t, `{"message": "Hi", "agent_file": "capped.chat.yaml"}`)
+		body := resp.Body.String()
+		assert.Equal(t, 2, strings.Count(body, "event: tool_use\n"))
+		assert.Contains(t, body, "event: limit_reached\ndata: {\"type\":\"limit_reached\",\"text\":\"the agent exceeded its limit of 2 tool calls\"}")
+		assert.NotContains(t, body, "further")
+		assert.NotContains(t, body, "event: message_complete")
+
+		// the partial reply is kept and billed
+		require.Len(t, fake.conv.Messages, 2)
+		assistant := fake.conv.Messages[1]
+		assert.Equal(t, "Looking ", assistant.Content)
+		assert.Len(t, assistant.ToolCalls, 2)
+		assert.Equal(t, 10, assistant.Usage.InputTokens)
+	})
+
+	t.Run("Ephemeral", func(t *testing.T) {
+		_, err := files_service.ChangeRepoFiles(t.Context(), repo, doer, &files_service.ChangeRepoFilesOptions{
+			Files: []*files_service.ChangeRepoFile{{
+				Operation: "create",
+				TreePath:  "saved.chat.yaml",
+				ContentReader: strings.NewReader(`version: "1.0"
+ui:
+  name: Saved Assistant
+llm:
+  provider: openai
+  model: gpt-test
+  api_key_ref: CHAT_TEST_API_KEY
+history:
+  enabled: true
+`),
+			}},
+			OldBranch: repo.DefaultBranch,
+			NewBranch: repo.DefaultBranch,
+			Message:   "Add chat agent with history",
+		})
+		require.NoError(t, err)
+
+		buf := chat.GetBuffer(repo.ID)
+		buf.DrainConversations()
+		monthlyCost.Delete(repo.ID)
+		defer monthlyCost.Delete(repo.ID)
+		monthlyRequests := func() int {
+			return chatStats(repo.ID, nil, time.Now()).MonthRequests
+		}
+
+		fake.events = []chat.SSEEvent{
+			{Type: "text", Text: "Noted"},
+			{Type: "done", Usage: &chat.Usage{InputTokens: 10, OutputTokens: 2, CostUSD: 0.01}},
+		}
+		resp := chatRequest(t, `{"message": "My password is hunter2", "agent_file": "saved.chat.yaml", "ephemeral": true}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		body := resp.Body.String()
+		assert.Contains(t, body, "event: message_delta\ndata: {\"type\":\"text\",\"text\":\"Noted\"}")
+		assert.Contains(t, body, "event: message_complete\ndata: {\"type\":\"done\",\"usage\"")
+		assert.Empty(t, fake.conv.ID)
+		assert.Empty(t, buf.DrainConversations(), "ephemeral conversations must not be buffered")
+		assert.Equal(t, 1, monthlyRequests(), "ephemeral messages are still billed")
+
+		resp = chatRequest(t, `{"message": "Hi", "agent_file": "saved.chat.yaml"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		buffered := buf.DrainConversations()
+		require.Len(t, buffered, 1)
+		assert.Equal(t, fake.conv.ID, buffered[0].ID)
+		assert.Equal(t, 2, monthlyRequests())
+
+		resp = chatRequest(t, `{"message": "Hi", "conversation_id": "conv_12345678", "ephemeral": true}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Keepalive", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Chat.SSEKeepaliveInterval, 10*time.Millisecond)()
+		fake.delay = 100 * time.Millisecond
+		defer func() { fake.delay = 0 }()
+		fake.events = []chat.SSEEvent{
+			{Type: "text", Text: "Thought it over"},
+			{Type: "done", Usage: &chat.Usage{InputTokens: 10, OutputTokens: 2}},
+		}
+		resp := chatRequest(t, `{"message": "Hi"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		body := resp.Body.String()
+		assert.GreaterOrEqual(t, strings.Count(body, ": keepalive\n\n"), 2)
+		// keepalives are only sent while waiting, not after the reply
+		assert.True(t, strings.HasPrefix(body, ": keepalive\n\n"))
+		assert.Greater(t, strings.Index(body, "event: message_complete"), strings.LastIndex(body, ": keepalive"))
+	})
+
+	t.Run("MessageTooLong", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Chat.MaxMessageLength, 5)()
+		providerName = ""
+
+		resp := chatRequest(t, `{"message": "Hello world"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "message is too long (max 5 characters)")
+		assert.Empty(t, providerName, "no provider may be created for a rejected message")
+
+		// the limit counts characters, not bytes
+		fake.events = []chat.SSEEvent{{Type: "done", Usage: &chat.Usage{}}}
+		resp = chatRequest(t, `{"message": "Grüße"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("AgentMismatch", func(t *testing.T) {
+		// the earlier subtests have used up most of the per-minute rate limit
+		rateLimits.Delete("1:2")
+		conversation := func(id, agentFile string) string {
+			return `{"id": "` + id + `", "agent_config": "` + agentFile + `", "created_at": "2026-02-01T10:00:00Z", "user": {"id": "2"},
+"messages": [{"role": "user", "content": "Hi"}, {"role": "assistant", "content": "Hello"}]}`
+		}
+		commitChatHistory(t, map[string]string{
+			"_index.json": `{"version": "1", "conversations": [
+{"id": "conv_same", "user_hash": "2", "created_at": "2026-02-01T10:00:00Z"},
+{"id": "conv_other", "user_hash": "2", "created_at": "2026-02-01T10:00:00Z"}]}`,
+			"2026/02/01/conv_same.json":  conversation("conv_same", "agent.chat.yaml"),
+			"2026/02/01/conv_other.json": conversation("conv_other", "other.chat.yaml"),
+		})
+		fake.events = []chat.SSEEvent{{Type: "done", Usage: &chat.Usage{}}}
+
+		resp := chatRequest(t, `{"message": "And then?", "conversation_id": "conv_same"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "conv_same", fake.conv.ID)
+		assert.Len(t, fake.conv.Messages, 4)
+
+		providerName = ""
+		resp = chatRequest(t, `{"message": "And then?", "conversation_id": "conv_other"}`)
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "conversation conv_other belongs to agent other.chat.yaml, not agent.chat.yaml")
+		assert.Empty(t, providerName)
+
+		defer test.MockVariableValue(&setting.Chat.NewConversationOnAgentChange, true)()
+		resp = chatRequest(t, `{"message": "And then?", "conversation_id": "conv_other"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NotEqual(t, "conv_other", fake.conv.ID)
+		assert.Equal(t, "agent.chat.yaml", fake.conv.AgentConfig)
+		assert.Len(t, fake.conv.Messages, 2)
+	})
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		fake.err = util.NewInvalidArgumentErrorf("attachment missing.png not found")
+		resp := chatRequest(t, `{"message": "Hi", "attachments": ["missing.png"]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "attachment missing.png not found")
+	})
+
+	t.Run("BackendError", func(t *testing.T) {
+		fake.err = errors.New("API request failed: connection refused")
+		resp := chatRequest(t, `{"message": "Hi"}`)
+		assert.Equal(t, http.StatusBadGateway, resp.Code)
+	})
+
+	t.Run("BackendRejectedKey", func(t *testing.T) {
+		fake.err = &chat.UpstreamError{StatusCode: http.StatusUnauthorized, Body: `{"error":{"message":"invalid x-api-key"}}`}
+		resp := chatRequest(t, `{"message": "Hi"}`)
+		assert.Equal(t, http.StatusBadGateway, resp.Code)
+		assert.Contains(t, resp.Body.String(), "chat is misconfigured, contact the operator")
+		assert.NotContains(t, resp.Body.String(), "x-api-key")
+	})
+}
+
+func TestChatStats(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	// repo1 is shared with other tests tracking costs
+	monthlyCost.Delete(int64(1))
+	defer monthlyCost.Delete(int64(1))
+
+	commitChatHistory(t, map[string]string{
+		"_index.json": `{"version": "1", "total_conversations": 3, "total_messages": 14, "total_cost_usd": 2.75, "conversations": []}`,
+	})
+
+	statsRequest := func(t *testing.T, userID int64) *httptest.ResponseRecorder {
+		ctx, resp := contexttest.MockContext(t, "GET /user2/repo1/chat/stats")
+		contexttest.LoadUser(t, ctx, userID)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		ChatStats(ctx)
+		return resp
+	}
+
+	trackCost(1, 0.5)
+	trackCost(1, 0.25)
+
+	resp := statsRequest(t, 2)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var stats chat.Stats
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, time.Now().Format("2006-01"), stats.Month)
+	assert.InDelta(t, 0.75, stats.MonthCostUSD, 1e-9)
+	assert.Equal(t, 2, stats.MonthRequests)
+	assert.InDelta(t, 2.75, stats.TotalCostUSD, 1e-9)
+	assert.Equal(t, 3, stats.TotalConversations)
+	assert.Equal(t, 14, stats.TotalMessages)
+
+	// the monthly figures start over in the next month
+	next := chatStats(1, nil, time.Now().AddDate(0, 1, 0))
+	assert.Zero(t, next.MonthCostUSD)
+	assert.Zero(t, next.MonthRequests)
+	assert.Zero(t, next.TotalConversations)
+
+	assert.Equal(t, http.StatusForbidden, statsRequest(t, 4).Code)
+
+	defer test.MockVariableValue(&setting.Chat.Enabled, false)()
+	assert.Equal(t, http.StatusNotFound, statsRequest(t, 2).Code)
+}
+
+// commitChatHistory creates the chat-history branch of repo1 with the given files.
+func commitChatHistory(t *testing.T, files map[string]string) {
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	opts := &files_service.ChangeRepoFilesOptions{
+		OldBranch: repo.DefaultBranch,
+		NewBranch: "chat-history",
+		Message:   "Add chat history",
+	}
+	for treePath, content := range files {
+		opts.Files = append(opts.Files, &files_service.ChangeRepoFile{
+			Operation:     "create",
+			TreePath:      treePath,
+			ContentReader: strings.NewReader(content),
+		})
+	}
+	_, err := files_service.ChangeRepoFiles(t.Context(), repo, doer, opts)
+	require.NoError(t, err)
+}
+
+func TestChatHistorySearch(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	conversation := func(id, day, question string) string {
+		return `{"id": "` + id + `", "created_at": "2026-02-` + day + `T10:00:00Z", "user": {"id": "2"},
+"messages": [{"role": "user", "content": "` + question + `"}, {"role": "assistant", "content": "See the register."}]}`
+	}
+	commitChatHistory(t, map[string]string{
+		"_index.json": `{"version": "1", "conversations": [
+{"id": "conv_a", "title": "GDPR letter classification", "user_hash": "2", "created_at": "2026-02-01T10:00:00Z"},
+{"id": "conv_b", "title": "Ministries overview", "user_hash": "2", "created_at": "2026-02-02T10:00:00Z"},
+{"id": "conv_c", "title": "Budget codes", "user_hash": "2", "created_at": "2026-02-03T10:00:00Z"},
+{"id": "conv_d", "title": "gdpr for another user", "user_hash": "4", "created_at": "2026-02-04T10:00:00Z"}]}`,
+		"2026/02/01/conv_a.json": conversation("conv_a", "01", "Where to classify a GDPR letter?"),
+		"2026/02/02/conv_b.json": conversation("conv_b", "02", "Which ministries handle GDPR requests?"),
+		"2026/02/03/conv_c.json": conversation("conv_c", "03", "List the budget codes"),
+	})
+
+	search := func(t *testing.T, query string) []string {
+		ctx, resp := contexttest.MockContext(t, "GET /user2/repo1/chat/history?"+query)
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		ChatHistory(ctx)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var summaries []chat.ConversationSummary
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &summaries))
+		ids := []string{}
+		for _, summary := range summaries {
+			ids = append(ids, summary.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"conv_a", "conv_b", "conv_c"}, search(t, ""))
+	assert.Equal(t, []string{"conv_a"}, search(t, "q=gdpr"))
+	assert.Equal(t, []string{"conv_a", "conv_b"}, search(t, "q=gdpr&content=true"))
+	assert.Equal(t, []string{"conv_b"}, search(t, "q=gdpr&content=true&offset=1"))
+	assert.Equal(t, []string{"conv_a", "conv_b"}, search(t, "q=gdpr&content=true&offset=-1"))
+	assert.Equal(t, []string{"conv_a", "conv_b", "conv_c"}, search(t, "offset=-1"))
+	assert.Empty(t, search(t, "q=nothing&content=true"))
+
+	// the title of conv_a matches without reading it, and conv_c uses up the content search
+	defer test.MockVariableValue(&setting.Chat.HistorySearchLimit, 1)()
+	assert.Equal(t, []string{"conv_a"}, search(t, "q=gdpr&content=true"))
+}
+
+func TestChatExportConversation(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	commitChatHistory(t, map[string]string{
+		"_index.json": `{"version": "1", "conversations": [{"id": "conv_export01", "user_hash": "2", "created_at": "2026-02-11T14:30:00Z"}]}`,
+		"2026/02/11/conv_export01.json": `{"id": "conv_export01", "created_at": "2026-02-11T14:30:00Z", "user": {"id": "2", "display_name": "user2"},
+"model": "gpt-test", "messages": [{"role": "user", "content": "Hi"}, {"role": "assistant", "content": "Hello there", "tool_calls": [{"tool": "search", "server": "repo1-mcp"}]}]}`,
+	})
+
+	exportRequest := func(t *testing.T, userID int64, id, format string) *httptest.ResponseRecorder {
+		ctx, resp := contexttest.MockContext(t, "GET /user2/repo1/chat/conversations/"+id+"/export?format="+format)
+		contexttest.LoadUser(t, ctx, userID)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		ctx.SetPathParam("id", id)
+		ChatExportConversation(ctx)
+		return resp
+	}
+
+	t.Run("Markdown", func(t *testing.T) {
+		resp := exportRequest(t, 2, "conv_export01", "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/markdown; charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="conv_export01.md"`, resp.Header().Get("Content-Disposition"))
+		body := resp.Body.String()
+		assert.True(t, strings.HasPrefix(body, "# Hi\n"))
+		assert.Contains(t, body, "## Assistant — ")
+		assert.Contains(t, body, "_Tools called: `search` (repo1-mcp)_\n\nHello there\n")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		resp := exportRequest(t, 2, "conv_export01", "json")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `attachment; filename="conv_export01.json"`, resp.Header().Get("Content-Disposition"))
+		var conv chat.Conversation
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &conv))
+		assert.Equal(t, "conv_export01", conv.ID)
+		assert.Len(t, conv.Messages, 2)
+		assert.Contains(t, resp.Body.String(), "\n  \"id\": \"conv_export01\"")
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		resp := exportRequest(t, 2, "conv_export01", "pdf")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		resp := exportRequest(t, 2, "conv_missing", "")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		resp := exportRequest(t, 4, "conv_export01", "")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		resp := exportRequest(t, 1, "conv_export01", "json")
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("AgentHistoryBranch", func(t *testing.T) {
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+		doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		_, err := files_service.ChangeRepoFiles(t.Context(), repo, doer, &files_service.ChangeRepoFilesOptions{
+			Files: []*files_service.ChangeRepoFile{{
+				Operation: "create",
+				TreePath:  "archive.chat.yaml",
+				ContentReader: strings.NewReader(`version: "1.0"
+ui:
+  name: Archived Assistant
+llm:
+  provider: openai
+  model: gpt-test
+  api_key_ref: CHAT_TEST_API_KEY
+history:
+  enabled: true
+  branch: chat-archive
+`),
+			}},
+			OldBranch: repo.DefaultBranch,
+			NewBranch: repo.DefaultBranch,
+			Message:   "Add chat agent with its own history branch",
+		})
+		require.NoError(t, err)
+
+		// the conversation is on chat-history, not on the agent's branch
+		ctx, resp := contexttest.MockContext(t, "GET /user2/repo1/chat/conversations/conv_export01/export?agent_file=archive.chat.yaml&branch=chat-history")
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		ctx.SetPathParam("id", "conv_export01")
+		ChatExportConversation(ctx)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -4,45 +4,112 @@
 package repo
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"unicode"
 
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/unit"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/mcp"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/services/context"
 )
 
 // MCPEndpoint handles MCP JSON-RPC requests for a repository.
 func MCPEndpoint(ctx *context.Context) {
+	toolCtx := prepareMCPToolContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	// Delegate to MCP transport
+	mcp.ServeHTTP(ctx.Resp, ctx.Req, toolCtx)
+}
+
+// MCPExport streams a generated document of the repository's register, for registers
+// too large to return from the generate_document tool. It takes the same format, type
+// and parent query parameters as the tool.
+func MCPExport(ctx *context.Context) {
+	format := ctx.FormString("format")
+	if format == "" {
+		format = "markdown"
+	}
+	contentType, ok := mcp.DocumentContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "unknown format, use 'markdown' or 'csv'"})
+		return
+	}
+
+	toolCtx := prepareMCPToolContext(ctx)
+	if ctx.Written() {
+		return
+	}
+	// the export serves the generate_document tool's document, so it is disabled along with it
+	if !toolCtx.Config.ToolEnabled("generate_document") {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": "generate_document is disabled for this repository"})
+		return
+	}
+	if !toolCtx.HasIndexedData() {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": mcp.ErrNoIndexedData.Error()})
+		return
+	}
+
+	ext := "md"
+	if format == "csv" {
+		ext = "csv"
+	}
+	ctx.Resp.Header().Set("Content-Type", contentType)
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, ctx.Repo.Repository.Name, ext))
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if err := mcp.WriteDocument(ctx.Resp, toolCtx, format, ctx.FormString("type"), ctx.FormString("parent")); err != nil {
+		// headers are already sent, so the response can only be cut short
+		log.Error("MCP export of %s failed: %v", ctx.Repo.Repository.FullName(), err)
+	}
+}
+
+// prepareMCPToolContext checks access and loads the MCP config and entity index of the
+// commit MCP is served from, see getMCPCommit. It writes an error response if MCP can't be served.
+func prepareMCPToolContext(ctx *context.Context) *mcp.ToolContext {
 	if !setting.MCP.Enabled {
 		ctx.JSON(http.StatusNotFound, map[string]string{"error": "MCP is disabled on this instance"})
-		return
+		return nil
+	}
+	if !checkMCPAccess(ctx) {
+		return nil
 	}
 
-	// Get the default branch commit
-	commit, err := ctx.Repo.GitRepo.GetBranchCommit(ctx.Repo.Repository.DefaultBranch)
+	commit, err := getMCPCommit(ctx)
 	if err != nil {
-		if git.IsErrNotExist(err) {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		} else if git.IsErrNotExist(err) && ctx.FormTrim("ref") != "" {
+			ctx.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("ref %q not found", ctx.FormTrim("ref"))})
+		} else if git.IsErrNotExist(err) {
 			ctx.JSON(http.StatusNotFound, map[string]string{"error": "repository is empty"})
 		} else {
-			ctx.ServerError("GetBranchCommit", err)
+			ctx.ServerError("GetCommit", err)
 		}
-		return
+		return nil
 	}
 
 	// Load MCP config
-	cfg, err := mcp.LoadConfig(commit)
+	cfg, err := mcp.LoadRepoConfig(ctx.Repo.Repository.ID, commit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "failed to load MCP config: " + err.Error(),
 		})
-		return
+		return nil
 	}
 	if cfg == nil {
 		ctx.JSON(http.StatusNotFound, map[string]string{
 			"error": "MCP not enabled for this repository (no processgit.mcp.yaml found)",
 		})
-		return
+		return nil
 	}
 
 	// Build entity index
@@ -51,17 +118,141 @@ func MCPEndpoint(ctx *context.Context) {
 		ctx.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "failed to build index: " + err.Error(),
 		})
-		return
+		return nil
 	}
 
 	// Build tool context
-	toolCtx := &mcp.ToolContext{
+	return &mcp.ToolContext{
 		Config: cfg,
 		Commit: commit,
 		RepoID: ctx.Repo.Repository.ID,
 		Index:  index,
+
+		GitRepo:   ctx.Repo.GitRepo,
+		ExportURL: ctx.Repo.Repository.HTMLURL() + "/mcp/export",
 	}
+}
 
-	// Delegate to MCP transport
-	mcp.ServeHTTP(ctx.Resp, ctx.Req, toolCtx)
+// getMCPCommit returns the commit MCP is served from: the commit of the ref query parameter,
+// a branch, tag or commit ID, so that e.g. staged register changes can be queried, or else the
+// head of the default branch. Indexes are cached by commit ID, so refs don't share them.
+func getMCPCommit(ctx *context.Context) (*git.Commit, error) {
+	ref := ctx.FormTrim("ref")
+	if ref == "" {
+		return ctx.Repo.GitRepo.GetBranchCommit(ctx.Repo.Repository.DefaultBranch)
+	}
+	if strings.ContainsFunc(ref, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return nil, util.NewInvalidArgumentErrorf("invalid ref %q", ref)
+	}
+	return ctx.Repo.GitRepo.GetCommit(ref)
+}
+
+// MCPHealth reports MCP status for a repository as plain JSON, so monitoring can poll it
+// without speaking JSON-RPC or opening an SSE session. A repository without an MCP config
+// is still healthy and reports config_loaded=false; failing to load or index the config
+// responds with 503.
+func MCPHealth(ctx *context.Context) {
+	if !checkMCPAccess(ctx) {
+		return
+	}
+
+	health := map[string]any{
+		"enabled":        setting.MCP.Enabled,
+		"config_loaded":  false,
+		"total_entities": 0,
+		"source_files":   []string{},
+		"commit":         "",
+	}
+	if !setting.MCP.Enabled {
+		ctx.JSON(http.StatusOK, health)
+		return
+	}
+
+	commit, err := getMCPCommit(ctx)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			health["error"] = err.Error()
+			ctx.JSON(http.StatusBadRequest, health)
+		} else if git.IsErrNotExist(err) && ctx.FormTrim("ref") != "" {
+			health["error"] = fmt.Sprintf("ref %q not found", ctx.FormTrim("ref"))
+			ctx.JSON(http.StatusNotFound, health)
+		} else if git.IsErrNotExist(err) {
+			ctx.JSON(http.StatusOK, health)
+		} else {
+			ctx.ServerError("GetCommit", err)
+		}
+		return
+	}
+	health["commit"] = commit.ID.String()
+
+	cfg, err := mcp.LoadRepoConfig(ctx.Repo.Repository.ID, commit)
+	if err != nil {
+		health["error"] = "failed to load MCP config: " + err.Error()
+		ctx.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	if cfg == nil {
+		ctx.JSON(http.StatusOK, health)
+		return
+	}
+	health["config_loaded"] = true
+
+	sourceFiles := make([]string, 0, len(cfg.Sources))
+	for _, source := range cfg.Sources {
+		sourceFiles = append(sourceFiles, source.Path)
+	}
+	health["source_files"] = sourceFiles
+
+	index, err := mcp.GetOrBuildIndex(ctx.Repo.Repository.ID, commit, cfg)
+	if err != nil {
+		health["error"] = "failed to build index: " + err.Error()
+		ctx.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	health["total_entities"] = index.Stats.TotalEntities
+
+	ctx.JSON(http.StatusOK, health)
+}
+
+// checkMCPAccess ensures the caller may read the repository code. Callers authenticate with a
+// session or with an access token sent as "Authorization: Bearer <token>"; anonymous callers
+// are only served public repositories, and only when [mcp] ALLOW_ANONYMOUS is enabled.
+// It writes the error response and returns false when access is denied.
+func checkMCPAccess(ctx *context.Context) bool {
+	if !ctx.IsSigned {
+		if !setting.MCP.AllowAnonymous || !ctx.Repo.CanRead(unit.TypeCode) {
+			ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="`+setting.AppName+`"`)
+			ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+			return false
+		}
+		return true
+	}
+
+	if !ctx.Repo.CanRead(unit.TypeCode) {
+		ctx.JSON(http.StatusForbidden, map[string]string{"error": "no read access to repository code"})
+		return false
+	}
+
+	// access tokens must carry the read:repository scope, and public-only tokens can't read private repos
+	if scope, ok := ctx.Data["ApiTokenScope"].(auth_model.AccessTokenScope); ok {
+		publicOnly, err := scope.PublicOnly()
+		if err != nil {
+			ctx.ServerError("PublicOnly", err)
+			return false
+		}
+		if publicOnly && ctx.Repo.Repository.IsPrivate {
+			ctx.JSON(http.StatusForbidden, map[string]string{"error": "token is limited to public repositories"})
+			return false
+		}
+		hasScope, err := scope.HasScope(auth_model.AccessTokenScopeReadRepository)
+		if err != nil {
+			ctx.ServerError("HasScope", err)
+			return false
+		}
+		if !hasScope {
+			ctx.JSON(http.StatusForbidden, map[string]string{"error": "token requires the read:repository scope"})
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,81 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/services/contexttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPHealth(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	healthOf := func(t *testing.T, path string) (int, map[string]any) {
+		ctx, resp := contexttest.MockContext(t, path)
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		MCPHealth(ctx)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		return ctx.Resp.WrittenStatus(), body
+	}
+	health := func(t *testing.T) (int, map[string]any) {
+		return healthOf(t, "user2/repo1/mcp/health")
+	}
+
+	t.Run("NoConfig", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.MCP.Enabled, true)()
+
+		status, body := health(t)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, true, body["enabled"])
+		assert.Equal(t, false, body["config_loaded"])
+		assert.EqualValues(t, 0, body["total_entities"])
+		assert.Empty(t, body["source_files"])
+		assert.NotEmpty(t, body["commit"])
+	})
+
+	t.Run("Ref", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.MCP.Enabled, true)()
+
+		_, defaultBody := health(t)
+		status, body := healthOf(t, "user2/repo1/mcp/health?ref=branch2")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, body["commit"])
+		assert.NotEqual(t, defaultBody["commit"], body["commit"])
+
+		// a commit ID selects that commit
+		status, body = healthOf(t, "user2/repo1/mcp/health?ref="+defaultBody["commit"].(string))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, defaultBody["commit"], body["commit"])
+
+		status, body = healthOf(t, "user2/repo1/mcp/health?ref=no-such-branch")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, `ref "no-such-branch" not found`, body["error"])
+
+		status, _ = healthOf(t, "user2/repo1/mcp/health?ref=master%0Abranch2")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.MCP.Enabled, false)()
+
+		status, body := health(t)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, false, body["enabled"])
+		assert.Equal(t, false, body["config_loaded"])
+	})
+}
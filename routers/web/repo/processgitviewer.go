@@ -5,16 +5,22 @@ package repo
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/modules/charset"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/httpcache"
+	"code.gitea.io/gitea/modules/processgitviewer"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/forms"
+	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
 // ProcessGitViewerContent returns repository file content for ProcessGit viewers.
@@ -63,6 +69,18 @@ func ProcessGitViewerContent(ctx *context.Context) {
 		return
 	}
 
+	// content at a full commit SHA never changes, so it can be cached by its blob ID;
+	// branches and tags move, so their content must always be revalidated
+	var etag string
+	if git.IsStringLikelyCommitID(ctx.Repo.GetObjectFormat(), ref) {
+		etag = `"` + entry.ID.String() + `"`
+		if httpcache.HandleImmutableETagCache(ctx.Req, ctx.Resp, etag) {
+			return
+		}
+	} else {
+		httpcache.SetCacheControlInHeader(ctx.Resp.Header(), &httpcache.CacheControlOptions{})
+	}
+
 	blob := entry.Blob()
 
 	prefetchBuf, dataRc, fInfo, err := getFileReader(ctx, ctx.Repo.Repository.ID, blob)
@@ -79,6 +97,19 @@ func ProcessGitViewerContent(ctx *context.Context) {
 
 	reader := io.MultiReader(bytes.NewReader(prefetchBuf), dataRc)
 	if fInfo.st.IsRepresentableAsText() {
+		ctx.Resp.Header().Set("Accept-Ranges", "bytes")
+		if rangeHeader := ctx.Req.Header.Get("Range"); rangeHeader != "" {
+			start, end, ok, valid := parseProcessGitViewerRange(rangeHeader, fInfo.blobOrLfsSize)
+			if !valid {
+				ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", fInfo.blobOrLfsSize))
+				ctx.Status(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			if ok {
+				serveProcessGitViewerRange(ctx, reader, prefetchBuf, start, end, fInfo.blobOrLfsSize, etag)
+				return
+			}
+		}
 		reader = charset.ToUTF8WithFallbackReader(reader, charset.ConvertOpts{})
 	}
 
@@ -88,9 +119,131 @@ func ProcessGitViewerContent(ctx *context.Context) {
 		return
 	}
 
+	if etag != "" {
+		httpcache.SetImmutableCacheHeaders(ctx.Resp.Header(), etag)
+	}
 	ctx.JSON(http.StatusOK, map[string]string{
 		"content": string(content),
 		"path":    path.Clean(cleanPath),
 		"ref":     ref,
 	})
 }
+
+// parseProcessGitViewerRange parses a single "bytes=" range against the content size. ok is false
+// when the header should be ignored (other units or multiple ranges), valid is false when the range
+// cannot be satisfied.
+func parseProcessGitViewerRange(rangeHeader string, size int64) (start, end int64, ok, valid bool) {
+	rangeSpec, found := strings.CutPrefix(rangeHeader, "bytes=")
+	if !found || strings.Contains(rangeSpec, ",") {
+		return 0, 0, false, true
+	}
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(rangeSpec), "-")
+	if !found || size <= 0 {
+		return 0, 0, false, false
+	}
+
+	if startStr == "" {
+		// suffix range: the last N bytes
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, 0, false, false
+		}
+		return max(size-suffix, 0), size - 1, true, true
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false, false
+	}
+	end = size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true, true
+}
+
+// serveProcessGitViewerRange writes the raw bytes start-end of the stored file, so that the viewer can
+// load large files progressively. Offsets refer to the stored bytes, no charset conversion is done.
+// A non-empty etag marks the partial content as immutable.
+func serveProcessGitViewerRange(ctx *context.Context, reader io.Reader, prefetchBuf []byte, start, end, size int64, etag string) {
+	if _, err := io.CopyN(io.Discard, reader, start); err != nil {
+		ctx.ServerError("CopyN", err)
+		return
+	}
+
+	contentType := "text/plain"
+	if encoding, _ := charset.DetectEncoding(prefetchBuf); encoding != "" {
+		contentType += "; charset=" + strings.ToLower(encoding)
+	}
+	ctx.Resp.Header().Set("Content-Type", contentType)
+	ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	if etag != "" {
+		httpcache.SetImmutableCacheHeaders(ctx.Resp.Header(), etag)
+	}
+	ctx.Resp.WriteHeader(http.StatusPartialContent)
+	_, _ = io.CopyN(ctx.Resp, reader, end-start+1)
+}
+
+// ProcessGitViewerSavePost saves a file edited in a ProcessGit viewer. The URL path is the primary
+// file the viewer is bound to, the form tree_path is the file being written, which must be listed
+// in the resolved binding's edit_allow.
+func ProcessGitViewerSavePost(ctx *context.Context) {
+	parsed := prepareEditorCommitSubmittedForm[*forms.EditRepoFileForm](ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if !parsed.form.Content.Has() {
+		ctx.JSONError(ctx.Tr("error.occurred"))
+		return
+	}
+
+	dir := path.Dir(ctx.Repo.TreePath)
+	if dir == "." {
+		dir = ""
+	}
+	manifest, _, err := processgitviewer.LoadManifestFromDir(ctx.Repo.Commit, dir)
+	if err != nil {
+		ctx.JSONError(err.Error())
+		return
+	}
+	binding, err := processgitviewer.ResolveBinding(ctx.Repo.Commit, dir, ctx.Repo.TreePath, manifest)
+	if err != nil {
+		ctx.JSONError(err.Error())
+		return
+	}
+	if binding == nil || !binding.CanEdit(dir, parsed.form.TreePath) {
+		ctx.JSONError(ctx.Tr("error.not_found"))
+		return
+	}
+
+	_, err = files_service.ChangeRepoFiles(ctx, ctx.Repo.Repository, ctx.Doer, &files_service.ChangeRepoFilesOptions{
+		LastCommitID: parsed.form.LastCommit,
+		OldBranch:    parsed.OldBranchName,
+		NewBranch:    parsed.NewBranchName,
+		Message:      parsed.GetCommitMessage(ctx.Locale.TrString("repo.editor.update", parsed.form.TreePath)),
+		Files: []*files_service.ChangeRepoFile{
+			{
+				Operation:     "update",
+				FromTreePath:  parsed.form.TreePath,
+				TreePath:      parsed.form.TreePath,
+				ContentReader: strings.NewReader(strings.ReplaceAll(parsed.form.Content.Value(), "\r", "")),
+			},
+		},
+		Signoff:   parsed.form.Signoff,
+		Author:    parsed.GitCommitter,
+		Committer: parsed.GitCommitter,
+	})
+	if err != nil {
+		editorHandleFileOperationError(ctx, parsed.NewBranchName, err)
+		return
+	}
+
+	// return to the viewer of the primary file, even when a related file was saved
+	redirectForCommitChoice(ctx, parsed, ctx.Repo.TreePath)
+}
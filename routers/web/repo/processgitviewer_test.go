@@ -0,0 +1,136 @@
+// Copyright 2025 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/services/contexttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessGitViewerContentCache(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	content := func(t *testing.T, ref, ifNoneMatch string) *http.Response {
+		params := url.Values{"path": {"README.md"}, "ref": {ref}}
+		ctx, resp := contexttest.MockContext(t, "user2/repo1/api/processgitviewer?"+params.Encode())
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		if ifNoneMatch != "" {
+			ctx.Req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		ProcessGitViewerContent(ctx)
+		return resp.Result()
+	}
+
+	t.Run("CommitSHA", func(t *testing.T) {
+		commitID := "65f1bf27bc3bf70f64657658635e66094edbcb4d" // master of repo1
+
+		resp := content(t, commitID, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag := resp.Header.Get("Etag")
+		require.NotEmpty(t, etag)
+		assert.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+
+		resp = content(t, commitID, etag)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+		assert.Equal(t, etag, resp.Header.Get("Etag"))
+	})
+
+	t.Run("CommitSHAError", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.UI.MaxDisplayFileSize, 10)()
+
+		// errors aren't marked immutable
+		resp := content(t, "65f1bf27bc3bf70f64657658635e66094edbcb4d", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Etag"))
+		assert.NotContains(t, resp.Header.Get("Cache-Control"), "immutable")
+	})
+
+	t.Run("Branch", func(t *testing.T) {
+		resp := content(t, "master", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Etag"))
+		assert.NotContains(t, resp.Header.Get("Cache-Control"), "immutable")
+		assert.Contains(t, resp.Header.Get("Cache-Control"), "max-age=0")
+	})
+}
+
+func TestParseProcessGitViewerRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		ok, valid  bool
+	}{
+		{"bytes=0-9", 0, 9, true, true},
+		{"bytes=10-", 10, 29, true, true},
+		{"bytes=20-100", 20, 29, true, true},
+		{"bytes=-5", 25, 29, true, true},
+		{"bytes=-100", 0, 29, true, true},
+		{"bytes=0-1, 5-6", 0, 0, false, true},
+		{"items=0-1", 0, 0, false, true},
+		{"bytes=30-", 0, 0, false, false},
+		{"bytes=5-2", 0, 0, false, false},
+		{"bytes=abc", 0, 0, false, false},
+	}
+	for _, tc := range tests {
+		start, end, ok, valid := parseProcessGitViewerRange(tc.header, 30)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.valid, valid, tc.header)
+		if tc.ok {
+			assert.Equal(t, tc.start, start, tc.header)
+			assert.Equal(t, tc.end, end, tc.header)
+		}
+	}
+}
+
+func TestProcessGitViewerContentRange(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	content := func(t *testing.T, rangeHeader string) *http.Response {
+		ctx, resp := contexttest.MockContext(t, "user2/repo1/api/processgitviewer?path=README.md&ref=master")
+		contexttest.LoadUser(t, ctx, 2)
+		contexttest.LoadRepo(t, ctx, 1)
+		contexttest.LoadGitRepo(t, ctx)
+		defer ctx.Repo.GitRepo.Close()
+		if rangeHeader != "" {
+			ctx.Req.Header.Set("Range", rangeHeader)
+		}
+		ProcessGitViewerContent(ctx)
+		return resp.Result()
+	}
+
+	t.Run("Partial", func(t *testing.T) {
+		resp := content(t, "bytes=0-6")
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "bytes 0-6/30", resp.Header.Get("Content-Range"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "# repo1", string(body))
+	})
+
+	t.Run("Unsatisfiable", func(t *testing.T) {
+		resp := content(t, "bytes=100-")
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+		assert.Equal(t, "bytes */30", resp.Header.Get("Content-Range"))
+	})
+
+	t.Run("NoRange", func(t *testing.T) {
+		resp := content(t, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		assert.Empty(t, resp.Header.Get("Content-Range"))
+	})
+}
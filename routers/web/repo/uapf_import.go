@@ -7,26 +7,38 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/uapf"
 	"code.gitea.io/gitea/services/context"
 )
 
-// UAPFImportPost handles importing a .uapf package into a repository.
+// UAPFImportPost handles importing a .uapf package into a repository, under the target_path
+// directory if given. With dry_run set, the package is only validated and every problem found
+// is returned as a JSON ValidationReport.
 func UAPFImportPost(ctx *context.Context) {
+	dryRun := ctx.FormBool("dry_run")
+	fail := func(msg string) {
+		if dryRun {
+			ctx.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+			return
+		}
+		ctx.Flash.Error(msg)
+		ctx.Redirect(ctx.Repo.RepoLink)
+	}
+
 	upload, header, err := ctx.Req.FormFile("uapf")
 	if err != nil {
-		ctx.Flash.Error("Could not read the uploaded UAPF package.")
-		ctx.Redirect(ctx.Repo.RepoLink)
+		fail("Could not read the uploaded UAPF package.")
 		return
 	}
 	defer upload.Close()
 
 	filename := header.Filename
 	if !strings.HasSuffix(strings.ToLower(filename), ".uapf") {
-		ctx.Flash.Error("Only .uapf files can be imported.")
-		ctx.Redirect(ctx.Repo.RepoLink)
+		fail("Only .uapf files can be imported.")
 		return
 	}
 
@@ -36,12 +48,32 @@ func UAPFImportPost(ctx *context.Context) {
 		return
 	}
 
-	if err := uapf.ImportUAPF(ctx, ctx.Repo.Repository, ctx.Doer, fmt.Sprintf("Import UAPF package: %s", filename), bytes.NewReader(buffer), int64(len(buffer)), "/"); err != nil {
+	if dryRun {
+		if maxSize := setting.Repository.Upload.FileMaxSize << 20; maxSize > 0 && int64(len(buffer)) > maxSize {
+			fail(fmt.Sprintf("package exceeds maximum size: %d bytes > %d bytes", len(buffer), maxSize))
+			return
+		}
+		report, err := uapf.ValidatePackageReport(buffer)
+		if err != nil {
+			ctx.ServerError("ValidatePackageReport", err)
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+
+	// without a target_path the package is imported into the install_path of its manifest, or the root
+	installPath, err := uapf.ImportUAPF(ctx, ctx.Repo.Repository, ctx.Doer, fmt.Sprintf("Import UAPF package: %s", filename), bytes.NewReader(buffer), int64(len(buffer)), ctx.FormTrim("target_path"))
+	if err != nil {
 		ctx.Flash.Error(err.Error())
 		ctx.Redirect(ctx.Repo.RepoLink)
 		return
 	}
 
-	ctx.Flash.Success(fmt.Sprintf("Imported %s into repository root", filename))
+	if installPath == "" {
+		ctx.Flash.Success(fmt.Sprintf("Imported %s into repository root", filename))
+	} else {
+		ctx.Flash.Success(fmt.Sprintf("Imported %s into %s", filename, installPath))
+	}
 	ctx.Redirect(ctx.Repo.RepoLink)
 }
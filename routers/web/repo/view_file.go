@@ -407,10 +407,6 @@ func prepareFileView(ctx *context.Context, entry *git.TreeEntry) {
 				}
 				primaryRawURL := ctx.Repo.RepoLink + "/raw/" + ctx.Repo.RefTypeNameSubURL() + "/" + util.PathEscapeSegments(ctx.Repo.TreePath)
 				targetsRaw["xml"] = primaryRawURL
-				editAllowRepoPaths := make([]string, 0, len(binding.EditAllow))
-				for _, edit := range binding.EditAllow {
-					editAllowRepoPaths = append(editAllowRepoPaths, path.Join(dir, edit))
-				}
 				apiParams := url.Values{}
 				apiParams.Set("path", ctx.Repo.TreePath)
 				if ctx.Repo.BranchName != "" {
@@ -429,7 +425,7 @@ func prepareFileView(ctx *context.Context, entry *git.TreeEntry) {
 					LastCommit:  ctx.Repo.CommitID,
 					EntryRawURL: entryRawURL,
 					Targets:     targetsRaw,
-					EditAllow:   editAllowRepoPaths,
+					EditAllow:   binding.EditAllowPaths(dir),
 					APIURL:      apiURL,
 				}
 			}
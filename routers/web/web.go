@@ -1186,7 +1186,9 @@ func registerWebRoutes(m *web.Router) {
 
 	// MCP endpoint — Model Context Protocol server for repository
 	m.Group("/{username}/{reponame}/mcp", func() {
-		m.Methods("GET, POST, OPTIONS", "", repo.MCPEndpoint)
+		m.Methods("GET, HEAD, POST, OPTIONS", "", repo.MCPEndpoint)
+		m.Get("/health", repo.MCPHealth)
+		m.Get("/export", repo.MCPExport)
 	}, optSignInIgnoreCsrf, context.RepoAssignment)
 
 	// Chat agent endpoints — AI chatbot interface for repositories
@@ -1194,6 +1196,8 @@ func registerWebRoutes(m *web.Router) {
 		m.Post("", repo.ChatEndpoint)
 		m.Get("/agents", repo.ChatAgents)
 		m.Get("/history", repo.ChatHistory)
+		m.Get("/stats", repo.ChatStats)
+		m.Get("/conversations/{id}/export", repo.ChatExportConversation)
 	}, optSignInIgnoreCsrf, context.RepoAssignment)
 
 	m.Group("/{username}/{reponame}", func() {
@@ -1367,6 +1371,7 @@ func registerWebRoutes(m *web.Router) {
 				m.Combo("/{editor_action:_cherrypick}/{sha:([a-f0-9]{7,64})}/*").
 					Get(repo.CherryPick).
 					Post(web.Bind(forms.CherryPickForm{}), canWriteToBranch, repo.CherryPickPost)
+				m.Post("/{editor_action:_processgitviewer_save}/*", web.Bind(forms.EditRepoFileForm{}), canWriteToBranch, repo.ProcessGitViewerSavePost)
 			}, context.RepoRefByType(git.RefTypeBranch), repo.WebGitOperationCommonData)
 			m.Group("", func() {
 				m.Post("/upload-file", repo.UploadFileToServer)
@@ -27,6 +27,7 @@ type globalVarsStruct struct {
 	gitRawOrAttachPathRe *regexp.Regexp
 	lfsPathRe            *regexp.Regexp
 	archivePathRe        *regexp.Regexp
+	mcpPathRe            *regexp.Regexp
 }
 
 var globalVars = sync.OnceValue(func() *globalVarsStruct {
@@ -34,6 +35,7 @@ var globalVars = sync.OnceValue(func() *globalVarsStruct {
 		gitRawOrAttachPathRe: regexp.MustCompile(`^/[-.\w]+/[-.\w]+/(?:(?:git-(?:(?:upload)|(?:receive))-pack$)|(?:info/refs$)|(?:HEAD$)|(?:objects/)|(?:raw/)|(?:releases/download/)|(?:attachments/))`),
 		lfsPathRe:            regexp.MustCompile(`^/[-.\w]+/[-.\w]+/info/lfs/`),
 		archivePathRe:        regexp.MustCompile(`^/[-.\w]+/[-.\w]+/archive/`),
+		mcpPathRe:            regexp.MustCompile(`^/[-.\w]+/[-.\w]+/mcp(?:/health|/export)?$`),
 	}
 })
 
@@ -99,6 +101,11 @@ func (a *authPathDetector) isArchivePath() bool {
 	return a.vars.archivePathRe.MatchString(a.req.URL.Path)
 }
 
+// isMCPPath checks if the request targets a repository MCP endpoint, which headless agents call with a token
+func (a *authPathDetector) isMCPPath() bool {
+	return a.vars.mcpPathRe.MatchString(a.req.URL.Path)
+}
+
 func (a *authPathDetector) isAuthenticatedTokenRequest() bool {
 	switch a.req.URL.Path {
 	case "/login/oauth/userinfo", "/login/oauth/introspect":
@@ -131,3 +131,19 @@ func Test_isGitRawOrLFSPath(t *testing.T) {
 	}
 }
 
+func Test_isMCPPath(t *testing.T) {
+	tests := map[string]bool{
+		"/owner/repo/mcp":        true,
+		"/owner/my.repo/mcp":     true,
+		"/owner/repo/mcp/health": true,
+		"/owner/repo/mcp/export": true,
+		"/owner/repo/mcp/extra":  false,
+		"/owner/repo":            false,
+		"/owner/mcp":             false,
+		"/api/v1/owner/repo/mcp": false,
+	}
+	for path, want := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost"+path, nil)
+		assert.Equal(t, want, newAuthPathDetector(req).isMCPPath(), path)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/modules/chat"
+	secret_module "code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ResolveAPIKey resolves the api_key_ref of a chat agent of repo. A "repo:<name>" reference is
+// looked up in the secrets of the repository and an "org:<owner>:<name>" one in the secrets of
+// the repository's owner, which is the only owner it may name. If that secret doesn't exist,
+// <name> is resolved from the environment and app.ini like a plain reference.
+func ResolveAPIKey(ctx context.Context, repo *repo_model.Repository, ref string) (string, error) {
+	secretRef, ok, err := chat.ParseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return chat.ResolveAPIKey(ref)
+	}
+
+	opts := secret_model.FindSecretsOptions{RepoID: repo.ID, Name: secretRef.Name}
+	if secretRef.Owner != "" {
+		if !strings.EqualFold(secretRef.Owner, repo.OwnerName) {
+			return "", fmt.Errorf("api_key_ref %q: only secrets of the repository owner %s can be used", ref, repo.OwnerName)
+		}
+		opts = secret_model.FindSecretsOptions{OwnerID: repo.OwnerID, Name: secretRef.Name}
+	}
+
+	secrets, err := db.Find[secret_model.Secret](ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to find secret %s: %w", secretRef.Name, err)
+	}
+	if len(secrets) == 0 {
+		if key, err := chat.ResolveAPIKey(secretRef.Name); err == nil {
+			return key, nil
+		}
+		return "", fmt.Errorf("API key not found for ref %q: add the secret to the repository or its owner, set as environment variable or add to [chat] section in app.ini", ref)
+	}
+
+	key, err := secret_module.DecryptSecret(setting.SecretKey, secrets[0].Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", secretRef.Name, err)
+	}
+	return key, nil
+}
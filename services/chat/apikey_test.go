@@ -0,0 +1,61 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 3}) // org3/repo3
+	otherRepo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+
+	_, err := secret_model.InsertEncryptedSecret(t.Context(), 0, repo.ID, "ANTHROPIC_KEY", "sk-repo", "")
+	require.NoError(t, err)
+	_, err = secret_model.InsertEncryptedSecret(t.Context(), repo.OwnerID, 0, "ANTHROPIC_KEY", "sk-org", "")
+	require.NoError(t, err)
+
+	t.Run("RepoSecret", func(t *testing.T) {
+		key, err := ResolveAPIKey(t.Context(), repo, "repo:anthropic_key")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-repo", key)
+	})
+
+	t.Run("OrgSecret", func(t *testing.T) {
+		key, err := ResolveAPIKey(t.Context(), repo, "org:org3:ANTHROPIC_KEY")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-org", key)
+	})
+
+	t.Run("OtherOwner", func(t *testing.T) {
+		_, err := ResolveAPIKey(t.Context(), otherRepo, "org:org3:ANTHROPIC_KEY")
+		assert.ErrorContains(t, err, "only secrets of the repository owner user2 can be used")
+	})
+
+	t.Run("FallbackToEnv", func(t *testing.T) {
+		_, err := ResolveAPIKey(t.Context(), otherRepo, "repo:ANTHROPIC_KEY")
+		assert.ErrorContains(t, err, "API key not found")
+
+		t.Setenv("ANTHROPIC_KEY", "sk-env")
+		key, err := ResolveAPIKey(t.Context(), otherRepo, "repo:ANTHROPIC_KEY")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-env", key)
+	})
+
+	t.Run("PlainRef", func(t *testing.T) {
+		t.Setenv("CHAT_TEST_KEY", "sk-plain")
+		key, err := ResolveAPIKey(t.Context(), repo, "CHAT_TEST_KEY")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-plain", key)
+	})
+}
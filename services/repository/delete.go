@@ -73,6 +73,9 @@ func DeleteRepositoryDirectly(ctx context.Context, repoID int64, ignoreOrgTeams
 	if err := repo_model.DeleteRepoClassification(ctx, repoID); err != nil {
 		return err
 	}
+	if err := repo_model.DeleteRepoClassificationHistory(ctx, repoID); err != nil {
+		return err
+	}
 
 	// Query the action tasks of this repo, they will be needed after they have been deleted to remove the logs
 	tasks, err := db.Find[actions_model.ActionTask](ctx, actions_model.FindTaskOptions{RepoID: repoID})
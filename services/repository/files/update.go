@@ -47,6 +47,10 @@ type ChangeRepoFile struct {
 	ContentReader io.ReadSeeker
 	SHA           string
 
+	// EntryMode is the mode of a created file: git.EntryModeExec for an executable, or
+	// git.EntryModeSymlink with the link target as content. A regular file by default.
+	EntryMode git.EntryMode
+
 	DeleteRecursively bool // when deleting, work as `git rm -r ...`
 
 	Options *RepoFileOptions // FIXME: need to refactor, internal usage only
@@ -146,7 +150,7 @@ func ChangeRepoFiles(ctx context.Context, repo *repo_model.Repository, doer *use
 		file.Options = &RepoFileOptions{
 			treePath:     treePath,
 			fromTreePath: fromTreePath,
-			executable:   false,
+			executable:   file.EntryMode.IsExecutable(),
 		}
 		treePaths = append(treePaths, treePath)
 	}
@@ -505,7 +509,11 @@ func modifyFile(ctx context.Context, t *TemporaryUploadRepository, file *ChangeR
 	}
 
 	// Add the object to the index, the "file.Options.executable" is set in handleCheckErrors by the caller (legacy hacky approach)
-	if err = t.AddObjectToIndex(ctx, util.Iif(file.Options.executable, "100755", "100644"), writeObjectRet.ObjectHash, file.Options.treePath); err != nil {
+	mode := util.Iif(file.Options.executable, "100755", "100644")
+	if file.EntryMode.IsLink() {
+		mode = file.EntryMode.String()
+	}
+	if err = t.AddObjectToIndex(ctx, mode, writeObjectRet.ObjectHash, file.Options.treePath); err != nil {
 		return nil, err
 	}
 
@@ -560,7 +568,7 @@ type writeRepoObjectRet struct {
 func writeRepoObjectForModify(ctx context.Context, t *TemporaryUploadRepository, file *ChangeRepoFile) (ret *writeRepoObjectRet, err error) {
 	ret = &writeRepoObjectRet{}
 	treeObjectContentReader := file.ContentReader
-	if setting.LFS.StartServer {
+	if setting.LFS.StartServer && !file.EntryMode.IsLink() {
 		checkIsLfsFiles, err := checkIsLfsFileInGitAttributes(ctx, t, []string{file.Options.treePath})
 		if err != nil {
 			return nil, err
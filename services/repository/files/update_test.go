@@ -0,0 +1,58 @@
+// Copyright 2026 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package files
+
+import (
+	"strings"
+	"testing"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeRepoFilesEntryMode(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	_, err := ChangeRepoFiles(t.Context(), repo, doer, &ChangeRepoFilesOptions{
+		Files: []*ChangeRepoFile{
+			{Operation: "create", TreePath: "bin/run.sh", ContentReader: strings.NewReader("#!/bin/sh\n"), EntryMode: git.EntryModeExec},
+			{Operation: "create", TreePath: "bin/latest", ContentReader: strings.NewReader("run.sh"), EntryMode: git.EntryModeSymlink},
+			{Operation: "create", TreePath: "bin/README.md", ContentReader: strings.NewReader("# bin\n")},
+		},
+		OldBranch: repo.DefaultBranch,
+		NewBranch: repo.DefaultBranch,
+		Message:   "Add scripts",
+	})
+	require.NoError(t, err)
+
+	gitRepo, err := gitrepo.OpenRepository(t.Context(), repo)
+	require.NoError(t, err)
+	defer gitRepo.Close()
+	commit, err := gitRepo.GetBranchCommit(repo.DefaultBranch)
+	require.NoError(t, err)
+
+	for treePath, mode := range map[string]git.EntryMode{
+		"bin/run.sh":    git.EntryModeExec,
+		"bin/latest":    git.EntryModeSymlink,
+		"bin/README.md": git.EntryModeBlob,
+	} {
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		require.NoError(t, err)
+		assert.Equal(t, mode, entry.Mode(), treePath)
+	}
+
+	target, err := commit.GetTreeEntryByPath("bin/latest")
+	require.NoError(t, err)
+	content, err := target.Blob().GetBlobContent(1024)
+	require.NoError(t, err)
+	assert.Equal(t, "run.sh", content)
+}
@@ -34,7 +34,7 @@ function parsePayload(script: HTMLScriptElement | null): ProcessGitViewerPayload
     }
     return {
       id: raw.id ?? '',
-      type: 'html',
+      type: raw.type ?? 'html',
       repoLink: raw.repoLink ?? '',
       branch: raw.branch ?? '',
       ref: raw.ref ?? '',
@@ -66,8 +66,9 @@ function extractTargetPath(rawUrl: string, payload: ProcessGitViewerPayload): st
   }
 }
 
-function buildSaveUrl(payload: ProcessGitViewerPayload, treePath: string): string {
-  return `${payload.repoLink}/_edit/${encodePath(payload.branch)}/${encodePath(treePath)}`;
+// The URL path is the primary file; the server checks tree_path against the binding's edit_allow.
+function buildSaveUrl(payload: ProcessGitViewerPayload): string {
+  return `${payload.repoLink}/_processgitviewer_save/${encodePath(payload.branch)}/${encodePath(payload.path)}`;
 }
 
 function formatXml(xmlText: string): string {
@@ -173,7 +174,7 @@ async function saveContent(payload: ProcessGitViewerPayload, treePath: string, c
   form.set('tree_path', treePath);
   form.set('content', content);
 
-  const response = await fetch(buildSaveUrl(payload, treePath), {
+  const response = await fetch(buildSaveUrl(payload), {
     method: 'POST',
     headers: {
       'X-Requested-With': 'XMLHttpRequest',
@@ -1,6 +1,6 @@
 export type ProcessGitViewerPayload = {
   id: string;
-  type: 'html';
+  type: 'html' | 'svg' | 'canvas';
   repoLink: string;
   branch: string;
   ref: string;